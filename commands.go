@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"gin-demo/config"
+	"gin-demo/migrations"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"gin-demo/services"
+	"gin-demo/storage"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// runCommand handles the one-off maintenance commands that can be given
+// after the flags, e.g. `go run . -org acme migrate-people`. They work on the
+// catalog of the given organization.
+func runCommand(db *mongo.Database, organization string, args []string) error {
+	if organization != model.DefaultOrganization {
+		if _, err := repository.NewOrganizationRepository(db).GetBySlug(organization); err != nil {
+			return err
+		}
+	}
+	catalog := repository.TenantDatabase(db.Client(), db.Name(), organization)
+
+	switch args[0] {
+	case "migrate-people":
+		report, err := migrations.MergePeople(catalog)
+		if err != nil {
+			return err
+		}
+		return printJSON(report)
+	case "import":
+		return runImport(catalog, args[1:])
+	case "export":
+		return runExport(catalog, args[1:])
+	case "purge-trash":
+		return runPurgeTrash(db, catalog, organization)
+	case "data-quality":
+		return runDataQuality(catalog, args[1:])
+	case "create-admin":
+		return runCreateAdmin(db, args[1:])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// go run . import -kind movies -mode upsert -dry-run movies.csv
+func runImport(db *mongo.Database, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	kind := fs.String("kind", model.ImportKindMovies, "what the file holds: people or movies")
+	format := fs.String("format", "", "csv or jsonl, taken from the file extension when empty")
+	mode := fs.String("mode", model.ImportModeInsert, "insert or upsert")
+	dryRun := fs.Bool("dry-run", false, "validate only, do not write")
+	editor := fs.String("editor", "cli", "who the revisions of the imported records name")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: import [flags] <file>")
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Name())), ".")
+	}
+
+	// Whoever can reach the database directly is trusted like an admin, so
+	// the import leaves published movies published.
+	service := services.NewImportService(
+		repository.NewImportRepository(db),
+		services.NewRevisionService(repository.NewRevisionRepository(db)),
+	)
+	report, err := service.Import(*kind, *format, *mode, *dryRun, file, model.RoleAdmin, *editor)
+	if err != nil {
+		if report != nil {
+			printJSON(report)
+		}
+		return err
+	}
+	return printJSON(report)
+}
+
+// go run . export -kind movies -format csv -hydrate -out movies.csv
+func runExport(db *mongo.Database, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	kind := fs.String("kind", services.ExportKindMovies, "movies, actors, directors or people")
+	format := fs.String("format", model.FormatJSONL, "csv or jsonl")
+	hydrate := fs.Bool("hydrate", false, "add director and actor names to movies")
+	director := fs.String("director-id", "", "only movies by this director")
+	actor := fs.String("actor-id", "", "only movies with this actor")
+	outPath := fs.String("out", "", "file to write, stdout when empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	filter := services.ExportFilter{Hydrate: *hydrate}
+	var err error
+	if *director != "" {
+		if filter.DirectorID, err = primitive.ObjectIDFromHex(*director); err != nil {
+			return fmt.Errorf("invalid director id: %w", err)
+		}
+	}
+	if *actor != "" {
+		if filter.ActorID, err = primitive.ObjectIDFromHex(*actor); err != nil {
+			return fmt.Errorf("invalid actor id: %w", err)
+		}
+	}
+
+	out := os.Stdout
+	if *outPath != "" {
+		if out, err = os.Create(*outPath); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	service := services.NewExportService(
+		repository.NewExportRepository(db),
+		repository.NewPersonRepository(db),
+		repository.NewAwardRepository(db),
+	)
+	return service.Export(*kind, *format, filter, out)
+}
+
+// go run . purge-trash removes what has outlived the trash retention period
+// right away instead of waiting for the server's purge job. Users live in
+// the shared database, everything else in the organization's catalog.
+func runPurgeTrash(db, catalog *mongo.Database, organization string) error {
+	files, err := storage.NewStorage(config.GetConfig().Storage)
+	if err != nil {
+		return err
+	}
+	movies := repository.NewMovieRepository(catalog)
+	people := repository.NewPersonRepository(catalog)
+	collections := repository.NewCollectionRepository(catalog)
+	revisions := services.NewRevisionService(repository.NewRevisionRepository(catalog))
+	users := repository.NewUserRepository(db)
+	service := services.NewTrashService(
+		movies,
+		people,
+		users,
+		collections,
+		repository.NewAwardRepository(catalog),
+		services.NewImageService(movies, people, collections, files, revisions),
+		revisions,
+		config.GetConfig().Trash.Retention(),
+		organization,
+	)
+	report, err := service.Purge()
+	if err != nil {
+		return err
+	}
+	purged, err := services.NewUserTrashService(users, config.GetConfig().Trash.Retention()).Purge()
+	if err != nil {
+		return err
+	}
+	report.Users = purged.Users
+	return printJSON(report)
+}
+
+// go run . data-quality reports the problems in the catalog, and with
+// -fix missing_actor,duplicate_person repairs the fixable findings of those
+// codes. -ids picks single findings by the id the report gave them.
+func runDataQuality(db *mongo.Database, args []string) error {
+	fs := flag.NewFlagSet("data-quality", flag.ContinueOnError)
+	codes := fs.String("fix", "", "comma-separated codes of the findings to fix")
+	ids := fs.String("ids", "", "comma-separated ids of the findings to fix")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	service := services.NewQualityService(
+		repository.NewQualityRepository(db),
+		repository.NewMovieRepository(db),
+		repository.NewPersonRepository(db),
+		services.NewRevisionService(repository.NewRevisionRepository(db)),
+	)
+	if *codes == "" && *ids == "" {
+		report, err := service.Report()
+		if err != nil {
+			return err
+		}
+		return printJSON(report)
+	}
+
+	req := &model.QualityFixRequest{IDs: splitList(*ids), Codes: splitList(*codes)}
+	result, err := service.Fix(req, "data-quality")
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
+
+// echo "$PASSWORD" | go run . create-admin -username admin -email admin@example.com
+// creates an admin, who is also an admin of the default organization and
+// can then register editors and further admins through the API. The password is read from stdin so it stays out of the process
+// list and the shell history.
+func runCreateAdmin(db *mongo.Database, args []string) error {
+	fs := flag.NewFlagSet("create-admin", flag.ContinueOnError)
+	username := fs.String("username", "", "username of the admin")
+	email := fs.String("email", "", "email the admin logs in with")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return fmt.Errorf("usage: create-admin -username <name> -email <email> < password")
+	}
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return fmt.Errorf("the password is read from stdin and must not be empty")
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	user := &model.User{
+		Username:    *username,
+		Email:       *email,
+		Password:    string(hashed),
+		Role:        model.RoleAdmin,
+		Memberships: []model.Membership{{Organization: model.DefaultOrganization, Role: model.RoleAdmin}},
+	}
+	if err := repository.NewUserRepository(db).CreateUser(user); err != nil {
+		return err
+	}
+	return printJSON(user)
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
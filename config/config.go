@@ -4,19 +4,225 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 )
 
 var Env string = "development"
 
+// DBConfig points at the MongoDB server. Merging actors and directors runs
+// in a transaction, which MongoDB only supports on a replica set or sharded
+// cluster: set replica_set to the set's name, a single-node replica set
+// started with --replSet is enough for development. Everything else works
+// on a standalone server.
 type DBConfig struct {
-	Host   string `json:"host"`
-	Port   string `json:"port"`
-	DbName string `json:"name"`
+	Host       string `json:"host"`
+	Port       string `json:"port"`
+	DbName     string `json:"name"`
+	ReplicaSet string `json:"replica_set"`
+}
+
+// ConcurrencyConfig controls how catalog writes are guarded against
+// overwriting each other.
+type ConcurrencyConfig struct {
+	// RequireIfMatch rejects PUT/PATCH/DELETE requests that do not send an
+	// If-Match header instead of letting them write unconditionally.
+	RequireIfMatch bool `json:"require_if_match"`
+}
+
+// IdempotencyConfig controls how long responses to POST requests sent with an
+// Idempotency-Key header are kept for replay.
+type IdempotencyConfig struct {
+	TTLSeconds     int   `json:"ttl_seconds"`
+	PendingSeconds int   `json:"pending_seconds"`
+	MaxBodyBytes   int64 `json:"max_body_bytes"`
+}
+
+// TTL falls back to a day when the window is not configured.
+func (c IdempotencyConfig) TTL() time.Duration {
+	if c.TTLSeconds <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// PendingTTL is how long a key stays locked while its first request is being
+// handled, a minute unless configured. A request that outlives it can be
+// retried for real.
+func (c IdempotencyConfig) PendingTTL() time.Duration {
+	if c.PendingSeconds <= 0 {
+		return time.Minute
+	}
+	return time.Duration(c.PendingSeconds) * time.Second
+}
+
+// MaxBody caps the JSON request bodies that are buffered for fingerprinting,
+// 1 MiB unless configured.
+func (c IdempotencyConfig) MaxBody() int64 {
+	if c.MaxBodyBytes <= 0 {
+		return 1 << 20
+	}
+	return c.MaxBodyBytes
+}
+
+type LocalStorageConfig struct {
+	Dir     string `json:"dir"`
+	BaseURL string `json:"base_url"`
+}
+
+func (c LocalStorageConfig) Path() string {
+	if c.Dir == "" {
+		return "./uploads"
+	}
+	return c.Dir
+}
+
+// URLPrefix is the path the upload directory is served under.
+func (c LocalStorageConfig) URLPrefix() string {
+	if c.BaseURL == "" {
+		return "/media"
+	}
+	return c.BaseURL
+}
+
+// S3StorageConfig points at any S3-compatible service. Objects are addressed
+// path-style, which is what MinIO expects.
+type S3StorageConfig struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	PublicURL string `json:"public_url"`
+}
+
+type StorageConfig struct {
+	Driver         string             `json:"driver"`
+	MaxUploadBytes int64              `json:"max_upload_bytes"`
+	Local          LocalStorageConfig `json:"local"`
+	S3             S3StorageConfig    `json:"s3"`
+}
+
+// UploadLimit falls back to 5 MB when no limit is configured.
+func (c StorageConfig) UploadLimit() int64 {
+	if c.MaxUploadBytes <= 0 {
+		return 5 << 20
+	}
+	return c.MaxUploadBytes
+}
+
+// GraphConfig bounds the co-star path search.
+type GraphConfig struct {
+	MaxDepth    int `json:"max_depth"`
+	MaxFrontier int `json:"max_frontier"`
+}
+
+// Depth falls back to six degrees when no limit is configured.
+func (c GraphConfig) Depth() int {
+	if c.MaxDepth <= 0 {
+		return 6
+	}
+	return c.MaxDepth
+}
+
+// Frontier is the most actors one hop of the path search may reach before
+// it gives up, 500 unless configured.
+func (c GraphConfig) Frontier() int {
+	if c.MaxFrontier <= 0 {
+		return 500
+	}
+	return c.MaxFrontier
+}
+
+// RecommendationConfig controls how long computed recommendations are cached.
+// Any catalog change invalidates them earlier.
+type RecommendationConfig struct {
+	CacheTTLSeconds int `json:"cache_ttl_seconds"`
+}
+
+// CacheTTL falls back to an hour when no lifetime is configured.
+func (c RecommendationConfig) CacheTTL() time.Duration {
+	if c.CacheTTLSeconds <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.CacheTTLSeconds) * time.Second
+}
+
+// StatsConfig controls how long catalog statistics are cached.
+type StatsConfig struct {
+	CacheTTLSeconds int `json:"cache_ttl_seconds"`
+}
+
+// CacheTTL falls back to ten minutes when no lifetime is configured.
+func (c StatsConfig) CacheTTL() time.Duration {
+	if c.CacheTTLSeconds <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(c.CacheTTLSeconds) * time.Second
+}
+
+// I18nConfig sets the language movies are assumed to be written in when they
+// do not say otherwise.
+type I18nConfig struct {
+	DefaultLanguage string `json:"default_language"`
+}
+
+// Language falls back to English when no default is configured.
+func (c I18nConfig) Language() string {
+	if c.DefaultLanguage == "" {
+		return "en"
+	}
+	return c.DefaultLanguage
+}
+
+// ContentRatingConfig sets the country whose certifications apply to users
+// who have not told us where they are.
+type ContentRatingConfig struct {
+	DefaultCountry string `json:"default_country"`
+}
+
+// Country falls back to the US when no default is configured.
+func (c ContentRatingConfig) Country() string {
+	if c.DefaultCountry == "" {
+		return "US"
+	}
+	return c.DefaultCountry
+}
+
+// TrashConfig controls how long deleted movies, people and users stay
+// restorable and how often the purge job looks for expired ones.
+type TrashConfig struct {
+	RetentionDays        int `json:"retention_days"`
+	PurgeIntervalMinutes int `json:"purge_interval_minutes"`
+}
+
+// Retention falls back to thirty days when none is configured.
+func (c TrashConfig) Retention() time.Duration {
+	if c.RetentionDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(c.RetentionDays) * 24 * time.Hour
+}
+
+// PurgeInterval falls back to an hour when none is configured.
+func (c TrashConfig) PurgeInterval() time.Duration {
+	if c.PurgeIntervalMinutes <= 0 {
+		return time.Hour
+	}
+	return time.Duration(c.PurgeIntervalMinutes) * time.Minute
 }
 
 type Config struct {
-	Database DBConfig `json:"database"`
-	Secret   string   `json:"secret"`
+	Database        DBConfig             `json:"database"`
+	Secret          string               `json:"secret"`
+	Concurrency     ConcurrencyConfig    `json:"concurrency"`
+	Idempotency     IdempotencyConfig    `json:"idempotency"`
+	Storage         StorageConfig        `json:"storage"`
+	Graph           GraphConfig          `json:"graph"`
+	Recommendations RecommendationConfig `json:"recommendations"`
+	Stats           StatsConfig          `json:"stats"`
+	I18n            I18nConfig           `json:"i18n"`
+	ContentRating   ContentRatingConfig  `json:"content_rating"`
+	Trash           TrashConfig          `json:"trash"`
 }
 
 var AppConfig *Config
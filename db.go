@@ -22,6 +22,9 @@ func NewDatabase(cfg *config.Config) error {
 	uri := fmt.Sprintf("mongodb://%s:%s", cfg.Database.Host, cfg.Database.Port)
 
 	clientOptions := options.Client().ApplyURI(uri)
+	if cfg.Database.ReplicaSet != "" {
+		clientOptions.SetReplicaSet(cfg.Database.ReplicaSet)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
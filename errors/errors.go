@@ -14,4 +14,29 @@ const (
 	NoFieldsToUpdate     = "No fields to update"
 	InvalidActorID       = "Invalid actor ID"
 	InvalidDirectorID    = "Invalid director ID"
+	InvalidDepartment    = "Unknown crew department"
+	InvalidRevisionID    = "Invalid revision ID"
+	InvalidTrashKind     = "kind must be movie, person or user"
+	AdminOnly            = "Only admins can do this"
+	EditorOnly           = "Only editors and admins can do this"
+	InvalidNumber        = "Season and episode numbers must be whole numbers"
+	SeasonExists         = "A season with this number already exists"
+	EpisodeExists        = "An episode with this number already exists"
+	InvalidDepth         = "depth must be a positive number"
+	InvalidCountry       = "country must be an ISO 3166-1 alpha-2 code such as US or DE"
+	InvalidLocale        = "locale must be a language tag such as en or pt-BR"
+	TranslationNoTitle   = "translation title is required"
+	InvalidAwardFilter   = "award must be won or nominated and award_year a year"
+	InvalidStatsFilter   = "from and to must be yyyy-mm-dd, from_year and to_year years, interval month or year"
+	IfMatchRequired      = "If-Match header is required"
+	UnsupportedPatchType = "PATCH body must be application/merge-patch+json or application/json-patch+json"
+	IdempotencyKeyReused = "Idempotency-Key was already used for a different request"
+	IdempotencyInFlight  = "A request with this Idempotency-Key is still being processed"
+	IdempotencyStoreFail = "Failed to check the Idempotency-Key in Redis"
+	RequestTooLarge      = "Request body is larger than allowed"
+	UploadMissing        = "Upload must be multipart form data with a file field"
+	UploadTooLarge       = "Upload is larger than allowed"
+	VersionMismatch      = "Resource was modified by another request, reload it and retry"
+	NotMember            = "You are not a member of this organization"
+	OrganizationRequired = "Choose an organization with the X-Organization header or when logging in"
 )
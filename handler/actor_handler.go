@@ -5,7 +5,6 @@ import (
 	errMsg "gin-demo/errors"
 	"gin-demo/model"
 	"gin-demo/services"
-	"gin-demo/utils"
 	"net/http"
 	"time"
 
@@ -23,32 +22,34 @@ func NewActorHandler(service services.IActorService) *ActorHandler {
 }
 
 func (h *ActorHandler) CreateActor(c *gin.Context) {
-	var raw map[string]interface{}
-	if err := c.ShouldBindJSON(&raw); err != nil {
+	var req model.PersonCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	if birthStr, ok := raw["birthDate"].(string); ok {
-		t, err := utils.ParseDate(birthStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.TimeFormatWrong})
-			return
-		}
-		raw["birthDate"] = t
+	actor, err := req.Person()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	var actor model.Actor
-	bsonBytes, _ := bson.Marshal(raw)
-	bson.Unmarshal(bsonBytes, &actor)
-
-	_, err := h.service.Create(&actor)
+	id, err := h.service.Create(actor, c.GetString("email"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, "Successfully created an actor")
+	c.Header("Location", "/api/actor/"+id.Hex())
+	created, err := h.service.GetByID(id)
+	if err != nil {
+		// The actor is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, actor.Version)
+		c.JSON(http.StatusCreated, actor)
+		return
+	}
+	setETag(c, created.Version)
+	c.JSON(http.StatusCreated, created)
 }
 
 func (h *ActorHandler) GetActor(c *gin.Context) {
@@ -64,11 +65,16 @@ func (h *ActorHandler) GetActor(c *gin.Context) {
 		return
 	}
 
+	setETag(c, actor.Version)
 	c.JSON(http.StatusOK, actor)
 }
 
 func (h *ActorHandler) GetAllActors(c *gin.Context) {
-	actors, err := h.service.GetAll()
+	awards, ok := awardFilter(c)
+	if !ok {
+		return
+	}
+	actors, err := h.service.GetAll(awards)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -84,6 +90,10 @@ func (h *ActorHandler) UpdateActor(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	var body map[string]interface{}
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -91,6 +101,12 @@ func (h *ActorHandler) UpdateActor(c *gin.Context) {
 		return
 	}
 
+	// The birth date is parsed on its own, it would stop the decoding
+	// into the model halfway through.
+	normalizeBirthDate(body)
+	birthDate, hasBirthDate := body["birth_date"]
+	delete(body, "birth_date")
+
 	var updatedActor model.Actor
 	jsonBody, _ := json.Marshal(body)
 	json.Unmarshal(jsonBody, &updatedActor)
@@ -103,8 +119,8 @@ func (h *ActorHandler) UpdateActor(c *gin.Context) {
 	if updatedActor.LastName != "" {
 		updateBson["last_name"] = updatedActor.LastName
 	}
-	if raw, exists := body["birth_date"]; exists {
-		if str, ok := raw.(string); ok && str != "" {
+	if hasBirthDate {
+		if str, ok := birthDate.(string); ok && str != "" {
 			t, err := time.Parse("2006-01-02", str)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.TimeFormatWrong})
@@ -119,12 +135,39 @@ func (h *ActorHandler) UpdateActor(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Update(id, updateBson); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Update(id, updateBson, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	updated, err := h.service.GetByID(id)
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *ActorHandler) PatchActor(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, contentType, patch, ok := patchRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, "Successfully updated an actor")
+	actor, err := h.service.Patch(id, contentType, patch, version, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	setETag(c, actor.Version)
+	c.JSON(http.StatusOK, actor)
 }
 
 func (h *ActorHandler) DeleteActor(c *gin.Context) {
@@ -134,12 +177,76 @@ func (h *ActorHandler) DeleteActor(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	err = h.service.Delete(id)
+	err = h.service.Delete(id, version, c.GetString("email"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeFailed(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, "Successfully deleted an actor")
 }
+
+func (h *ActorHandler) MergeActors(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	var req model.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	actor, err := h.service.Merge(id, &req, c.GetString("email"))
+	if err != nil {
+		mergeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, actor)
+}
+
+func (h *ActorHandler) GetActorHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *ActorHandler) RevertActor(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	actor, err := h.service.Revert(id, revisionID, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, actor)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/middleware"
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AudienceHandler struct {
+	service services.IAudienceService
+}
+
+func NewAudienceHandler(service services.IAudienceService) *AudienceHandler {
+	return &AudienceHandler{service: service}
+}
+
+// audienceOf is the audience AudienceMiddleware resolved for the request.
+// Routes outside the protected group have none and are not filtered.
+func audienceOf(c *gin.Context) *model.Audience {
+	audience, _ := c.Get(middleware.AudienceKey)
+	a, _ := audience.(*model.Audience)
+	return a
+}
+
+// roleOf is the role of the user the request's audience is for.
+func roleOf(c *gin.Context) string {
+	if audience := audienceOf(c); audience != nil {
+		return audience.Role
+	}
+	return ""
+}
+
+func (h *AudienceHandler) GetParentalControl(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	control, err := h.service.ParentalControl(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if control == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "max_age": control.MaxAge, "updated_at": control.UpdatedAt})
+}
+
+// PutParentalControl sets the age limit. Once a PIN is set, current_pin has
+// to match it; pin chooses a new one.
+func (h *AudienceHandler) PutParentalControl(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		MaxAge     *int   `json:"max_age"`
+		PIN        string `json:"pin"`
+		CurrentPIN string `json:"current_pin"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.MaxAge == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidReqData})
+		return
+	}
+
+	control, err := h.service.SetParentalControl(user, *body.MaxAge, body.PIN, body.CurrentPIN)
+	if err != nil {
+		parentalControlFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "max_age": control.MaxAge, "updated_at": control.UpdatedAt})
+}
+
+func (h *AudienceHandler) DeleteParentalControl(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	var body struct {
+		PIN string `json:"pin"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidReqData})
+		return
+	}
+
+	if err := h.service.RemoveParentalControl(user, body.PIN); err != nil {
+		parentalControlFailed(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func parentalControlFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWrongPIN):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPIN), errors.Is(err, services.ErrInvalidAge):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AwardHandler struct {
+	service services.IAwardService
+}
+
+func NewAwardHandler(service services.IAwardService) *AwardHandler {
+	return &AwardHandler{service: service}
+}
+
+func (h *AwardHandler) CreateAward(c *gin.Context) {
+	var award model.Award
+	if err := c.ShouldBindJSON(&award); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.service.Create(&award, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/award/"+id.Hex())
+	created, err := h.service.GetByID(id, nil)
+	if err != nil {
+		// The award is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, award.Version)
+		c.JSON(http.StatusCreated, award)
+		return
+	}
+	setETag(c, created.Version)
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *AwardHandler) GetAward(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	award, err := h.service.GetByID(id, audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, award.Version)
+	c.JSON(http.StatusOK, award)
+}
+
+// GetAllAwards lists the awards, optionally of one ceremony and year.
+func (h *AwardHandler) GetAllAwards(c *gin.Context) {
+	year, ok := awardYear(c, c.Query("year"))
+	if !ok {
+		return
+	}
+	awards, err := h.service.GetAll(c.Query("ceremony"), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, awards)
+}
+
+// GetCeremony lists an edition's nominees by category.
+func (h *AwardHandler) GetCeremony(c *gin.Context) {
+	year, ok := awardYear(c, c.Param("year"))
+	if !ok {
+		return
+	}
+	results, err := h.service.Ceremony(c.Param("ceremony"), year, audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func (h *AwardHandler) GetMovieAwards(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	entries, err := h.service.ForMovie(id, audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *AwardHandler) GetPersonAwards(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	entries, err := h.service.ForPerson(id, audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *AwardHandler) UpdateAward(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var body model.AwardUpdate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update, err := body.Document()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(update) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.NoFieldsToUpdate})
+		return
+	}
+
+	if err := h.service.Update(id, update, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	updated, err := h.service.GetByID(id, nil)
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *AwardHandler) DeleteAward(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "Successfully deleted an award")
+}
+
+func (h *AwardHandler) GetAwardHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *AwardHandler) RevertAward(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	award, err := h.service.Revert(id, revisionID, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, award)
+}
+
+// awardFilter reads the award filter of a movie or people list: award is
+// won or nominated, award_year and award_ceremony narrow it down. A year or
+// ceremony on its own filters for winners. Without any of them the list is
+// not filtered and nil is returned.
+func awardFilter(c *gin.Context) (*model.AwardFilter, bool) {
+	award, ceremony, year := c.Query("award"), c.Query("award_ceremony"), c.Query("award_year")
+	if award == "" && ceremony == "" && year == "" {
+		return nil, true
+	}
+
+	filter := &model.AwardFilter{Ceremony: ceremony}
+	switch award {
+	case "", "won":
+		filter.WonOnly = true
+	case "nominated":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidAwardFilter})
+		return nil, false
+	}
+	if year != "" {
+		var err error
+		if filter.Year, err = strconv.Atoi(year); err != nil || filter.Year <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidAwardFilter})
+			return nil, false
+		}
+	}
+	return filter, true
+}
+
+// awardYear parses an optional year, answering 400 when it is not one.
+func awardYear(c *gin.Context, raw string) (int, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	year, err := strconv.Atoi(raw)
+	if err != nil || year <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidAwardFilter})
+		return 0, false
+	}
+	return year, true
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CollectionHandler struct {
+	service services.ICollectionService
+}
+
+func NewCollectionHandler(service services.ICollectionService) *CollectionHandler {
+	return &CollectionHandler{service: service}
+}
+
+func (h *CollectionHandler) CreateCollection(c *gin.Context) {
+	var collection model.Collection
+	if err := c.ShouldBindJSON(&collection); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	collection.Artwork = nil
+
+	id, err := h.service.Create(&collection, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/collection/"+id.Hex())
+	created, err := h.service.GetByID(id, audienceOf(c))
+	if err != nil {
+		// The collection is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, collection.Version)
+		c.JSON(http.StatusCreated, collection)
+		return
+	}
+	setETag(c, created.Version)
+	localizeCollection(c, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *CollectionHandler) GetCollection(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	collection, err := h.service.GetByID(id, audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, collection.Version)
+	localizeCollection(c, collection)
+	c.JSON(http.StatusOK, collection)
+}
+
+func (h *CollectionHandler) GetAllCollections(c *gin.Context) {
+	collections, err := h.service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, collections)
+}
+
+func (h *CollectionHandler) UpdateCollection(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var body model.CollectionUpdate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update, err := body.Document()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(update) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.NoFieldsToUpdate})
+		return
+	}
+
+	if err := h.service.Update(id, update, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	updated, err := h.service.GetByID(id, audienceOf(c))
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	localizeCollection(c, updated)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *CollectionHandler) DeleteCollection(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "Successfully deleted a collection")
+}
+
+func (h *CollectionHandler) GetCollectionHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *CollectionHandler) RevertCollection(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	collection, err := h.service.Revert(id, revisionID, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, collection)
+}
+
+// localizeCollection shows the member movies in the client's language.
+func localizeCollection(c *gin.Context, collection *model.CollectionResponse) {
+	prefs, fallback := preferredLanguages(c), defaultLanguage()
+	languages := make([]string, 0, len(collection.Movies))
+	for i := range collection.Movies {
+		collection.Movies[i].Localize(prefs, fallback)
+		languages = append(languages, collection.Movies[i].Language)
+	}
+	setContentLanguage(c, languages...)
+}
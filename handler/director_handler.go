@@ -5,7 +5,6 @@ import (
 	errMsg "gin-demo/errors"
 	"gin-demo/model"
 	"gin-demo/services"
-	"gin-demo/utils"
 	"net/http"
 	"time"
 
@@ -23,32 +22,34 @@ func NewDirectorHandler(service services.IDirectorService) *DirectorHandler {
 }
 
 func (h *DirectorHandler) CreateDirector(c *gin.Context) {
-	var raw map[string]interface{}
-	if err := c.ShouldBindJSON(&raw); err != nil {
+	var req model.PersonCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	if birthStr, ok := raw["birth_date"].(string); ok {
-		t, err := utils.ParseDate(birthStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.TimeFormatWrong})
-			return
-		}
-		raw["birth_date"] = t
+	director, err := req.Person()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	var director model.Director
-	bsonBytes, _ := bson.Marshal(raw)
-	bson.Unmarshal(bsonBytes, &director)
-
-	_, err := h.service.Create(&director)
+	id, err := h.service.Create(director, c.GetString("email"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, "Successfully created a director")
+	c.Header("Location", "/api/director/"+id.Hex())
+	created, err := h.service.GetByID(id)
+	if err != nil {
+		// The director is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, director.Version)
+		c.JSON(http.StatusCreated, director)
+		return
+	}
+	setETag(c, created.Version)
+	c.JSON(http.StatusCreated, created)
 }
 
 func (h *DirectorHandler) GetDirector(c *gin.Context) {
@@ -64,11 +65,16 @@ func (h *DirectorHandler) GetDirector(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, director.Version)
 	c.JSON(http.StatusOK, director)
 }
 
 func (h *DirectorHandler) GetAllDirectors(c *gin.Context) {
-	directors, err := h.service.GetAll()
+	awards, ok := awardFilter(c)
+	if !ok {
+		return
+	}
+	directors, err := h.service.GetAll(awards)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -83,6 +89,10 @@ func (h *DirectorHandler) UpdateDirector(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	var body map[string]interface{}
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -90,6 +100,12 @@ func (h *DirectorHandler) UpdateDirector(c *gin.Context) {
 		return
 	}
 
+	// The birth date is parsed on its own, it would stop the decoding
+	// into the model halfway through.
+	normalizeBirthDate(body)
+	birthDate, hasBirthDate := body["birth_date"]
+	delete(body, "birth_date")
+
 	var update model.Director
 	jsonBody, _ := json.Marshal(body)
 	json.Unmarshal(jsonBody, &update)
@@ -102,8 +118,8 @@ func (h *DirectorHandler) UpdateDirector(c *gin.Context) {
 	if update.LastName != "" {
 		updateBson["last_name"] = update.LastName
 	}
-	if raw, exists := body["birthDate"]; exists {
-		if str, ok := raw.(string); ok && str != "" {
+	if hasBirthDate {
+		if str, ok := birthDate.(string); ok && str != "" {
 			t, err := time.Parse("2006-01-02", str)
 			if err != nil {
 				c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.TimeFormatWrong})
@@ -118,12 +134,39 @@ func (h *DirectorHandler) UpdateDirector(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.Update(id, updateBson); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Update(id, updateBson, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	updated, err := h.service.GetByID(id)
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *DirectorHandler) PatchDirector(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, contentType, patch, ok := patchRequest(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, "Successfully updated a director")
+	director, err := h.service.Patch(id, contentType, patch, version, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	setETag(c, director.Version)
+	c.JSON(http.StatusOK, director)
 }
 
 func (h *DirectorHandler) DeleteDirector(c *gin.Context) {
@@ -133,10 +176,74 @@ func (h *DirectorHandler) DeleteDirector(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	if err := h.service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Delete(id, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, "Successfully deleted a director")
 }
+
+func (h *DirectorHandler) MergeDirectors(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	var req model.MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.Validate(id); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	director, err := h.service.Merge(id, &req, c.GetString("email"))
+	if err != nil {
+		mergeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, director)
+}
+
+func (h *DirectorHandler) GetDirectorHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *DirectorHandler) RevertDirector(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	director, err := h.service.Revert(id, revisionID, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, director)
+}
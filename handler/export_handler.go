@@ -0,0 +1,75 @@
+package handler
+
+import (
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ExportHandler struct {
+	service services.IExportService
+}
+
+func NewExportHandler(service services.IExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// Export streams a collection in the import layout. Movies take the
+// parameters of the movie listing as well, q, country, sort and the award
+// filter.
+//
+//	GET /api/export/movies?format=csv&hydrate=true&director_id=...&actor_id=...
+func (h *ExportHandler) Export(c *gin.Context) {
+	kind := c.Param("kind")
+	format := c.DefaultQuery("format", model.FormatJSONL)
+	if err := h.service.Validate(kind, format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query, ok := movieListQuery(c)
+	if !ok {
+		return
+	}
+	filter := services.ExportFilter{MovieListQuery: query}
+	filter.Hydrate, _ = strconv.ParseBool(c.DefaultQuery("hydrate", "false"))
+	if hex := c.Query("director_id"); hex != "" {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidDirectorID})
+			return
+		}
+		filter.DirectorID = id
+	}
+	if hex := c.Query("actor_id"); hex != "" {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidActorID})
+			return
+		}
+		filter.ActorID = id
+	}
+
+	contentType := "application/x-ndjson"
+	if format == model.FormatCSV {
+		contentType = "text/csv"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", "attachment; filename=\""+kind+"."+format+"\"")
+
+	if err := h.service.Export(kind, format, filter, c.Writer); err != nil {
+		if !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		// The status line is already out, all we can do is cut the stream.
+		log.Printf("export of %s failed mid-stream: %v", kind, err)
+		c.Abort()
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GraphHandler struct {
+	service services.IGraphService
+}
+
+func NewGraphHandler(service services.IGraphService) *GraphHandler {
+	return &GraphHandler{service: service}
+}
+
+func (h *GraphHandler) GetCoStars(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidActorID})
+		return
+	}
+
+	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "20"), 10, 64)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	coStars, err := h.service.CoStars(id, limit, audienceOf(c))
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, coStars)
+}
+
+// GetActorPath answers /actors/path?from=...&to=...&depth=...
+func (h *GraphHandler) GetActorPath(c *gin.Context) {
+	from, err := primitive.ObjectIDFromHex(c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidActorID})
+		return
+	}
+	to, err := primitive.ObjectIDFromHex(c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidActorID})
+		return
+	}
+	depth := 0
+	if raw := c.Query("depth"); raw != "" {
+		if depth, err = strconv.Atoi(raw); err != nil || depth <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidDepth})
+			return
+		}
+	}
+
+	path, err := h.service.ShortestPath(from, to, depth, audienceOf(c))
+	if errors.Is(err, services.ErrNoActorPath) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, model.ErrPathSearchTooWide) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, path)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/imaging"
+	"gin-demo/model"
+	"gin-demo/services"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// multipartOverhead is what the form around the file may add to the body.
+const multipartOverhead = 64 << 10
+
+type ImageHandler struct {
+	service  services.IImageService
+	maxBytes int64
+}
+
+func NewImageHandler(service services.IImageService, maxBytes int64) *ImageHandler {
+	return &ImageHandler{service: service, maxBytes: maxBytes}
+}
+
+func (h *ImageHandler) UploadMoviePoster(c *gin.Context) {
+	h.upload(c, func(id primitive.ObjectID, data []byte) (*model.ImageSet, error) {
+		return h.service.UploadPoster(id, data, c.GetString("email"))
+	})
+}
+
+func (h *ImageHandler) UploadCollectionArtwork(c *gin.Context) {
+	h.upload(c, func(id primitive.ObjectID, data []byte) (*model.ImageSet, error) {
+		return h.service.UploadArtwork(id, data, c.GetString("email"))
+	})
+}
+
+func (h *ImageHandler) UploadActorHeadshot(c *gin.Context) {
+	h.uploadHeadshot(c, model.DepartmentActing)
+}
+
+func (h *ImageHandler) UploadDirectorHeadshot(c *gin.Context) {
+	h.uploadHeadshot(c, model.DepartmentDirecting)
+}
+
+func (h *ImageHandler) UploadPersonHeadshot(c *gin.Context) {
+	h.uploadHeadshot(c, "")
+}
+
+func (h *ImageHandler) uploadHeadshot(c *gin.Context, department string) {
+	h.upload(c, func(id primitive.ObjectID, data []byte) (*model.ImageSet, error) {
+		return h.service.UploadHeadshot(department, id, data, c.GetString("email"))
+	})
+}
+
+// upload reads the "file" part of a multipart form, enforcing the size limit
+// while reading, and hands it to store.
+func (h *ImageHandler) upload(c *gin.Context, store func(primitive.ObjectID, []byte) (*model.ImageSet, error)) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+multipartOverhead)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errMsg.UploadTooLarge})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.UploadMissing})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if int64(len(data)) > h.maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": errMsg.UploadTooLarge})
+		return
+	}
+
+	set, err := store(id, data)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedImage):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, imaging.ErrImageTooLarge):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusOK, set)
+	}
+}
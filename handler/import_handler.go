@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	service services.IImportService
+}
+
+func NewImportHandler(service services.IImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// Import accepts the file either as the multipart field "file" or as the raw
+// request body. The format comes from ?format=, the file extension or the
+// Content-Type, in that order.
+//
+//	POST /api/import/people?format=csv&mode=upsert&dry_run=true
+func (h *ImportHandler) Import(c *gin.Context) {
+	kind := c.Param("kind")
+	mode := c.DefaultQuery("mode", model.ImportModeInsert)
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	format := c.Query("format")
+
+	var input io.Reader = c.Request.Body
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		input = file
+		if format == "" {
+			format = formatFromName(header.Filename)
+		}
+	}
+	if format == "" {
+		format = formatFromContentType(c.ContentType())
+	}
+
+	report, err := h.service.Import(kind, format, mode, dryRun, input, roleOf(c), c.GetString("email"))
+	if errors.Is(err, model.ErrInvalidImport) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		// The report says how far the import got before the database failed.
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "report": report})
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun && report.Inserted > 0 {
+		status = http.StatusCreated
+	}
+	c.JSON(status, report)
+}
+
+func formatFromName(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return model.FormatCSV
+	case ".jsonl", ".ndjson":
+		return model.FormatJSONL
+	}
+	return ""
+}
+
+func formatFromContentType(contentType string) string {
+	switch contentType {
+	case "text/csv":
+		return model.FormatCSV
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return model.FormatJSONL
+	}
+	return ""
+}
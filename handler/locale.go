@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"gin-demo/config"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// preferredLanguages lists the languages the client asked for, best first.
+// The lang query parameter wins over the Accept-Language header, which
+// remains the fallback. Tags that do not parse are ignored.
+func preferredLanguages(c *gin.Context) []language.Tag {
+	var tags []language.Tag
+	if lang := c.Query("lang"); lang != "" {
+		if tag, err := language.Parse(lang); err == nil {
+			tags = append(tags, tag)
+		}
+	}
+	accepted, _, _ := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	return append(tags, accepted...)
+}
+
+func defaultLanguage() string {
+	if cfg := config.GetConfig(); cfg != nil {
+		return cfg.I18n.Language()
+	}
+	return config.I18nConfig{}.Language()
+}
+
+// setContentLanguage names the languages the response is in. Responses
+// differ by Accept-Language, which caches have to know about.
+func setContentLanguage(c *gin.Context, languages ...string) {
+	seen := map[string]bool{}
+	var unique []string
+	for _, l := range languages {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			unique = append(unique, l)
+		}
+	}
+	c.Header("Vary", "Accept-Language")
+	if len(unique) > 0 {
+		c.Header("Content-Language", strings.Join(unique, ", "))
+	}
+}
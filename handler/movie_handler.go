@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	errMsg "gin-demo/errors"
 	"gin-demo/model"
 	"gin-demo/services"
 	"gin-demo/utils"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/bson"
@@ -22,18 +24,35 @@ func NewMovieHandler(service services.IMovieService) *MovieHandler {
 }
 
 func (h *MovieHandler) CreateMovie(c *gin.Context) {
-	var movie model.Movie
-	if err := c.ShouldBindJSON(&movie); err != nil {
+	var req model.MovieCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	movie, err := req.Movie()
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	_, err := h.service.Create(&movie)
+	id, err := h.service.Create(movie, c.GetString("email"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeFailed(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, "Successfully created a movie")
+
+	c.Header("Location", "/api/movie/"+id.Hex())
+	created, err := h.service.GetByID(id, nil)
+	if err != nil {
+		// The movie is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, movie.Version)
+		c.JSON(http.StatusCreated, movie)
+		return
+	}
+	setETag(c, created.Version)
+	localizeMovie(c, created)
+	c.JSON(http.StatusCreated, created)
 }
 
 func (h *MovieHandler) GetMovie(c *gin.Context) {
@@ -44,20 +63,43 @@ func (h *MovieHandler) GetMovie(c *gin.Context) {
 		return
 	}
 
-	movie, err := h.service.GetByID(id)
+	if _, ok := requestedCountry(c); !ok {
+		return
+	}
+
+	movie, err := h.service.GetByID(id, audienceOf(c))
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	setETag(c, movie.Version)
+	localizeMovie(c, movie)
 	c.JSON(http.StatusOK, movie)
 }
 
+// GetAllMovies lists the movies in the client's language. q searches the
+// titles and sort is title, release_year or either prefixed with - to
+// reverse it. country adds each movie's release date and rating there.
 func (h *MovieHandler) GetAllMovies(c *gin.Context) {
-	movies, err := h.service.GetAll()
+	query, ok := movieListQuery(c)
+	if !ok {
+		return
+	}
+	movies, err := h.service.GetAll(query)
+	if errors.Is(err, model.ErrInvalidSort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+
+	languages := make([]string, 0, len(movies))
+	for _, m := range movies {
+		languages = append(languages, m.Language)
+	}
+	setContentLanguage(c, languages...)
 	c.JSON(http.StatusOK, movies)
 }
 
@@ -68,6 +110,10 @@ func (h *MovieHandler) UpdateMovies(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	var update model.Movie
 	if err := c.ShouldBindJSON(&update); err != nil {
@@ -79,26 +125,84 @@ func (h *MovieHandler) UpdateMovies(c *gin.Context) {
 	if update.Title != "" {
 		updateBson["title"] = update.Title
 	}
+	if update.Overview != "" {
+		updateBson["overview"] = update.Overview
+	}
+	if update.OriginalLanguage != "" {
+		locale, err := model.NormalizeLocale(update.OriginalLanguage)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidLocale})
+			return
+		}
+		updateBson["original_language"] = locale
+	}
 	if update.ReleaseYear != 0 {
 		updateBson["release_year"] = update.ReleaseYear
 	}
+	if len(update.Releases) > 0 {
+		releases, err := model.NormalizeReleases(update.Releases)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		updateBson["releases"] = releases
+	}
+	if len(update.Genres) > 0 {
+		updateBson["genres"] = model.NormalizeGenres(update.Genres)
+	}
 	if update.DirectorID != primitive.NilObjectID {
 		updateBson["director_id"] = update.DirectorID
 	}
 	if len(update.Actors) > 0 {
 		updateBson["actors"] = update.Actors
 	}
+	if len(update.Crew) > 0 {
+		if !validCrew(update.Crew) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidDepartment})
+			return
+		}
+		updateBson["crew"] = update.Crew
+	}
 
 	if len(updateBson) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.NoFieldsToUpdate})
 		return
 	}
 
-	if err := h.service.Update(id, updateBson); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Update(id, updateBson, version, roleOf(c), c.GetString("email")); err != nil {
+		writeFailed(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, "Successfully updated a movie")
+	updated, err := h.service.GetByID(id, nil)
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	localizeMovie(c, updated)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *MovieHandler) PatchMovie(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, contentType, patch, ok := patchRequest(c)
+	if !ok {
+		return
+	}
+
+	movie, err := h.service.Patch(id, contentType, patch, version, roleOf(c), c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	setETag(c, movie.Version)
+	localizeMovie(c, movie)
+	c.JSON(http.StatusOK, movie)
 }
 
 func (h *MovieHandler) DeleteMovies(c *gin.Context) {
@@ -108,9 +212,13 @@ func (h *MovieHandler) DeleteMovies(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
 		return
 	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	if err := h.service.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.Delete(id, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, "Successfully deleted a movie")
@@ -123,6 +231,10 @@ func (h *MovieHandler) GetMoviesByDirector(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidDirectorID})
 		return
 	}
+	country, ok := requestedCountry(c)
+	if !ok {
+		return
+	}
 
 	page, _ := strconv.ParseInt(c.DefaultQuery("page", "1"), 10, 64)
 	limit, _ := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
@@ -132,7 +244,7 @@ func (h *MovieHandler) GetMoviesByDirector(c *gin.Context) {
 	fieldsToExclude := c.DefaultQuery("exclude", "")
 	projection := utils.BuildProjection(fieldsToInclude, fieldsToExclude)
 
-	movies, totalRows, err := h.service.GetByDirector(directorID, pagination, projection)
+	movies, totalRows, err := h.service.GetByDirector(directorID, pagination, projection, audienceOf(c), country)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -155,6 +267,10 @@ func (h *MovieHandler) GetMoviesByActor(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidActorID})
 		return
 	}
+	country, ok := requestedCountry(c)
+	if !ok {
+		return
+	}
 
 	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
@@ -163,7 +279,7 @@ func (h *MovieHandler) GetMoviesByActor(c *gin.Context) {
 	fieldsToExclude := c.DefaultQuery("exclude", "")
 	projection := utils.BuildProjection(fieldsToInclude, fieldsToExclude)
 
-	movies, totalRows, err := h.service.GetByActor(actorID, pagination, projection)
+	movies, totalRows, err := h.service.GetByActor(actorID, pagination, projection, audienceOf(c), country)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -178,3 +294,245 @@ func (h *MovieHandler) GetMoviesByActor(c *gin.Context) {
 		"limit":  pagination.Limit,
 	})
 }
+
+func (h *MovieHandler) GetActorFilmography(c *gin.Context) {
+	h.getFilmography(c, model.DepartmentActing, errMsg.InvalidActorID)
+}
+
+func (h *MovieHandler) GetDirectorFilmography(c *gin.Context) {
+	h.getFilmography(c, model.DepartmentDirecting, errMsg.InvalidDirectorID)
+}
+
+func (h *MovieHandler) getFilmography(c *gin.Context, department, invalidID string) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidID})
+		return
+	}
+
+	filmography, err := h.service.Filmography(id, department, audienceOf(c))
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, filmography)
+}
+
+// GetTranslations lists the translations of a movie keyed by locale.
+func (h *MovieHandler) GetTranslations(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	movie, err := h.service.Translations(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if !audienceOf(c).Allows(movie) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "movie not found with given id"})
+		return
+	}
+	respondTranslations(c, movie)
+}
+
+func (h *MovieHandler) PutTranslation(c *gin.Context) {
+	id, locale, version, ok := translationRequest(c)
+	if !ok {
+		return
+	}
+	var translation model.Translation
+	if err := c.ShouldBindJSON(&translation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(translation.Title) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.TranslationNoTitle})
+		return
+	}
+
+	movie, err := h.service.SetTranslation(id, locale, &translation, version, roleOf(c), c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	respondTranslations(c, movie)
+}
+
+func (h *MovieHandler) DeleteTranslation(c *gin.Context) {
+	id, locale, version, ok := translationRequest(c)
+	if !ok {
+		return
+	}
+
+	movie, err := h.service.SetTranslation(id, locale, nil, version, roleOf(c), c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	respondTranslations(c, movie)
+}
+
+func translationRequest(c *gin.Context) (primitive.ObjectID, string, int64, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return id, "", 0, false
+	}
+	locale, err := model.NormalizeLocale(c.Param("locale"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidLocale})
+		return id, "", 0, false
+	}
+	version, ok := ifMatchVersion(c)
+	return id, locale, version, ok
+}
+
+func respondTranslations(c *gin.Context, movie *model.Movie) {
+	translations := movie.Translations
+	if translations == nil {
+		translations = map[string]model.Translation{}
+	}
+	original := movie.OriginalLanguage
+	if original == "" {
+		original = defaultLanguage()
+	}
+	setETag(c, movie.Version)
+	c.JSON(http.StatusOK, gin.H{
+		"original_language": original,
+		"translations":      translations,
+	})
+}
+
+// localizeMovie shows the movie in the client's language along with its
+// release in the requested country.
+func localizeMovie(c *gin.Context, movie *model.MovieResponse) {
+	movie.Localize(preferredLanguages(c), defaultLanguage())
+	setContentLanguage(c, movie.Language)
+	if country, ok := model.NormalizeCountry(c.Query("country")); ok {
+		movie.LocalRelease = model.LocalRelease(movie.Releases, country)
+	}
+}
+
+// requestedCountry reads the optional country query parameter, answering 400
+// when it is not a country code.
+// movieListQuery reads the parameters of a movie listing, answering 400 when
+// one of them is not valid.
+func movieListQuery(c *gin.Context) (model.MovieListQuery, bool) {
+	country, ok := requestedCountry(c)
+	if !ok {
+		return model.MovieListQuery{}, false
+	}
+	awards, ok := awardFilter(c)
+	if !ok {
+		return model.MovieListQuery{}, false
+	}
+	if !model.ValidMovieSort(c.Query("sort")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": model.ErrInvalidSort.Error()})
+		return model.MovieListQuery{}, false
+	}
+	return model.MovieListQuery{
+		Audience:        audienceOf(c),
+		Languages:       preferredLanguages(c),
+		DefaultLanguage: defaultLanguage(),
+		Country:         country,
+		Search:          c.Query("q"),
+		Sort:            c.Query("sort"),
+		Awards:          awards,
+	}, true
+}
+
+func requestedCountry(c *gin.Context) (string, bool) {
+	raw := c.Query("country")
+	if raw == "" {
+		return "", true
+	}
+	country, ok := model.NormalizeCountry(raw)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidCountry})
+		return "", false
+	}
+	return country, true
+}
+
+func validCrew(crew []model.CrewMember) bool {
+	for _, c := range crew {
+		if c.PersonID.IsZero() || !model.ValidDepartment(c.Department) {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *MovieHandler) GetMovieHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *MovieHandler) RevertMovie(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	movie, err := h.service.Revert(id, revisionID, roleOf(c), c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	localizeMovie(c, movie)
+	c.JSON(http.StatusOK, movie)
+}
+
+// TransitionMovie moves the movie on in the editorial workflow. Editors
+// submit drafts, admins approve, reject, archive and schedule publication.
+func (h *MovieHandler) TransitionMovie(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	var transition model.Transition
+	if err := c.ShouldBindJSON(&transition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movie, err := h.service.Transition(id, transition, roleOf(c), version, c.GetString("email"))
+	switch {
+	case errors.Is(err, model.ErrTransitionForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, model.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		writeFailed(c, err)
+		return
+	}
+	setETag(c, movie.Version)
+	localizeMovie(c, movie)
+	c.JSON(http.StatusOK, movie)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OrganizationHandler struct {
+	service services.IOrganizationService
+}
+
+func NewOrganizationHandler(service services.IOrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var org model.Organization
+	if err := c.ShouldBindJSON(&org); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, err := h.service.Create(&org, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.Header("Location", "/api/organizations/"+org.Slug)
+	c.JSON(http.StatusCreated, org)
+}
+
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	orgs, err := h.service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	org, err := h.service.Get(c.Param("org"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, org)
+}
+
+// GetMyOrganizations lists the organizations the user can pick with the
+// X-Organization header or when logging in.
+func (h *OrganizationHandler) GetMyOrganizations(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	orgs, err := h.service.ForUser(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, orgs)
+}
+
+func (h *OrganizationHandler) GetMembers(c *gin.Context) {
+	members, err := h.service.Members(c.Param("org"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	// The body with the member's role may be left out, they join as a
+	// viewer then.
+	var membership model.Membership
+	if err := c.ShouldBindJSON(&membership); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.service.AddMember(c.Param("org"), userID, membership.Role, c.GetString("email"))
+	switch {
+	case errors.Is(err, model.ErrInvalidDocument):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	user, err := h.service.RemoveMember(c.Param("org"), userID, c.GetString("email"))
+	switch {
+	case errors.Is(err, model.ErrInvalidDocument):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"gin-demo/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PersonHandler struct {
+	service services.IPersonService
+}
+
+func NewPersonHandler(service services.IPersonService) *PersonHandler {
+	return &PersonHandler{service: service}
+}
+
+func (h *PersonHandler) CreatePerson(c *gin.Context) {
+	var req model.PersonCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	person, err := req.Person()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.service.Create(person, c.GetString("email"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Location", "/api/person/"+id.Hex())
+	created, err := h.service.GetByID(id)
+	if err != nil {
+		// The person is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, person.Version)
+		c.JSON(http.StatusCreated, person)
+		return
+	}
+	setETag(c, created.Version)
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *PersonHandler) GetPerson(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	person, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, person.Version)
+	c.JSON(http.StatusOK, person)
+}
+
+func (h *PersonHandler) GetAllPeople(c *gin.Context) {
+	awards, ok := awardFilter(c)
+	if !ok {
+		return
+	}
+	people, err := h.service.GetAll(awards)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, people)
+}
+
+func (h *PersonHandler) UpdatePerson(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updateBson := bson.M{}
+	if v, ok := body["first_name"].(string); ok && v != "" {
+		updateBson["first_name"] = v
+	}
+	if v, ok := body["last_name"].(string); ok && v != "" {
+		updateBson["last_name"] = v
+	}
+	if v, ok := body["birth_date"].(string); ok && v != "" {
+		t, err := utils.ParseDate(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.TimeFormatWrong})
+			return
+		}
+		updateBson["birth_date"] = t
+	}
+	if list, ok := body["departments"].([]interface{}); ok {
+		departments := make([]string, 0, len(list))
+		for _, item := range list {
+			d, _ := item.(string)
+			if !model.ValidDepartment(d) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidDepartment})
+				return
+			}
+			departments = append(departments, d)
+		}
+		updateBson["departments"] = departments
+	}
+
+	if len(updateBson) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.NoFieldsToUpdate})
+		return
+	}
+
+	if err := h.service.Update(id, updateBson, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+	updated, err := h.service.GetByID(id)
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *PersonHandler) PatchPerson(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, contentType, patch, ok := patchRequest(c)
+	if !ok {
+		return
+	}
+
+	person, err := h.service.Patch(id, contentType, patch, version, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	setETag(c, person.Version)
+	c.JSON(http.StatusOK, person)
+}
+
+func (h *PersonHandler) DeletePerson(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "Successfully deleted a person")
+}
+
+func (h *PersonHandler) GetPersonHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *PersonHandler) RevertPerson(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	person, err := h.service.Revert(id, revisionID, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, person)
+}
+
+// normalizeBirthDate moves the birthDate key older clients send over to
+// birth_date, which is what the rest of the API uses.
+func normalizeBirthDate(body map[string]interface{}) {
+	if v, ok := body["birthDate"]; ok {
+		if _, exists := body["birth_date"]; !exists {
+			body["birth_date"] = v
+		}
+		delete(body, "birthDate")
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"errors"
+	"gin-demo/config"
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes the document version so clients can send it back in
+// If-Match on their next write.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion reads the version the client expects to overwrite from the
+// If-Match header. Without the header the write is unconditional unless the
+// config requires it, in which case 428 is sent and false returned.
+func ifMatchVersion(c *gin.Context) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		if cfg := config.GetConfig(); cfg != nil && cfg.Concurrency.RequireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, gin.H{"error": errMsg.IfMatchRequired})
+			return 0, false
+		}
+		return model.AnyVersion, true
+	}
+	if header == "*" {
+		return model.AnyVersion, true
+	}
+
+	// Versions are strong validators, so a weak tag can never match.
+	tag, err := strconv.Unquote(header)
+	if err == nil {
+		if version, err := strconv.ParseInt(tag, 10, 64); err == nil && version >= 0 {
+			return version, true
+		}
+	}
+	c.JSON(http.StatusPreconditionFailed, gin.H{"error": errMsg.VersionMismatch})
+	return 0, false
+}
+
+// writeFailed answers a failed catalog write, telling stale writes and bad
+// patches apart from everything else.
+func writeFailed(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, model.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": errMsg.VersionMismatch})
+	case errors.Is(err, utils.ErrInvalidPatch):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrInvalidDocument):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	case errors.Is(err, model.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// readFailed answers a failed read with 404 when the document is missing and
+// 500 when the query itself failed.
+func readFailed(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// mergeFailed answers a failed merge: a request that does not validate is a
+// 400, missing records a 404.
+func mergeFailed(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrInvalidDocument) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	readFailed(c, err)
+}
+
+// patchRequest reads what every PATCH handler needs: the expected version and
+// a body in one of the supported patch formats.
+func patchRequest(c *gin.Context) (version int64, contentType string, body []byte, ok bool) {
+	if version, ok = ifMatchVersion(c); !ok {
+		return
+	}
+	contentType = c.ContentType()
+	if !utils.SupportedPatchType(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": errMsg.UnsupportedPatchType})
+		return version, contentType, nil, false
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return version, contentType, nil, false
+	}
+	return version, contentType, body, true
+}
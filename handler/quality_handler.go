@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QualityHandler struct {
+	service services.IQualityService
+}
+
+func NewQualityHandler(service services.IQualityService) *QualityHandler {
+	return &QualityHandler{service: service}
+}
+
+// GetDataQuality checks the catalog and lists every problem found, each with
+// a link to the movie or person concerned and a suggested fix.
+func (h *QualityHandler) GetDataQuality(c *gin.Context) {
+	report, err := h.service.Report()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// FixDataQuality applies the suggested fix of the findings named by id or by
+// code. Findings that need an editor are reported back as skipped.
+func (h *QualityHandler) FixDataQuality(c *gin.Context) {
+	var req model.QualityFixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.Fix(&req, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
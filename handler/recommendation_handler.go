@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RecommendationHandler struct {
+	recommendations services.IRecommendationService
+	ratings         services.IRatingService
+	watchlist       services.IWatchlistService
+}
+
+func NewRecommendationHandler(
+	recommendations services.IRecommendationService,
+	ratings services.IRatingService,
+	watchlist services.IWatchlistService,
+) *RecommendationHandler {
+	return &RecommendationHandler{recommendations: recommendations, ratings: ratings, watchlist: watchlist}
+}
+
+func (h *RecommendationHandler) GetSimilarMovies(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	recs, err := h.recommendations.Similar(id, recommendationLimit(c), audienceOf(c))
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, recs)
+}
+
+func (h *RecommendationHandler) GetRecommendations(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	recs, err := h.recommendations.ForUser(user, recommendationLimit(c), audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, recs)
+}
+
+func (h *RecommendationHandler) GetRatings(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	ratings, err := h.ratings.GetByUser(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ratings)
+}
+
+func (h *RecommendationHandler) RateMovie(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	var body struct {
+		Score int `json:"score"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidReqData})
+		return
+	}
+
+	rating, err := h.ratings.Rate(user, id, body.Score)
+	if errors.Is(err, services.ErrInvalidScore) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.recommendations.UserChanged(user)
+	c.JSON(http.StatusOK, rating)
+}
+
+func (h *RecommendationHandler) DeleteRating(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	if err := h.ratings.Remove(user, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.recommendations.UserChanged(user)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *RecommendationHandler) GetWatchlist(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+
+	entries, err := h.watchlist.GetByUser(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func (h *RecommendationHandler) AddToWatchlist(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("movieId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	entry, err := h.watchlist.Add(user, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.recommendations.UserChanged(user)
+	c.JSON(http.StatusOK, entry)
+}
+
+func (h *RecommendationHandler) RemoveFromWatchlist(c *gin.Context) {
+	user, ok := authenticatedUser(c)
+	if !ok {
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("movieId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	if err := h.watchlist.Remove(user, id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	h.recommendations.UserChanged(user)
+	c.Status(http.StatusNoContent)
+}
+
+func authenticatedUser(c *gin.Context) (string, bool) {
+	user := c.GetString("email")
+	if user == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": errMsg.UserNotAuthenticated})
+		return "", false
+	}
+	return user, true
+}
+
+func recommendationLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 || limit > 50 {
+		return 10
+	}
+	return limit
+}
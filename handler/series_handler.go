@@ -0,0 +1,517 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"gin-demo/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SeriesHandler struct {
+	service services.ISeriesService
+}
+
+func NewSeriesHandler(service services.ISeriesService) *SeriesHandler {
+	return &SeriesHandler{service: service}
+}
+
+func (h *SeriesHandler) CreateSeries(c *gin.Context) {
+	var series model.Series
+	if err := c.ShouldBindJSON(&series); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	id, err := h.service.Create(&series, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/series/"+id.Hex())
+	created, err := h.service.GetByID(id)
+	if err != nil {
+		// The series is stored already: failing the request would only make
+		// the client create it again, so it goes back as it was written.
+		setETag(c, series.Version)
+		c.JSON(http.StatusCreated, series)
+		return
+	}
+	setETag(c, created.Version)
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *SeriesHandler) GetSeries(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	setETag(c, series.Version)
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *SeriesHandler) GetAllSeries(c *gin.Context) {
+	series, err := h.service.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *SeriesHandler) UpdateSeries(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var body model.SeriesUpdate
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	update, err := body.Document()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(update) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.NoFieldsToUpdate})
+		return
+	}
+
+	if err := h.service.Update(id, update, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+
+	updated, err := h.service.GetByID(id)
+	if err != nil {
+		// The update went through, there is just no document to send back.
+		c.Status(http.StatusNoContent)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *SeriesHandler) DeleteSeries(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(id, version, c.GetString("email")); err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, "Successfully deleted a series")
+}
+
+func (h *SeriesHandler) GetSeriesHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	revisions, err := h.service.History(id)
+	if err != nil {
+		readFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (h *SeriesHandler) RevertSeries(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+	revisionID, err := primitive.ObjectIDFromHex(c.Param("revisionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidRevisionID})
+		return
+	}
+
+	series, err := h.service.Revert(id, revisionID, c.GetString("email"))
+	if err != nil {
+		writeFailed(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *SeriesHandler) GetSeasons(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	setETag(c, series.Version)
+	c.JSON(http.StatusOK, series.Seasons)
+}
+
+func (h *SeriesHandler) GetSeason(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	season, ok := findSeason(c, series)
+	if !ok {
+		return
+	}
+	setETag(c, series.Version)
+	c.JSON(http.StatusOK, season)
+}
+
+// CreateSeason adds a season after the last one, or under the number given
+// in the body as long as it is still free. Specials, season 0, are added
+// with PUT.
+func (h *SeriesHandler) CreateSeason(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	version, ok := writeVersion(c, series)
+	if !ok {
+		return
+	}
+
+	var season model.Season
+	if err := c.ShouldBindJSON(&season); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if season.Number == 0 {
+		season.Number = series.NextSeason()
+	}
+	if _, err := series.Season(season.Number); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": errMsg.SeasonExists})
+		return
+	}
+
+	updated, err := h.service.PutSeason(series.ID, season, version, c.GetString("email"))
+	if err != nil {
+		seriesFailed(c, err)
+		return
+	}
+	c.Header("Location", "/api/series/"+series.ID.Hex()+"/seasons/"+strconv.Itoa(season.Number))
+	respondSeason(c, http.StatusCreated, updated, season.Number)
+}
+
+// PutSeason creates or replaces the season with the number in the path. The
+// episodes stay as they are unless the body lists them.
+func (h *SeriesHandler) PutSeason(c *gin.Context) {
+	id, number, ok := seasonPath(c)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var season model.Season
+	if err := c.ShouldBindJSON(&season); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	season.Number = number
+
+	updated, err := h.service.PutSeason(id, season, version, c.GetString("email"))
+	if err != nil {
+		seriesFailed(c, err)
+		return
+	}
+	respondSeason(c, http.StatusOK, updated, number)
+}
+
+func (h *SeriesHandler) DeleteSeason(c *gin.Context) {
+	id, number, ok := seasonPath(c)
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.DeleteSeason(id, number, version, c.GetString("email"))
+	if err != nil {
+		seriesFailed(c, err)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, "Successfully deleted a season")
+}
+
+func (h *SeriesHandler) GetEpisodes(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	season, ok := findSeason(c, series)
+	if !ok {
+		return
+	}
+	setETag(c, series.Version)
+	c.JSON(http.StatusOK, season.Episodes)
+}
+
+func (h *SeriesHandler) GetEpisode(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	season, ok := findSeason(c, series)
+	if !ok {
+		return
+	}
+	number, ok := pathNumber(c, "episode")
+	if !ok {
+		return
+	}
+	episode, err := season.Episode(number)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	setETag(c, series.Version)
+	c.JSON(http.StatusOK, episode)
+}
+
+// CreateEpisode adds an episode after the last one of the season, or under
+// the number given in the body as long as it is still free.
+func (h *SeriesHandler) CreateEpisode(c *gin.Context) {
+	series, ok := h.series(c)
+	if !ok {
+		return
+	}
+	season, ok := findSeason(c, series)
+	if !ok {
+		return
+	}
+	version, ok := writeVersion(c, series)
+	if !ok {
+		return
+	}
+
+	var episode model.Episode
+	if err := c.ShouldBindJSON(&episode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if episode.Number == 0 {
+		episode.Number = season.NextEpisode()
+	}
+	if _, err := season.Episode(episode.Number); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": errMsg.EpisodeExists})
+		return
+	}
+
+	updated, err := h.service.PutEpisode(series.ID, season.Number, episode, version, c.GetString("email"))
+	if err != nil {
+		seriesFailed(c, err)
+		return
+	}
+	c.Header("Location", "/api/series/"+series.ID.Hex()+"/seasons/"+strconv.Itoa(season.Number)+
+		"/episodes/"+strconv.Itoa(episode.Number))
+	respondEpisode(c, http.StatusCreated, updated, season.Number, episode.Number)
+}
+
+// PutEpisode creates or replaces the episode with the number in the path.
+func (h *SeriesHandler) PutEpisode(c *gin.Context) {
+	id, season, ok := seasonPath(c)
+	if !ok {
+		return
+	}
+	number, ok := pathNumber(c, "episode")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var episode model.Episode
+	if err := c.ShouldBindJSON(&episode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	episode.Number = number
+
+	updated, err := h.service.PutEpisode(id, season, episode, version, c.GetString("email"))
+	if err != nil {
+		seriesFailed(c, err)
+		return
+	}
+	respondEpisode(c, http.StatusOK, updated, season, number)
+}
+
+func (h *SeriesHandler) DeleteEpisode(c *gin.Context) {
+	id, season, ok := seasonPath(c)
+	if !ok {
+		return
+	}
+	number, ok := pathNumber(c, "episode")
+	if !ok {
+		return
+	}
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.DeleteEpisode(id, season, number, version, c.GetString("email"))
+	if err != nil {
+		seriesFailed(c, err)
+		return
+	}
+	setETag(c, updated.Version)
+	c.JSON(http.StatusOK, "Successfully deleted an episode")
+}
+
+func (h *SeriesHandler) GetActorCredits(c *gin.Context) {
+	h.getCredits(c, "actorId", model.DepartmentActing, errMsg.InvalidActorID)
+}
+
+func (h *SeriesHandler) GetDirectorCredits(c *gin.Context) {
+	h.getCredits(c, "directorId", model.DepartmentDirecting, errMsg.InvalidDirectorID)
+}
+
+// getCredits lists movies and series together, told apart by their type,
+// and is paged like the movie-only listings.
+func (h *SeriesHandler) getCredits(c *gin.Context, param, department, invalidID string) {
+	personID, err := primitive.ObjectIDFromHex(c.Param(param))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": invalidID})
+		return
+	}
+
+	skip, _ := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	pagination := utils.NewPagination(int64(skip), int64(limit))
+
+	credits, totalRows, err := h.service.Credits(personID, department, pagination, audienceOf(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	pagination.SetTotal(totalRows)
+
+	c.JSON(http.StatusOK, gin.H{
+		"credits": credits,
+		"total":   pagination.TotalRows,
+		"page":    pagination.Page,
+		"limit":   pagination.Limit,
+	})
+}
+
+func (h *SeriesHandler) series(c *gin.Context) (*model.Series, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return nil, false
+	}
+	series, err := h.service.GetByID(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return series, true
+}
+
+func findSeason(c *gin.Context, series *model.Series) (*model.Season, bool) {
+	number, ok := pathNumber(c, "season")
+	if !ok {
+		return nil, false
+	}
+	season, err := series.Season(number)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return season, true
+}
+
+func seasonPath(c *gin.Context) (primitive.ObjectID, int, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return id, 0, false
+	}
+	number, ok := pathNumber(c, "season")
+	return id, number, ok
+}
+
+func pathNumber(c *gin.Context, param string) (int, bool) {
+	number, err := strconv.Atoi(c.Param(param))
+	if err != nil || number < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidNumber})
+		return 0, false
+	}
+	return number, true
+}
+
+// writeVersion is the version an add is checked against: the one the client
+// expects or, without If-Match, the one the free number was picked from.
+func writeVersion(c *gin.Context, series *model.Series) (int64, bool) {
+	version, ok := ifMatchVersion(c)
+	if ok && version == model.AnyVersion {
+		version = series.Version
+	}
+	return version, ok
+}
+
+func respondSeason(c *gin.Context, status int, series *model.Series, number int) {
+	setETag(c, series.Version)
+	season, err := series.Season(number)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, season)
+}
+
+func respondEpisode(c *gin.Context, status int, series *model.Series, season, number int) {
+	setETag(c, series.Version)
+	s, err := series.Season(season)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	episode, err := s.Episode(number)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, episode)
+}
+
+// seriesFailed answers a failed season or episode write.
+func seriesFailed(c *gin.Context, err error) {
+	if errors.Is(err, model.ErrSeasonNotFound) || errors.Is(err, model.ErrEpisodeNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	writeFailed(c, err)
+}
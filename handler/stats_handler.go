@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct {
+	service services.IStatsService
+}
+
+func NewStatsHandler(service services.IStatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+func (h *StatsHandler) GetReleaseYears(c *gin.Context) {
+	filter, ok := statsFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := h.service.ReleaseYears(filter)
+	respondStats(c, stats, err)
+}
+
+func (h *StatsHandler) GetTopDirectors(c *gin.Context) {
+	h.getTopPeople(c, model.DepartmentDirecting)
+}
+
+func (h *StatsHandler) GetTopActors(c *gin.Context) {
+	h.getTopPeople(c, model.DepartmentActing)
+}
+
+func (h *StatsHandler) getTopPeople(c *gin.Context, department string) {
+	filter, ok := statsFilter(c)
+	if !ok {
+		return
+	}
+	people, err := h.service.TopPeople(filter, department, statsLimit(c))
+	respondStats(c, people, err)
+}
+
+func (h *StatsHandler) GetCastSize(c *gin.Context) {
+	filter, ok := statsFilter(c)
+	if !ok {
+		return
+	}
+	stats, err := h.service.CastSize(filter)
+	respondStats(c, stats, err)
+}
+
+func (h *StatsHandler) GetPartnerships(c *gin.Context) {
+	filter, ok := statsFilter(c)
+	if !ok {
+		return
+	}
+	pairs, err := h.service.Partnerships(filter, statsLimit(c))
+	respondStats(c, pairs, err)
+}
+
+func (h *StatsHandler) GetGrowth(c *gin.Context) {
+	filter, ok := statsFilter(c)
+	if !ok {
+		return
+	}
+	points, err := h.service.Growth(filter, c.DefaultQuery("interval", model.GrowthByMonth))
+	respondStats(c, points, err)
+}
+
+func respondStats(c *gin.Context, stats interface{}, err error) {
+	if errors.Is(err, model.ErrInvalidStatsFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidStatsFilter})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// statsFilter reads from and to (yyyy-mm-dd, both inclusive) bounding when
+// movies were added, and from_year and to_year bounding their release.
+func statsFilter(c *gin.Context) (model.StatsFilter, bool) {
+	var filter model.StatsFilter
+	var err error
+	if raw := c.Query("from"); raw != "" {
+		if filter.AddedFrom, err = time.Parse("2006-01-02", raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidStatsFilter})
+			return filter, false
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if filter.AddedTo, err = time.Parse("2006-01-02", raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidStatsFilter})
+			return filter, false
+		}
+		filter.AddedTo = filter.AddedTo.AddDate(0, 0, 1)
+	}
+	if raw := c.Query("from_year"); raw != "" {
+		if filter.FromYear, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidStatsFilter})
+			return filter, false
+		}
+	}
+	if raw := c.Query("to_year"); raw != "" {
+		if filter.ToYear, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidStatsFilter})
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+func statsLimit(c *gin.Context) int64 {
+	limit, err := strconv.ParseInt(c.DefaultQuery("limit", "10"), 10, 64)
+	if err != nil || limit <= 0 || limit > 100 {
+		return 10
+	}
+	return limit
+}
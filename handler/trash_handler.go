@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	errMsg "gin-demo/errors"
+	"gin-demo/model"
+	"gin-demo/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TrashHandler struct {
+	service services.ITrashService
+}
+
+func NewTrashHandler(service services.ITrashService) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+// GetTrash lists deleted movies, people and users, or only one kind of them
+// when kind is given.
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	kind := c.Query("kind")
+	if kind != "" && !model.ValidTrashKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidTrashKind})
+		return
+	}
+
+	items, err := h.service.List(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, items)
+}
+
+// RestoreFromTrash brings a deleted document back. A movie crediting people
+// who no longer exist is refused with 409 unless drop_missing=true asks to
+// restore it without them.
+func (h *TrashHandler) RestoreFromTrash(c *gin.Context) {
+	kind := c.Param("kind")
+	if !model.ValidTrashKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidTrashKind})
+		return
+	}
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg.InvalidID})
+		return
+	}
+
+	err = h.service.Restore(kind, id, c.Query("drop_missing") == "true", c.GetString("email"))
+	if errors.Is(err, model.ErrMissingReferences) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"restored": id})
+}
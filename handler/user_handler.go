@@ -91,7 +91,7 @@ func (h *Handler) Register(c *gin.Context) {
 		req.Password = pwd
 	}
 
-	if err := h.userServiceFacade.Register(&req); err != nil {
+	if err := h.userServiceFacade.Register(&req, c.GetString("email")); err != nil {
 		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		return
 	}
@@ -145,3 +145,17 @@ func (h *Handler) GetUserById(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
+
+// DeleteUser moves the user to the trash, which also logs them out.
+func (h *Handler) DeleteUser(c *gin.Context) {
+	id, er := primitive.ObjectIDFromHex(c.Param("id"))
+	if er != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.InvalidID})
+		return
+	}
+	if er := h.userServiceFacade.Delete(id, c.GetString("email")); er != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": er.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, "Successfully deleted a user")
+}
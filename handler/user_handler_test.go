@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 func setupUserRouter(handler *handler.Handler) *gin.Engine {
@@ -30,7 +31,7 @@ func setupUserRouter(handler *handler.Handler) *gin.Engine {
 
 func TestLogin_Success(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 
 	reqBody := model.UserLoginRequest{
@@ -56,7 +57,7 @@ func TestLogin_Success(t *testing.T) {
 
 func TestLogin_InvalidRequest(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	req, _ := http.NewRequest(http.MethodPost, "/api/login", bytes.NewBufferString("{invalid-json}"))
 	req.Header.Set("Content-Type", "application/json")
@@ -70,7 +71,7 @@ func TestLogin_InvalidRequest(t *testing.T) {
 
 func TestLogin_ServiceError(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	reqBody := model.UserLoginRequest{
 		Email:    "fail@example.com",
@@ -92,7 +93,7 @@ func TestLogin_ServiceError(t *testing.T) {
 
 func TestLogout_Success(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	gin.SetMode(gin.TestMode)
 	w := httptest.NewRecorder()
@@ -111,7 +112,7 @@ func TestLogout_Success(t *testing.T) {
 
 func TestLogout_Error(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
@@ -126,7 +127,8 @@ func TestLogout_Error(t *testing.T) {
 
 func TestRegister_Success(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	mockRevisions := new(svcMocks.IRevisionService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, mockRevisions)
 	handler := handler.NewHandler(*userServiceFacade)
 	user := model.User{
 		Email:    "new@example.com",
@@ -135,6 +137,11 @@ func TestRegister_Success(t *testing.T) {
 	mockService.On("Register", mock.MatchedBy(func(u *model.User) bool {
 		return u != nil && u.Email == "new@example.com"
 	})).Return(nil)
+	mockRevisions.On("Record", model.EntityUser, mock.Anything, model.RevisionCreate, "", nil,
+		mock.MatchedBy(func(after bson.M) bool {
+			_, hasPassword := after["password"]
+			return after["email"] == "new@example.com" && !hasPassword
+		})).Return(nil)
 
 	body, _ := json.Marshal(user)
 	req, _ := http.NewRequest(http.MethodPost, "/api/users", bytes.NewBuffer(body))
@@ -150,7 +157,7 @@ func TestRegister_Success(t *testing.T) {
 
 func TestRegister_Error(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	user := model.User{Email: "bad@example.com"}
 	mockService.On("Register", &user).Return(errors.New("email exists"))
@@ -168,7 +175,7 @@ func TestRegister_Error(t *testing.T) {
 
 func TestGetUsers_Success(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	users := []model.User{{Email: "a@example.com"}, {Email: "b@example.com"}}
 	mockService.On("GetUsers", "test@example.com").Return(users)
@@ -186,7 +193,7 @@ func TestGetUsers_Success(t *testing.T) {
 
 func TestGetAuthenticatedUser_Success(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	user := &model.User{Email: "me@example.com"}
 	mockService.On("GetUserByEmail", "me@example.com").Return(user, nil)
@@ -202,7 +209,7 @@ func TestGetAuthenticatedUser_Success(t *testing.T) {
 
 func TestGetUserById_Success(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	user := &model.User{
 		Username: "tester",
@@ -221,7 +228,7 @@ func TestGetUserById_Success(t *testing.T) {
 
 func TestGetUserById_InvalidID(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	req, _ := http.NewRequest(http.MethodGet, "/api/user/abc", nil)
 	w := httptest.NewRecorder()
@@ -233,7 +240,7 @@ func TestGetUserById_InvalidID(t *testing.T) {
 
 func TestGetUserById_NotFound(t *testing.T) {
 	mockService := new(svcMocks.IUserService)
-	userServiceFacade := services.NewUserServiceFacade(mockService)
+	userServiceFacade := services.NewUserServiceFacade(mockService, new(svcMocks.IRevisionService))
 	handler := handler.NewHandler(*userServiceFacade)
 	mockService.On("GetUserById", "99").Return(nil, errors.New("user not found"))
 
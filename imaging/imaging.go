@@ -0,0 +1,117 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("only JPEG, PNG and GIF images are accepted")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Thumbnail widths generated for every upload, keyed by variant name.
+var ThumbnailWidths = map[string]int{
+	"small":  160,
+	"medium": 480,
+}
+
+// maxPixels guards against images that are small on disk but huge once
+// decoded.
+const maxPixels = 40_000_000
+
+// Decode checks the upload by sniffing its content rather than trusting the
+// file name or the declared content type, then decodes it. It returns the
+// sniffed content type along with the image.
+func Decode(data []byte) (image.Image, string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, "", ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupportedImage
+	}
+	return img, contentType, nil
+}
+
+// Resize scales the image down to the given width keeping its aspect ratio.
+// Each target pixel is the average of the source pixels it covers. Images
+// that are already narrow enough are returned as they are.
+func Resize(img image.Image, width int) image.Image {
+	src := img.Bounds()
+	if src.Dx() <= width {
+		return img
+	}
+	height := max(1, src.Dy()*width/src.Dx())
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := max(y0+1, src.Min.Y+(y+1)*src.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := max(x0+1, src.Min.X+(x+1)*src.Dx()/width)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.Set(x, y, color.NRGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(b / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
+
+// Encode writes the image back out. JPEG stays JPEG, everything else becomes
+// PNG so transparency survives. It returns the bytes, their content type and
+// the file extension to store them under.
+func Encode(img image.Image, contentType string) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	if contentType == "image/jpeg" {
+		err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", ".jpg", err
+	}
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", ".png", err
+}
+
+// Extension is the file extension for an upload kept in its original form.
+func Extension(contentType string) string {
+	switch contentType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	}
+	return ".png"
+}
@@ -0,0 +1,54 @@
+package imaging_test
+
+import (
+	"bytes"
+	"gin-demo/imaging"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodedPNG(t *testing.T, width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestDecode_SniffsContentNotName(t *testing.T) {
+	img, contentType, err := imaging.Decode(encodedPNG(t, 40, 20))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.Equal(t, 40, img.Bounds().Dx())
+
+	_, _, err = imaging.Decode([]byte("<html><body>not an image</body></html>"))
+	assert.ErrorIs(t, err, imaging.ErrUnsupportedImage)
+}
+
+func TestResize_KeepsAspectRatioAndColour(t *testing.T) {
+	img, _, err := imaging.Decode(encodedPNG(t, 400, 200))
+	require.NoError(t, err)
+
+	thumb := imaging.Resize(img, 160)
+
+	assert.Equal(t, 160, thumb.Bounds().Dx())
+	assert.Equal(t, 80, thumb.Bounds().Dy())
+	r, g, b, _ := thumb.At(10, 10).RGBA()
+	assert.Equal(t, []uint32{200, 100, 50}, []uint32{r >> 8, g >> 8, b >> 8})
+}
+
+func TestResize_LeavesSmallImagesAlone(t *testing.T) {
+	img, _, err := imaging.Decode(encodedPNG(t, 100, 50))
+	require.NoError(t, err)
+
+	assert.Same(t, img, imaging.Resize(img, 160))
+}
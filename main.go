@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"gin-demo/config"
+	"gin-demo/model"
 	"gin-demo/redis_utils"
 	"gin-demo/routes"
 	"log"
@@ -12,6 +13,7 @@ func main() {
 	env := config.Env
 
 	configFile := flag.String("config", "./config/config.json", "Path to the config file")
+	organization := flag.String("org", model.DefaultOrganization, "Organization whose catalog commands work on")
 	flag.Parse()
 
 	if err := config.LoadConfig(*configFile); err != nil {
@@ -23,6 +25,14 @@ func main() {
 	}
 	db := GetDatabase(config.GetConfig())
 	redis_utils.InitRedis(env)
+
+	if flag.NArg() > 0 {
+		if err := runCommand(db, *organization, flag.Args()); err != nil {
+			log.Fatalf("%s failed: %v", flag.Arg(0), err)
+		}
+		return
+	}
+
 	router := routes.SetupRouter(db)
 
 	router.Run(":8080")
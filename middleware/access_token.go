@@ -12,8 +12,9 @@ import (
 )
 
 type Claims struct {
-	Email     string `json:"email"`
-	SessionID string `json:"session_id"`
+	Email        string `json:"email"`
+	SessionID    string `json:"session_id"`
+	Organization string `json:"organization,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -32,13 +33,16 @@ func NewJWTStrategy(redisClient *redis.Client) *JWTStrategy {
 	}
 }
 
-func (s *JWTStrategy) GenerateAccessToken(email string) (string, error) {
+// GenerateAccessToken issues a token for the user. The organization, when
+// given, is the catalog the token works with unless a request picks another.
+func (s *JWTStrategy) GenerateAccessToken(email, organization string) (string, error) {
 	expirationTime := time.Now().Add(8 * time.Hour)
 	sessionID := uuid.New().String()
 	ctx := context.Background()
 	claims := &Claims{
-		Email:     email,
-		SessionID: sessionID,
+		Email:        email,
+		SessionID:    sessionID,
+		Organization: organization,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
@@ -85,8 +89,9 @@ func (s *JWTStrategy) ValidateAccessToken(tokenString string) (*TokenData, error
 	}
 
 	return &TokenData{
-		Email:     claims.Email,
-		SessionID: claims.SessionID,
+		Email:        claims.Email,
+		SessionID:    claims.SessionID,
+		Organization: claims.Organization,
 	}, nil
 }
 
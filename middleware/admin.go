@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	errMessage "gin-demo/errors"
+	"gin-demo/model"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminMiddleware only lets admins through, the users who manage users and
+// organizations. It has to run after AuthMiddleware.
+func AdminMiddleware(lookup func(email string) (*model.User, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := lookup(c.GetString("email"))
+		if err != nil || user.Role != model.RoleAdmin {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errMessage.AdminOnly})
+			return
+		}
+		c.Next()
+	}
+}
+
+// TenantAdminMiddleware only lets through the admins of the organization the
+// request works with. It has to run after TenantMiddleware.
+func TenantAdminMiddleware() gin.HandlerFunc {
+	return tenantRole(errMessage.AdminOnly, model.RoleAdmin)
+}
+
+// EditorMiddleware only lets through the editors and admins of the
+// organization the request works with, the users who work on its catalog.
+// It has to run after TenantMiddleware.
+func EditorMiddleware() gin.HandlerFunc {
+	return tenantRole(errMessage.EditorOnly, model.RoleEditor, model.RoleAdmin)
+}
+
+func tenantRole(message string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString(TenantRoleKey)) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": message})
+			return
+		}
+		c.Next()
+	}
+}
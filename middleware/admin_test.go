@@ -0,0 +1,41 @@
+package middleware_test
+
+import (
+	"gin-demo/middleware"
+	"gin-demo/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func tenantRouter(role string, guard gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.TenantMiddleware(func(email, requested string) (*model.Membership, error) {
+		return &model.Membership{Organization: "acme", Role: role}, nil
+	}))
+	r.POST("/movies", guard, func(c *gin.Context) { c.Status(http.StatusCreated) })
+	return r
+}
+
+func TestTenantRoles(t *testing.T) {
+	cases := []struct {
+		role   string
+		guard  gin.HandlerFunc
+		status int
+	}{
+		{model.RoleViewer, middleware.EditorMiddleware(), http.StatusForbidden},
+		{model.RoleEditor, middleware.EditorMiddleware(), http.StatusCreated},
+		{model.RoleAdmin, middleware.EditorMiddleware(), http.StatusCreated},
+		{model.RoleEditor, middleware.TenantAdminMiddleware(), http.StatusForbidden},
+		{model.RoleAdmin, middleware.TenantAdminMiddleware(), http.StatusCreated},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		tenantRouter(tc.role, tc.guard).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/movies", nil))
+		assert.Equal(t, tc.status, w.Code, tc.role)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"errors"
+	errMessage "gin-demo/errors"
+	"gin-demo/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AudienceKey is where AudienceMiddleware leaves the caller's audience.
+const AudienceKey = "audience"
+
+// AudienceMiddleware works out once per request what the authenticated user
+// may see, so every catalog read can filter by it. It has to run after
+// AuthMiddleware, and on catalog routes after TenantMiddleware so the user's
+// role is the one they have in the organization.
+func AudienceMiddleware(resolve func(email, organization string) (*model.Audience, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		audience, err := resolve(c.GetString("email"), c.GetString(TenantKey))
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errMessage.UserNotAuthenticated})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(AudienceKey, audience)
+		c.Next()
+	}
+}
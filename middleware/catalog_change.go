@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CatalogChangeMiddleware calls onChange after every successful request that
+// may have written something, so caches built from the catalog can be
+// refreshed. It only belongs on routes that write the catalog.
+func CatalogChangeMiddleware(onChange func()) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return
+		}
+		if c.Writer.Status() < http.StatusBadRequest {
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"gin-demo/config"
+	errMessage "gin-demo/errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const IdempotencyHeader = "Idempotency-Key"
+
+// replayedHeaders are the response headers stored next to the body so a
+// replay looks like the original response.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type idempotencyRecord struct {
+	Fingerprint string            `json:"fingerprint"`
+	Done        bool              `json:"done"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers"`
+	Body        []byte            `json:"body"`
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes POST requests sent with an Idempotency-Key safe
+// to retry. The first response is kept in Redis and replayed for every retry
+// with the same key and payload. While the first request is handled the key
+// is only locked for the short pending TTL, and released if the handler
+// fails or panics. Keys are scoped to the authenticated user, so it has to
+// run after AuthMiddleware, and on catalog routes to the organization, so it
+// has to run after TenantMiddleware there.
+func IdempotencyMiddleware(client *redis.Client, cfg config.IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if buffered(c.Request) {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxBody())
+			var err error
+			body, err = io.ReadAll(c.Request.Body)
+			if err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": errMessage.RequestTooLarge})
+					return
+				}
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		ctx := context.Background()
+		scope := c.GetString("email")
+		if tenant := c.GetString(TenantKey); tenant != "" {
+			scope = tenant + ":" + scope
+		}
+		redisKey := "idempotency:" + scope + ":" + key
+		fingerprint := requestFingerprint(c, body)
+
+		pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+		acquired, err := client.SetNX(ctx, redisKey, pending, cfg.PendingTTL()).Result()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": errMessage.IdempotencyStoreFail})
+			return
+		}
+		if !acquired {
+			replay(c, client, redisKey, fingerprint)
+			return
+		}
+
+		// Unless a response is stored below the key is released, so neither
+		// a panic nor a server error keeps retries locked out.
+		stored := false
+		defer func() {
+			if !stored {
+				client.Del(ctx, redisKey)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not worth replaying, the client should be able
+		// to retry them for real.
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		record := idempotencyRecord{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      recorder.Status(),
+			Headers:     map[string]string{},
+			Body:        recorder.body.Bytes(),
+		}
+		for _, h := range replayedHeaders {
+			if v := recorder.Header().Get(h); v != "" {
+				record.Headers[h] = v
+			}
+		}
+		raw, _ := json.Marshal(record)
+		stored = client.Set(ctx, redisKey, raw, cfg.TTL()).Err() == nil
+	}
+}
+
+func replay(c *gin.Context, client *redis.Client, redisKey, fingerprint string) {
+	raw, err := client.Get(context.Background(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The original request failed and released the key in between.
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": errMessage.IdempotencyInFlight})
+		return
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": errMessage.IdempotencyStoreFail})
+		return
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": errMessage.IdempotencyStoreFail})
+		return
+	}
+	if record.Fingerprint != fingerprint {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": errMessage.IdempotencyKeyReused})
+		return
+	}
+	if !record.Done {
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": errMessage.IdempotencyInFlight})
+		return
+	}
+
+	for h, v := range record.Headers {
+		c.Header(h, v)
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.Status, record.Headers["Content-Type"], record.Body)
+	c.Abort()
+}
+
+// buffered tells the JSON bodies, which are read up to the configured cap
+// and fingerprinted by their content, from uploads and imports. Those are
+// multipart or streamed, can be far larger and are left to the route's own
+// limit, so they are fingerprinted by their headers instead.
+func buffered(r *http.Request) bool {
+	if r.ContentLength < 0 {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// requestFingerprint identifies the payload a key was first used with. A body
+// that was not buffered is nil and stands in by its type and length.
+func requestFingerprint(c *gin.Context, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	if body == nil {
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		sum.Write([]byte(mediaType + " " + strconv.FormatInt(c.Request.ContentLength, 10) + "\n"))
+	}
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
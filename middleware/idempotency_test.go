@@ -0,0 +1,168 @@
+package middleware_test
+
+import (
+	"errors"
+	"fmt"
+	"gin-demo/config"
+	errMessage "gin-demo/errors"
+	"gin-demo/middleware"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redismock/v9"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const idempotencyKey = "idempotency:john@example.com:abc"
+
+var idempotencyConfig = config.IdempotencyConfig{TTLSeconds: 3600, PendingSeconds: 30, MaxBodyBytes: 64}
+
+// capture matches a command on its name and key only and keeps the value it
+// was sent with, so tests can hand back what the middleware stored.
+func capture(value *string) redismock.CustomMatch {
+	return func(expected, actual []interface{}) error {
+		if len(actual) < 3 || fmt.Sprint(expected[:2]) != fmt.Sprint(actual[:2]) {
+			return fmt.Errorf("expected %v, got %v", expected, actual)
+		}
+		*value = fmt.Sprintf("%s", actual[2])
+		return nil
+	}
+}
+
+func idempotentRouter(client *redis.Client, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard))
+	r.Use(func(c *gin.Context) { c.Set("email", "john@example.com") })
+	r.Use(middleware.IdempotencyMiddleware(client, idempotencyConfig))
+	r.POST("/movies", handler)
+	return r
+}
+
+func post(r *gin.Engine, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/movies", strings.NewReader(body))
+	req.Header.Set(middleware.IdempotencyHeader, "abc")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func created(c *gin.Context) {
+	c.Header("Location", "/api/movie/1")
+	c.JSON(http.StatusCreated, gin.H{"id": "1"})
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	calls := 0
+	r := idempotentRouter(client, func(c *gin.Context) {
+		calls++
+		created(c)
+	})
+
+	var stored string
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(true)
+	mock.CustomMatch(capture(&stored)).ExpectSet(idempotencyKey, "", time.Hour).SetVal("OK")
+	first := post(r, `{"title":"Dawn"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(false)
+	mock.ExpectGet(idempotencyKey).SetVal(stored)
+	retry := post(r, `{"title":"Dawn"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/api/movie/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	r := idempotentRouter(client, created)
+
+	var stored string
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(true)
+	mock.CustomMatch(capture(&stored)).ExpectSet(idempotencyKey, "", time.Hour).SetVal("OK")
+	post(r, `{"title":"Dawn"}`)
+
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(false)
+	mock.ExpectGet(idempotencyKey).SetVal(stored)
+	w := post(r, `{"title":"Dusk"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), errMessage.IdempotencyKeyReused)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	r := idempotentRouter(client, created)
+
+	var pending string
+	mock.CustomMatch(capture(&pending)).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(true)
+	mock.CustomMatch(capture(new(string))).ExpectSet(idempotencyKey, "", time.Hour).SetVal("OK")
+	post(r, `{"title":"Dawn"}`)
+
+	// The first request is still running: only its pending record is there.
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(false)
+	mock.ExpectGet(idempotencyKey).SetVal(pending)
+	w := post(r, `{"title":"Dawn"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), errMessage.IdempotencyInFlight)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	r := idempotentRouter(client, func(c *gin.Context) {
+		panic(errors.New("boom"))
+	})
+
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(true)
+	mock.ExpectDel(idempotencyKey).SetVal(1)
+	w := post(r, `{"title":"Dawn"}`)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	r := idempotentRouter(client, created)
+
+	w := post(r, `{"title":"`+strings.Repeat("a", 100)+`"}`)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestIdempotencyLeavesUploadsUnbuffered(t *testing.T) {
+	client, mock := redismock.NewClientMock()
+	var received int
+	r := idempotentRouter(client, func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = len(body)
+		created(c)
+	})
+
+	mock.CustomMatch(capture(new(string))).ExpectSetNX(idempotencyKey, "", 30*time.Second).SetVal(true)
+	mock.CustomMatch(capture(new(string))).ExpectSet(idempotencyKey, "", time.Hour).SetVal("OK")
+	req := httptest.NewRequest(http.MethodPost, "/movies", strings.NewReader(strings.Repeat("a", 100)))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	req.Header.Set(middleware.IdempotencyHeader, "abc")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 100, received)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
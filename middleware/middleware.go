@@ -46,6 +46,7 @@ func AuthMiddleware(strategy JWTStrategy) gin.HandlerFunc {
 		}
 
 		c.Set("email", claims.Email)
+		c.Set(TokenOrganizationKey, claims.Organization)
 		c.Next()
 	}
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"gin-demo/model"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SlugMiddleware lets a route take a slug wherever it takes an id in param.
+// A current slug is swapped for the id before the handler runs, while an
+// alias left behind by a rename is answered with a permanent redirect to
+// the same URL under the current slug. resolve gets the audience left by
+// AudienceMiddleware and reports what it may not see as not found, so a hidden
+// target is answered with 404 rather than redirected to.
+func SlugMiddleware(param string, resolve func(slug string, audience *model.Audience) (*model.SlugMatch, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.Param(param)
+		if model.LooksLikeID(value) {
+			c.Next()
+			return
+		}
+
+		audience, _ := c.Get(AudienceKey)
+		viewer, _ := audience.(*model.Audience)
+		match, err := resolve(value, viewer)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if match.Alias {
+			location := *c.Request.URL
+			location.Path = canonicalPath(c.FullPath(), c.Request.URL.Path, param, match.Slug)
+			location.RawPath = ""
+			c.Redirect(http.StatusMovedPermanently, location.RequestURI())
+			c.Abort()
+			return
+		}
+
+		for i := range c.Params {
+			if c.Params[i].Key == param {
+				c.Params[i].Value = match.ID.Hex()
+			}
+		}
+		c.Next()
+	}
+}
+
+// canonicalPath puts slug into path where the route has param.
+func canonicalPath(route, path, param, slug string) string {
+	routeSegments := strings.Split(route, "/")
+	pathSegments := strings.Split(path, "/")
+	for i, segment := range routeSegments {
+		if segment == ":"+param && i < len(pathSegments) {
+			pathSegments[i] = slug
+		}
+	}
+	return strings.Join(pathSegments, "/")
+}
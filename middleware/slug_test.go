@@ -0,0 +1,42 @@
+package middleware_test
+
+import (
+	"gin-demo/middleware"
+	"gin-demo/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func slugRouter(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.AudienceMiddleware(func(email, organization string) (*model.Audience, error) {
+		return &model.Audience{Role: role}, nil
+	}))
+	// Only editors may see the movie behind "old-draft".
+	resolve := func(slug string, audience *model.Audience) (*model.SlugMatch, error) {
+		if !audience.Editorial() {
+			return nil, model.NotFound("movie")
+		}
+		return &model.SlugMatch{ID: primitive.NewObjectID(), Slug: "draft", Alias: true}, nil
+	}
+	r.GET("/movie/:id", middleware.SlugMiddleware("id", resolve), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestSlugAliasHiddenFromAudience(t *testing.T) {
+	w := httptest.NewRecorder()
+	slugRouter(model.RoleViewer).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/movie/old-draft", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	slugRouter(model.RoleEditor).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/movie/old-draft", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/movie/draft", w.Header().Get("Location"))
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	errMessage "gin-demo/errors"
+	"gin-demo/model"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// OrganizationHeader picks the organization for one request, overriding
+	// the one in the token.
+	OrganizationHeader = "X-Organization"
+	// TokenOrganizationKey is where AuthMiddleware leaves the organization
+	// the token was issued for.
+	TokenOrganizationKey = "token_organization"
+	// TenantKey is where TenantMiddleware leaves the organization the
+	// request works with.
+	TenantKey = "tenant"
+	// TenantRoleKey is where TenantMiddleware leaves the user's role in that
+	// organization.
+	TenantRoleKey = "tenant_role"
+)
+
+// TenantMiddleware works out which organization's catalog the request works
+// with, from the X-Organization header or else the token, and makes sure the
+// user belongs to it. It has to run after AuthMiddleware.
+func TenantMiddleware(resolve func(email, requested string) (*model.Membership, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := c.GetHeader(OrganizationHeader)
+		if requested == "" {
+			requested = c.GetString(TokenOrganizationKey)
+		}
+
+		membership, err := resolve(c.GetString("email"), requested)
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": errMessage.UserNotAuthenticated})
+			return
+		case errors.Is(err, model.ErrNotMember):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": errMessage.NotMember})
+			return
+		case errors.Is(err, model.ErrOrganizationRequired):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": errMessage.OrganizationRequired})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(TenantKey, membership.Organization)
+		c.Set(TenantRoleKey, membership.Role)
+		c.Next()
+	}
+}
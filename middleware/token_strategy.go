@@ -5,8 +5,9 @@ import (
 )
 
 type TokenData struct {
-	Email     string
-	SessionID string
+	Email        string
+	SessionID    string
+	Organization string
 }
 
 // Token strategy is using strategy pattern,
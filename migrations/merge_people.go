@@ -0,0 +1,176 @@
+package migrations
+
+import (
+	"context"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MergePeopleReport struct {
+	ActorsRead     int `json:"actors_read"`
+	DirectorsRead  int `json:"directors_read"`
+	PeopleWritten  int `json:"people_written"`
+	DuplicatesSeen int `json:"duplicates_seen"`
+	MoviesUpdated  int `json:"movies_updated"`
+}
+
+// MergePeople copies the legacy actors and directors collections into the
+// people collection. Matching records are merged into one person, movies are
+// rewritten to point at the surviving IDs and get their crew list built.
+// Actor IDs are kept as person IDs, so running it twice is harmless.
+// The legacy collections are left in place.
+func MergePeople(db *mongo.Database) (*MergePeopleReport, error) {
+	ctx := context.Background()
+	report := &MergePeopleReport{}
+
+	byKey := map[string]*model.Person{}
+	byID := map[primitive.ObjectID]*model.Person{}
+	remap := map[primitive.ObjectID]primitive.ObjectID{}
+	var order []*model.Person
+
+	existing, err := loadPeople(ctx, db.Collection("people"))
+	if err != nil {
+		return nil, err
+	}
+	for i := range existing {
+		p := &existing[i]
+		byKey[p.IdentityKey()] = p
+		byID[p.ID] = p
+		order = append(order, p)
+	}
+
+	merge := func(legacy []model.Person, department string) {
+		for i := range legacy {
+			l := legacy[i]
+			key := l.IdentityKey()
+			p, ok := byID[l.ID]
+			if !ok {
+				p, ok = byKey[key]
+			}
+			if ok {
+				if p.ID != l.ID {
+					remap[l.ID] = p.ID
+					report.DuplicatesSeen++
+				}
+				if !p.HasDepartment(department) {
+					p.Departments = append(p.Departments, department)
+				}
+				continue
+			}
+			l.Departments = []string{department}
+			byKey[key] = &l
+			byID[l.ID] = &l
+			order = append(order, &l)
+		}
+	}
+
+	actors, err := loadPeople(ctx, db.Collection("actors"))
+	if err != nil {
+		return nil, err
+	}
+	report.ActorsRead = len(actors)
+	merge(actors, model.DepartmentActing)
+
+	directors, err := loadPeople(ctx, db.Collection("directors"))
+	if err != nil {
+		return nil, err
+	}
+	report.DirectorsRead = len(directors)
+	merge(directors, model.DepartmentDirecting)
+
+	if len(order) > 0 {
+		writes := make([]mongo.WriteModel, 0, len(order))
+		for _, p := range order {
+			writes = append(writes, mongo.NewReplaceOneModel().
+				SetFilter(bson.M{"_id": p.ID}).
+				SetReplacement(p).
+				SetUpsert(true))
+		}
+		if _, err := db.Collection("people").BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return nil, err
+		}
+		report.PeopleWritten = len(order)
+	}
+
+	updated, err := rewriteMovieCredits(ctx, db.Collection("movie"), remap)
+	if err != nil {
+		return nil, err
+	}
+	report.MoviesUpdated = updated
+
+	return report, nil
+}
+
+func loadPeople(ctx context.Context, collection *mongo.Collection) ([]model.Person, error) {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var people []model.Person
+	if err := cursor.All(ctx, &people); err != nil {
+		return nil, err
+	}
+	return people, nil
+}
+
+func rewriteMovieCredits(
+	ctx context.Context,
+	movies *mongo.Collection,
+	remap map[primitive.ObjectID]primitive.ObjectID,
+) (int, error) {
+	resolve := func(id primitive.ObjectID) primitive.ObjectID {
+		if to, ok := remap[id]; ok {
+			return to
+		}
+		return id
+	}
+
+	cursor, err := movies.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cursor.Next(ctx) {
+		var m model.Movie
+		if err := cursor.Decode(&m); err != nil {
+			return 0, err
+		}
+
+		m.DirectorID = resolve(m.DirectorID)
+		for i := range m.Actors {
+			m.Actors[i] = resolve(m.Actors[i])
+		}
+		for i := range m.Crew {
+			m.Crew[i].PersonID = resolve(m.Crew[i].PersonID)
+		}
+		m.SyncCrew()
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": m.ID}).
+			SetUpdate(bson.M{"$set": bson.M{
+				"director_id": m.DirectorID,
+				"actors":      m.Actors,
+				"crew":        m.Crew,
+			}}))
+	}
+	if err := cursor.Err(); err != nil {
+		return 0, err
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+
+	res, err := movies.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
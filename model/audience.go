@@ -0,0 +1,99 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Roles a user can have. Editors and admins see the whole catalog whatever
+// their age.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+func ValidRole(role string) bool {
+	return role == RoleViewer || role == RoleEditor || role == RoleAdmin
+}
+
+// ParentalControl replaces the user's age as the limit for what they may see.
+// Changing or removing it takes the PIN it was set up with.
+type ParentalControl struct {
+	MaxAge    int       `bson:"max_age" json:"max_age"`
+	PINHash   string    `bson:"pin_hash" json:"-"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Audience is who a catalog read is for. A nil audience, as used internally,
+// sees everything.
+type Audience struct {
+	Country      string
+	MaxAge       int
+	Unrestricted bool
+	Role         string
+}
+
+// Editorial reports whether the audience works on the catalog and so sees
+// movies in every workflow status, not only published ones.
+func (a *Audience) Editorial() bool {
+	return a.Role == RoleEditor || a.Role == RoleAdmin
+}
+
+// Allows tells whether the audience may see the movie: unless it is an
+// editorial audience the movie must be published, and it must not have been
+// rated above the audience's age in the audience's country. Movies without a
+// rating there are shown.
+func (a *Audience) Allows(m *Movie) bool {
+	if a == nil {
+		return true
+	}
+	if !a.Editorial() && !m.Published(time.Now()) {
+		return false
+	}
+	if a.Unrestricted {
+		return true
+	}
+	for _, r := range m.Releases {
+		if r.Country == a.Country && r.MinAge > a.MaxAge {
+			return false
+		}
+	}
+	return true
+}
+
+// certificationAges maps the certifications of the larger markets to the age
+// they require. Anything else is read by CertificationMinAge.
+var certificationAges = map[string]map[string]int{
+	"US": {"G": 0, "PG": 0, "PG-13": 13, "R": 17, "NC-17": 18, "TV-Y": 0, "TV-Y7": 7, "TV-G": 0,
+		"TV-PG": 0, "TV-14": 14, "TV-MA": 17},
+	"GB": {"U": 0, "PG": 0, "12A": 12, "12": 12, "15": 15, "18": 18, "R18": 18},
+	"IE": {"G": 0, "PG": 0, "12A": 12, "15A": 15, "16": 16, "18": 18},
+	"CA": {"G": 0, "PG": 0, "14A": 14, "18A": 18, "R": 18},
+	"AU": {"G": 0, "PG": 0, "M": 15, "MA15+": 15, "R18+": 18, "X18+": 18},
+	"FR": {"U": 0, "TP": 0, "-10": 10, "-12": 12, "-16": 16, "-18": 18},
+	"BR": {"L": 0},
+}
+
+var certificationNumber = regexp.MustCompile(`\d+`)
+
+// CertificationMinAge is the age a certification requires in a country. Most
+// systems put the age in the label, as in FSK 12 or PEGI 16, so unknown
+// labels fall back to the number they contain. Labels without one require
+// nothing.
+func CertificationMinAge(country, certification string) int {
+	label := strings.ToUpper(strings.TrimSpace(certification))
+	if label == "" {
+		return 0
+	}
+	if age, ok := certificationAges[country][label]; ok {
+		return age
+	}
+	if n := certificationNumber.FindString(label); n != "" {
+		age, _ := strconv.Atoi(n)
+		return age
+	}
+	return 0
+}
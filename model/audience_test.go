@@ -0,0 +1,33 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificationMinAge(t *testing.T) {
+	assert.Equal(t, 13, model.CertificationMinAge("US", "PG-13"))
+	assert.Equal(t, 17, model.CertificationMinAge("US", "r"))
+	assert.Equal(t, 12, model.CertificationMinAge("GB", "12A"))
+	assert.Equal(t, 12, model.CertificationMinAge("DE", "FSK 12"))
+	assert.Equal(t, 16, model.CertificationMinAge("NL", "16"))
+	assert.Equal(t, 0, model.CertificationMinAge("GB", "U"))
+	assert.Equal(t, 0, model.CertificationMinAge("US", ""))
+}
+
+func TestAudienceAllows(t *testing.T) {
+	movie := &model.Movie{Releases: []model.Release{
+		{Country: "US", MinAge: 17},
+		{Country: "DE", MinAge: 12},
+	}}
+
+	assert.False(t, (&model.Audience{Country: "US", MaxAge: 14}).Allows(movie))
+	assert.True(t, (&model.Audience{Country: "DE", MaxAge: 14}).Allows(movie))
+	assert.True(t, (&model.Audience{Country: "FR", MaxAge: 10}).Allows(movie))
+	assert.True(t, (&model.Audience{Country: "US", MaxAge: 14, Unrestricted: true}).Allows(movie))
+
+	var internal *model.Audience
+	assert.True(t, internal.Allows(movie))
+}
@@ -0,0 +1,263 @@
+package model
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Award is one category of one edition of a ceremony, such as Best Director
+// at the 2004 Academy Awards, together with everybody nominated in it.
+type Award struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Ceremony string             `bson:"ceremony" json:"ceremony"`
+	Year     int                `bson:"year" json:"year"`
+	Category string             `bson:"category" json:"category"`
+	Nominees []Nominee          `bson:"nominees" json:"nominees"`
+	Version  int64              `bson:"version" json:"version"`
+}
+
+// Nominee is the nomination of a movie and, in personal categories, of one of
+// the people credited on it. Won marks the winners.
+type Nominee struct {
+	MovieID  primitive.ObjectID  `bson:"movie_id" json:"movie_id"`
+	PersonID *primitive.ObjectID `bson:"person_id,omitempty" json:"person_id,omitempty"`
+	Won      bool                `bson:"won" json:"won"`
+
+	MovieTitle string  `bson:"-" json:"movie_title,omitempty"`
+	Person     *Person `bson:"-" json:"person,omitempty"`
+}
+
+// Normalize trims the names, checks the award is complete and drops repeated
+// nominations of the same movie and person.
+func (a *Award) Normalize() error {
+	a.Ceremony = strings.TrimSpace(a.Ceremony)
+	a.Category = strings.TrimSpace(a.Category)
+	if a.Ceremony == "" || a.Category == "" {
+		return invalidDocument("award ceremony and category are required")
+	}
+	if a.Year <= 0 {
+		return invalidDocument("award year must be a positive number")
+	}
+	nominees, err := normalizeNominees(a.Nominees)
+	if err != nil {
+		return err
+	}
+	a.Nominees = nominees
+	return nil
+}
+
+func normalizeNominees(nominees []Nominee) ([]Nominee, error) {
+	type key struct{ movie, person primitive.ObjectID }
+	out := make([]Nominee, 0, len(nominees))
+	seen := map[key]bool{}
+	for _, n := range nominees {
+		if n.MovieID.IsZero() {
+			return nil, invalidDocument("every nominee needs a movie_id")
+		}
+		if n.PersonID != nil && n.PersonID.IsZero() {
+			n.PersonID = nil
+		}
+		k := key{movie: n.MovieID}
+		if n.PersonID != nil {
+			k.person = *n.PersonID
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, Nominee{MovieID: n.MovieID, PersonID: n.PersonID, Won: n.Won})
+	}
+	return out, nil
+}
+
+// AwardUpdate is the body of a PUT on an award. Fields left out keep their
+// value; nominees replaces the whole list.
+type AwardUpdate struct {
+	Ceremony *string   `json:"ceremony"`
+	Year     *int      `json:"year"`
+	Category *string   `json:"category"`
+	Nominees []Nominee `json:"nominees"`
+}
+
+// Document turns the update into the fields to set.
+func (u *AwardUpdate) Document() (bson.M, error) {
+	update := bson.M{}
+	if u.Ceremony != nil {
+		ceremony := strings.TrimSpace(*u.Ceremony)
+		if ceremony == "" {
+			return nil, invalidDocument("award ceremony and category are required")
+		}
+		update["ceremony"] = ceremony
+	}
+	if u.Category != nil {
+		category := strings.TrimSpace(*u.Category)
+		if category == "" {
+			return nil, invalidDocument("award ceremony and category are required")
+		}
+		update["category"] = category
+	}
+	if u.Year != nil {
+		if *u.Year <= 0 {
+			return nil, invalidDocument("award year must be a positive number")
+		}
+		update["year"] = *u.Year
+	}
+	if u.Nominees != nil {
+		nominees, err := normalizeNominees(u.Nominees)
+		if err != nil {
+			return nil, err
+		}
+		update["nominees"] = nominees
+	}
+	return update, nil
+}
+
+// Credits reports whether the person worked on the movie in any department.
+func (m *Movie) Credits(personID primitive.ObjectID) bool {
+	if m.DirectorID == personID {
+		return true
+	}
+	for _, id := range m.Actors {
+		if id == personID {
+			return true
+		}
+	}
+	for _, c := range m.Crew {
+		if c.PersonID == personID {
+			return true
+		}
+	}
+	return false
+}
+
+// AwardEntry is one nomination as listed for a movie or a person.
+type AwardEntry struct {
+	AwardID  primitive.ObjectID `json:"award_id"`
+	Ceremony string             `json:"ceremony"`
+	Year     int                `json:"year"`
+	Category string             `json:"category"`
+	Nominee
+}
+
+// AwardEntries lists the nominations matching keep, newest edition first.
+func AwardEntries(awards []Award, keep func(Nominee) bool) []AwardEntry {
+	entries := []AwardEntry{}
+	for _, a := range awards {
+		for _, n := range a.Nominees {
+			if keep(n) {
+				entries = append(entries, AwardEntry{
+					AwardID:  a.ID,
+					Ceremony: a.Ceremony,
+					Year:     a.Year,
+					Category: a.Category,
+					Nominee:  n,
+				})
+			}
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Year != entries[j].Year {
+			return entries[i].Year > entries[j].Year
+		}
+		if entries[i].Ceremony != entries[j].Ceremony {
+			return entries[i].Ceremony < entries[j].Ceremony
+		}
+		return entries[i].Category < entries[j].Category
+	})
+	return entries
+}
+
+// NominatedPerson keeps the nominations of one person.
+func NominatedPerson(personID primitive.ObjectID) func(Nominee) bool {
+	return func(n Nominee) bool {
+		return n.PersonID != nil && *n.PersonID == personID
+	}
+}
+
+// NominatedMovie keeps the nominations of one movie.
+func NominatedMovie(movieID primitive.ObjectID) func(Nominee) bool {
+	return func(n Nominee) bool {
+		return n.MovieID == movieID
+	}
+}
+
+// CeremonyResults lists the nominees of one edition of a ceremony by
+// category.
+type CeremonyResults struct {
+	Ceremony   string             `json:"ceremony"`
+	Year       int                `json:"year"`
+	Categories []CeremonyCategory `json:"categories"`
+}
+
+type CeremonyCategory struct {
+	AwardID  primitive.ObjectID `json:"award_id"`
+	Category string             `json:"category"`
+	Nominees []Nominee          `json:"nominees"`
+}
+
+// NewCeremonyResults groups the awards of one edition, categories sorted by
+// name and winners listed first.
+func NewCeremonyResults(ceremony string, year int, awards []Award) *CeremonyResults {
+	results := &CeremonyResults{Ceremony: ceremony, Year: year, Categories: []CeremonyCategory{}}
+	for _, a := range awards {
+		nominees := append([]Nominee{}, a.Nominees...)
+		sort.SliceStable(nominees, func(i, j int) bool {
+			return nominees[i].Won && !nominees[j].Won
+		})
+		results.Categories = append(results.Categories, CeremonyCategory{
+			AwardID:  a.ID,
+			Category: a.Category,
+			Nominees: nominees,
+		})
+		results.Ceremony = a.Ceremony
+	}
+	sort.SliceStable(results.Categories, func(i, j int) bool {
+		return results.Categories[i].Category < results.Categories[j].Category
+	})
+	return results
+}
+
+// AwardCount sums up nominations, wins included, and wins.
+type AwardCount struct {
+	Nominations int `json:"nominations"`
+	Wins        int `json:"wins"`
+}
+
+func (c *AwardCount) add(n Nominee) {
+	c.Nominations++
+	if n.Won {
+		c.Wins++
+	}
+}
+
+// AddAwards counts the person's nominations on each credit of the
+// filmography. The total only covers movies listed in it, so a director's
+// filmography does not count acting awards for movies they only starred in.
+func (f *Filmography) AddAwards(entries []AwardEntry) {
+	byMovie := map[primitive.ObjectID]AwardCount{}
+	for _, e := range entries {
+		count := byMovie[e.MovieID]
+		count.add(e.Nominee)
+		byMovie[e.MovieID] = count
+	}
+	f.Awards = AwardCount{}
+	for i := range f.Decades {
+		for j := range f.Decades[i].Credits {
+			credit := &f.Decades[i].Credits[j]
+			credit.Awards = byMovie[credit.MovieID]
+			f.Awards.Nominations += credit.Awards.Nominations
+			f.Awards.Wins += credit.Awards.Wins
+		}
+	}
+}
+
+// AwardFilter narrows movie and people lists to those nominated for, or with
+// WonOnly those who won, an award. Zero fields match any ceremony or year.
+type AwardFilter struct {
+	Ceremony string
+	Year     int
+	WonOnly  bool
+}
@@ -0,0 +1,83 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestAwardNormalizeDropsRepeatedNominees(t *testing.T) {
+	movie, person := primitive.NewObjectID(), primitive.NewObjectID()
+	award := &model.Award{
+		Ceremony: " Academy Awards ",
+		Year:     2004,
+		Category: "Best Director",
+		Nominees: []model.Nominee{
+			{MovieID: movie, PersonID: &person, Won: true},
+			{MovieID: movie, PersonID: &person},
+			{MovieID: movie},
+		},
+	}
+	require.NoError(t, award.Normalize())
+	assert.Equal(t, "Academy Awards", award.Ceremony)
+	require.Len(t, award.Nominees, 2)
+	assert.True(t, award.Nominees[0].Won)
+
+	award.Nominees = []model.Nominee{{}}
+	assert.ErrorIs(t, award.Normalize(), model.ErrInvalidDocument)
+	assert.ErrorIs(t, (&model.Award{Ceremony: "BAFTA", Category: "Best Film"}).Normalize(), model.ErrInvalidDocument)
+}
+
+func TestAwardEntriesNewestFirst(t *testing.T) {
+	movie, person := primitive.NewObjectID(), primitive.NewObjectID()
+	awards := []model.Award{
+		{Ceremony: "Academy Awards", Year: 2002, Category: "Best Picture", Nominees: []model.Nominee{{MovieID: movie}}},
+		{Ceremony: "Academy Awards", Year: 2004, Category: "Best Director", Nominees: []model.Nominee{
+			{MovieID: movie, PersonID: &person, Won: true},
+			{MovieID: primitive.NewObjectID(), PersonID: ptr(primitive.NewObjectID())},
+		}},
+	}
+
+	entries := model.AwardEntries(awards, model.NominatedMovie(movie))
+	require.Len(t, entries, 2)
+	assert.Equal(t, 2004, entries[0].Year)
+	assert.Equal(t, "Best Picture", entries[1].Category)
+
+	entries = model.AwardEntries(awards, model.NominatedPerson(person))
+	require.Len(t, entries, 1)
+	assert.True(t, entries[0].Won)
+}
+
+func TestCeremonyResultsListWinnersFirst(t *testing.T) {
+	loser, winner := primitive.NewObjectID(), primitive.NewObjectID()
+	results := model.NewCeremonyResults("academy awards", 2004, []model.Award{
+		{Ceremony: "Academy Awards", Category: "Best Picture", Nominees: []model.Nominee{{MovieID: loser}, {MovieID: winner, Won: true}}},
+		{Ceremony: "Academy Awards", Category: "Best Director"},
+	})
+	assert.Equal(t, "Academy Awards", results.Ceremony)
+	require.Len(t, results.Categories, 2)
+	assert.Equal(t, "Best Director", results.Categories[0].Category)
+	assert.Equal(t, winner, results.Categories[1].Nominees[0].MovieID)
+}
+
+func TestFilmographyAddAwardsCountsListedMovies(t *testing.T) {
+	credited, other := primitive.NewObjectID(), primitive.NewObjectID()
+	filmography := &model.Filmography{Decades: []model.FilmographyDecade{
+		{Credits: []model.FilmographyCredit{{MovieID: credited}}},
+	}}
+
+	filmography.AddAwards([]model.AwardEntry{
+		{Nominee: model.Nominee{MovieID: credited, Won: true}},
+		{Nominee: model.Nominee{MovieID: credited}},
+		{Nominee: model.Nominee{MovieID: other, Won: true}},
+	})
+	assert.Equal(t, model.AwardCount{Nominations: 2, Wins: 1}, filmography.Awards)
+	assert.Equal(t, 1, filmography.Decades[0].Credits[0].Awards.Wins)
+}
+
+func ptr(id primitive.ObjectID) *primitive.ObjectID {
+	return &id
+}
@@ -0,0 +1,296 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ImportKindPeople = "people"
+	ImportKindMovies = "movies"
+
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+
+	ImportModeInsert = "insert"
+	ImportModeUpsert = "upsert"
+)
+
+// ErrInvalidImport is wrapped by the errors about an import request or its
+// file, as opposed to the database failing while it is written.
+var ErrInvalidImport = errors.New("invalid import")
+
+// PersonRecord is one person as it appears in an import or export file.
+// References from movie records to people are written as the person ID, the
+// external ID or "First Last".
+type PersonRecord struct {
+	ID          string   `json:"id,omitempty"`
+	ExternalID  string   `json:"external_id,omitempty"`
+	FirstName   string   `json:"first_name"`
+	LastName    string   `json:"last_name"`
+	BirthDate   string   `json:"birth_date,omitempty"`
+	Departments []string `json:"departments,omitempty"`
+}
+
+type CrewRecord struct {
+	Person     string `json:"person"`
+	Department string `json:"department"`
+	Job        string `json:"job"`
+}
+
+// MovieRecord is one movie as it appears in an import or export file. The
+// director and the cast have their own columns, every other credit goes in crew.
+type MovieRecord struct {
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"external_id,omitempty"`
+	Title       string       `json:"title"`
+	ReleaseYear int          `json:"release_year"`
+	Director    string       `json:"director,omitempty"`
+	Actors      []string     `json:"actors,omitempty"`
+	Crew        []CrewRecord `json:"crew,omitempty"`
+
+	Overview         string                 `json:"overview,omitempty"`
+	OriginalLanguage string                 `json:"original_language,omitempty"`
+	Genres           []string               `json:"genres,omitempty"`
+	Releases         []Release              `json:"releases,omitempty"`
+	Translations     map[string]Translation `json:"translations,omitempty"`
+
+	// Filled in by hydrated exports only, the importer ignores them.
+	DirectorName string   `json:"director_name,omitempty"`
+	ActorNames   []string `json:"actor_names,omitempty"`
+}
+
+var PersonCSVHeader = []string{"id", "external_id", "first_name", "last_name", "birth_date", "departments"}
+var MovieCSVHeader = []string{
+	"id", "external_id", "title", "release_year", "director", "actors", "crew",
+	"overview", "original_language", "genres", "releases", "translations",
+}
+var HydratedMovieCSVHeader = append(append([]string{}, MovieCSVHeader...), "director_name", "actor_names")
+
+// List columns in CSV files are separated by "|", crew entries are written as
+// department:job:person and releases as country:type:yyyy-mm-dd:certification.
+// Translations, whose titles may hold any separator, are a JSON object.
+const csvListSeparator = "|"
+
+func (r *PersonRecord) CSVRow() []string {
+	return []string{
+		r.ID,
+		r.ExternalID,
+		r.FirstName,
+		r.LastName,
+		r.BirthDate,
+		strings.Join(r.Departments, csvListSeparator),
+	}
+}
+
+func (r *MovieRecord) CSVRow() []string {
+	crew := make([]string, 0, len(r.Crew))
+	for _, c := range r.Crew {
+		crew = append(crew, c.Department+":"+c.Job+":"+c.Person)
+	}
+	releases := make([]string, 0, len(r.Releases))
+	for _, rel := range r.Releases {
+		entry := rel.Country + ":" + rel.Type + ":" + rel.Date.UTC().Format("2006-01-02")
+		if rel.Certification != "" {
+			entry += ":" + rel.Certification
+		}
+		releases = append(releases, entry)
+	}
+	translations := ""
+	if len(r.Translations) > 0 {
+		raw, _ := json.Marshal(r.Translations)
+		translations = string(raw)
+	}
+	return []string{
+		r.ID,
+		r.ExternalID,
+		r.Title,
+		strconv.Itoa(r.ReleaseYear),
+		r.Director,
+		strings.Join(r.Actors, csvListSeparator),
+		strings.Join(crew, csvListSeparator),
+		r.Overview,
+		r.OriginalLanguage,
+		strings.Join(r.Genres, csvListSeparator),
+		strings.Join(releases, csvListSeparator),
+		translations,
+	}
+}
+
+func (r *MovieRecord) HydratedCSVRow() []string {
+	return append(r.CSVRow(), r.DirectorName, strings.Join(r.ActorNames, csvListSeparator))
+}
+
+func NewPersonRecord(p *Person) *PersonRecord {
+	record := &PersonRecord{
+		ID:          p.ID.Hex(),
+		ExternalID:  p.ExternalID,
+		FirstName:   p.FirstName,
+		LastName:    p.LastName,
+		Departments: p.Departments,
+	}
+	if !p.BirthDate.IsZero() {
+		record.BirthDate = p.BirthDate.UTC().Format("2006-01-02")
+	}
+	return record
+}
+
+// NewMovieRecord writes people references as IDs so that the record imports
+// back onto the same people.
+func NewMovieRecord(m *Movie) *MovieRecord {
+	m.SyncCrew()
+	record := &MovieRecord{
+		ID:               m.ID.Hex(),
+		ExternalID:       m.ExternalID,
+		Title:            m.Title,
+		ReleaseYear:      m.ReleaseYear,
+		Actors:           make([]string, 0, len(m.Actors)),
+		Overview:         m.Overview,
+		OriginalLanguage: m.OriginalLanguage,
+		Genres:           m.Genres,
+		Releases:         m.Releases,
+		Translations:     m.Translations,
+	}
+	if !m.DirectorID.IsZero() {
+		record.Director = m.DirectorID.Hex()
+	}
+	for _, id := range m.Actors {
+		record.Actors = append(record.Actors, id.Hex())
+	}
+	directorWritten := false
+	for _, c := range m.Crew {
+		if c.Department == DepartmentActing {
+			continue
+		}
+		if !directorWritten && c.Department == DepartmentDirecting && c.Job == JobDirector && c.PersonID == m.DirectorID {
+			directorWritten = true
+			continue
+		}
+		record.Crew = append(record.Crew, CrewRecord{
+			Person:     c.PersonID.Hex(),
+			Department: c.Department,
+			Job:        c.Job,
+		})
+	}
+	return record
+}
+
+// CSVColumns maps header names to their position in the row.
+type CSVColumns map[string]int
+
+func NewCSVColumns(header []string) CSVColumns {
+	columns := CSVColumns{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	return columns
+}
+
+func (c CSVColumns) get(row []string, name string) string {
+	i, ok := c[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	var items []string
+	for _, item := range strings.Split(value, csvListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func PersonRecordFromCSV(columns CSVColumns, row []string) *PersonRecord {
+	return &PersonRecord{
+		ID:          columns.get(row, "id"),
+		ExternalID:  columns.get(row, "external_id"),
+		FirstName:   columns.get(row, "first_name"),
+		LastName:    columns.get(row, "last_name"),
+		BirthDate:   columns.get(row, "birth_date"),
+		Departments: splitList(columns.get(row, "departments")),
+	}
+}
+
+func MovieRecordFromCSV(columns CSVColumns, row []string) (*MovieRecord, error) {
+	record := &MovieRecord{
+		ID:               columns.get(row, "id"),
+		ExternalID:       columns.get(row, "external_id"),
+		Title:            columns.get(row, "title"),
+		Director:         columns.get(row, "director"),
+		Actors:           splitList(columns.get(row, "actors")),
+		Overview:         columns.get(row, "overview"),
+		OriginalLanguage: columns.get(row, "original_language"),
+		Genres:           splitList(columns.get(row, "genres")),
+	}
+	if year := columns.get(row, "release_year"); year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			return nil, fmt.Errorf("release_year must be a number")
+		}
+		record.ReleaseYear = y
+	}
+	for _, entry := range splitList(columns.get(row, "crew")) {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("crew entry %q must be department:job:person", entry)
+		}
+		record.Crew = append(record.Crew, CrewRecord{
+			Department: parts[0],
+			Job:        parts[1],
+			Person:     parts[2],
+		})
+	}
+	for _, entry := range splitList(columns.get(row, "releases")) {
+		parts := strings.SplitN(entry, ":", 4)
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("release %q must be country:type:yyyy-mm-dd", entry)
+		}
+		date, err := time.Parse("2006-01-02", parts[2])
+		if err != nil {
+			return nil, fmt.Errorf("release %q must be country:type:yyyy-mm-dd", entry)
+		}
+		release := Release{Country: parts[0], Type: parts[1], Date: date}
+		if len(parts) == 4 {
+			release.Certification = parts[3]
+		}
+		record.Releases = append(record.Releases, release)
+	}
+	if raw := columns.get(row, "translations"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &record.Translations); err != nil {
+			return nil, fmt.Errorf("translations must be a JSON object keyed by locale")
+		}
+	}
+	return record, nil
+}
+
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	Kind     string           `json:"kind"`
+	Format   string           `json:"format"`
+	Mode     string           `json:"mode"`
+	DryRun   bool             `json:"dry_run"`
+	Rows     int              `json:"rows"`
+	Valid    int              `json:"valid"`
+	Inserted int              `json:"inserted"`
+	Updated  int              `json:"updated"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+func (r *ImportReport) AddError(line int, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, ImportRowError{Line: line, Message: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,80 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMovieRecord_CSVRoundTrip(t *testing.T) {
+	record := &model.MovieRecord{
+		ExternalID:  "tt0120737",
+		Title:       "The Fellowship of the Ring",
+		ReleaseYear: 2001,
+		Director:    "Peter Jackson",
+		Actors:      []string{"Elijah Wood", "Ian McKellen"},
+		Crew:        []model.CrewRecord{{Person: "Howard Shore", Department: model.DepartmentMusic, Job: "Composer"}},
+
+		Overview:         "A hobbit sets out: to destroy | the ring.",
+		OriginalLanguage: "en",
+		Genres:           []string{"fantasy", "adventure"},
+		Releases: []model.Release{
+			{Country: "NZ", Type: model.ReleasePremiere, Date: time.Date(2001, 12, 10, 0, 0, 0, 0, time.UTC)},
+			{Country: "DE", Type: model.ReleaseTheatrical, Date: time.Date(2001, 12, 19, 0, 0, 0, 0, time.UTC), Certification: "FSK 12"},
+		},
+		Translations: map[string]model.Translation{"de": {Title: "Die Gefährten", Overview: "Frodo: bricht auf | allein"}},
+	}
+
+	columns := model.NewCSVColumns(model.MovieCSVHeader)
+	parsed, err := model.MovieRecordFromCSV(columns, record.CSVRow())
+
+	require.NoError(t, err)
+	assert.Equal(t, record, parsed)
+}
+
+func TestMovieRecordFromCSV_RejectsBadValues(t *testing.T) {
+	columns := model.NewCSVColumns([]string{"title", "release_year", "crew"})
+
+	_, err := model.MovieRecordFromCSV(columns, []string{"Heat", "nineteen", ""})
+	assert.Error(t, err)
+
+	_, err = model.MovieRecordFromCSV(columns, []string{"Heat", "1995", "music:Elliot Goldenthal"})
+	assert.Error(t, err)
+}
+
+func TestPersonRecordFromCSV_ReadsColumnsByName(t *testing.T) {
+	columns := model.NewCSVColumns([]string{"Last_Name", "first_name", "departments"})
+
+	record := model.PersonRecordFromCSV(columns, []string{"Mann", " Michael ", "directing|writing"})
+
+	assert.Equal(t, "Michael", record.FirstName)
+	assert.Equal(t, "Mann", record.LastName)
+	assert.Equal(t, []string{"directing", "writing"}, record.Departments)
+}
+
+func TestNewMovieRecord_KeepsCoDirectorsInCrew(t *testing.T) {
+	director := primitive.NewObjectID()
+	coDirector := primitive.NewObjectID()
+	actor := primitive.NewObjectID()
+	movie := &model.Movie{
+		ID:    primitive.NewObjectID(),
+		Title: "The Matrix",
+		Crew: []model.CrewMember{
+			{PersonID: director, Department: model.DepartmentDirecting, Job: model.JobDirector},
+			{PersonID: coDirector, Department: model.DepartmentDirecting, Job: model.JobDirector},
+			{PersonID: actor, Department: model.DepartmentActing, Job: model.JobActor},
+		},
+	}
+
+	record := model.NewMovieRecord(movie)
+
+	assert.Equal(t, director.Hex(), record.Director)
+	assert.Equal(t, []string{actor.Hex()}, record.Actors)
+	assert.Equal(t, []model.CrewRecord{
+		{Person: coDirector.Hex(), Department: model.DepartmentDirecting, Job: model.JobDirector},
+	}, record.Crew)
+}
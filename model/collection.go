@@ -0,0 +1,120 @@
+package model
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Collection groups movies that belong together, such as the films of a
+// franchise. MovieIDs holds the members in the order they are meant to be
+// watched.
+type Collection struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name        string               `bson:"name" json:"name"`
+	Description string               `bson:"description,omitempty" json:"description"`
+	MovieIDs    []primitive.ObjectID `bson:"movie_ids" json:"movie_ids"`
+	Artwork     *ImageSet            `bson:"artwork,omitempty" json:"artwork,omitempty"`
+	Version     int64                `bson:"version" json:"version"`
+}
+
+// CollectionResponse is a collection with its member movies hydrated, in the
+// collection's order. Incomplete lists the members whose credits could not be
+// loaded; they are in Movies without them.
+type CollectionResponse struct {
+	Collection
+	Movies     []MovieResponse      `json:"movies"`
+	Incomplete []primitive.ObjectID `json:"incomplete,omitempty"`
+}
+
+// CollectionLink is how a movie points to a collection it belongs to.
+// Position is the movie's place in the collection, starting at 1.
+type CollectionLink struct {
+	ID       primitive.ObjectID `json:"id"`
+	Name     string             `json:"name"`
+	Position int                `json:"position"`
+}
+
+// Normalize trims the name and description and drops repeated members,
+// keeping the first place a movie was listed at.
+func (c *Collection) Normalize() error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Description = strings.TrimSpace(c.Description)
+	if c.Name == "" {
+		return invalidDocument("collection name is required")
+	}
+	c.MovieIDs = uniqueIDs(c.MovieIDs)
+	return nil
+}
+
+// CollectionUpdate is the body of a PUT on a collection. Fields left out keep
+// their value; movie_ids replaces the members and their order as a whole.
+type CollectionUpdate struct {
+	Name        *string              `json:"name"`
+	Description *string              `json:"description"`
+	MovieIDs    []primitive.ObjectID `json:"movie_ids"`
+}
+
+// Document turns the update into the fields to set.
+func (u *CollectionUpdate) Document() (bson.M, error) {
+	update := bson.M{}
+	if u.Name != nil {
+		name := strings.TrimSpace(*u.Name)
+		if name == "" {
+			return nil, invalidDocument("collection name is required")
+		}
+		update["name"] = name
+	}
+	if u.Description != nil {
+		update["description"] = strings.TrimSpace(*u.Description)
+	}
+	if u.MovieIDs != nil {
+		update["movie_ids"] = uniqueIDs(u.MovieIDs)
+	}
+	return update, nil
+}
+
+func uniqueIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	out := make([]primitive.ObjectID, 0, len(ids))
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range ids {
+		if id.IsZero() || seen[id] {
+			continue
+		}
+		seen[id] = true
+		out = append(out, id)
+	}
+	return out
+}
+
+// OrderMovies puts movies in the order of ids. Movies that are not listed are
+// dropped, ids without a movie are skipped.
+func OrderMovies(ids []primitive.ObjectID, movies []Movie) []Movie {
+	byID := make(map[primitive.ObjectID]*Movie, len(movies))
+	for i := range movies {
+		byID[movies[i].ID] = &movies[i]
+	}
+	ordered := make([]Movie, 0, len(ids))
+	for _, id := range ids {
+		if m, ok := byID[id]; ok {
+			ordered = append(ordered, *m)
+		}
+	}
+	return ordered
+}
+
+// LinkCollections lists the collections a movie belongs to together with its
+// position in each of them.
+func LinkCollections(movieID primitive.ObjectID, collections []Collection) []CollectionLink {
+	links := make([]CollectionLink, 0, len(collections))
+	for _, c := range collections {
+		for i, id := range c.MovieIDs {
+			if id == movieID {
+				links = append(links, CollectionLink{ID: c.ID, Name: c.Name, Position: i + 1})
+				break
+			}
+		}
+	}
+	return links
+}
@@ -0,0 +1,57 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCollectionNormalizeKeepsFirstPosition(t *testing.T) {
+	a, b := primitive.NewObjectID(), primitive.NewObjectID()
+	c := &model.Collection{Name: "  The Lord of the Rings ", MovieIDs: []primitive.ObjectID{a, b, a}}
+	require.NoError(t, c.Normalize())
+	assert.Equal(t, "The Lord of the Rings", c.Name)
+	assert.Equal(t, []primitive.ObjectID{a, b}, c.MovieIDs)
+
+	assert.ErrorIs(t, (&model.Collection{Name: " "}).Normalize(), model.ErrInvalidDocument)
+}
+
+func TestCollectionUpdateDocument(t *testing.T) {
+	a := primitive.NewObjectID()
+	name := "Middle-earth"
+	update, err := (&model.CollectionUpdate{Name: &name, MovieIDs: []primitive.ObjectID{a, a}}).Document()
+	require.NoError(t, err)
+	assert.Equal(t, "Middle-earth", update["name"])
+	assert.Equal(t, []primitive.ObjectID{a}, update["movie_ids"])
+	assert.NotContains(t, update, "description")
+
+	blank := ""
+	_, err = (&model.CollectionUpdate{Name: &blank}).Document()
+	assert.ErrorIs(t, err, model.ErrInvalidDocument)
+}
+
+func TestOrderMoviesFollowsCollection(t *testing.T) {
+	first, second, third := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	movies := []model.Movie{{ID: third}, {ID: first}, {ID: primitive.NewObjectID()}}
+
+	ordered := model.OrderMovies([]primitive.ObjectID{first, second, third}, movies)
+	require.Len(t, ordered, 2)
+	assert.Equal(t, first, ordered[0].ID)
+	assert.Equal(t, third, ordered[1].ID)
+}
+
+func TestLinkCollectionsReportsPosition(t *testing.T) {
+	movie := primitive.NewObjectID()
+	collections := []model.Collection{
+		{ID: primitive.NewObjectID(), Name: "Trilogy", MovieIDs: []primitive.ObjectID{primitive.NewObjectID(), movie}},
+		{ID: primitive.NewObjectID(), Name: "Unrelated"},
+	}
+
+	links := model.LinkCollections(movie, collections)
+	require.Len(t, links, 1)
+	assert.Equal(t, "Trilogy", links[0].Name)
+	assert.Equal(t, 2, links[0].Position)
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MovieCreate is the body of a POST creating a movie. It lists every field a
+// client may set; ids, versions, slugs, the workflow, the trash fields and
+// the poster are up to the server. Translations have their own endpoints.
+type MovieCreate struct {
+	ExternalID       string               `json:"external_id"`
+	Title            string               `json:"title"`
+	Overview         string               `json:"overview"`
+	OriginalLanguage string               `json:"original_language"`
+	ReleaseYear      int                  `json:"release_year"`
+	Releases         []Release            `json:"releases"`
+	Genres           []string             `json:"genres"`
+	DirectorID       primitive.ObjectID   `json:"director_id"`
+	Actors           []primitive.ObjectID `json:"actors"`
+	Crew             []CrewMember         `json:"crew"`
+}
+
+// Movie validates the request and turns it into the movie to store.
+func (r *MovieCreate) Movie() (*Movie, error) {
+	for i, c := range r.Crew {
+		if c.PersonID.IsZero() {
+			return nil, invalidDocument("crew[%d] has no person_id", i)
+		}
+		if !ValidDepartment(c.Department) {
+			return nil, invalidDocument("crew[%d] has unknown department %q", i, c.Department)
+		}
+	}
+	releases, err := NormalizeReleases(r.Releases)
+	if err != nil {
+		return nil, err
+	}
+	movie := &Movie{
+		ExternalID:  r.ExternalID,
+		Title:       r.Title,
+		Overview:    r.Overview,
+		ReleaseYear: r.ReleaseYear,
+		Releases:    releases,
+		Genres:      r.Genres,
+		DirectorID:  r.DirectorID,
+		Actors:      r.Actors,
+	}
+	for _, c := range r.Crew {
+		movie.Crew = append(movie.Crew, CrewMember{PersonID: c.PersonID, Department: c.Department, Job: c.Job})
+	}
+	if r.OriginalLanguage != "" {
+		locale, err := NormalizeLocale(r.OriginalLanguage)
+		if err != nil {
+			return nil, invalidDocument("original_language is not a language tag")
+		}
+		movie.OriginalLanguage = locale
+	}
+	return movie, nil
+}
+
+// PersonCreate is the body of a POST creating a person, actor or director,
+// with every field a client may set. Older clients send the birth date as
+// birthDate.
+type PersonCreate struct {
+	ExternalID      string   `json:"external_id"`
+	FirstName       string   `json:"first_name"`
+	LastName        string   `json:"last_name"`
+	BirthDate       *string  `json:"birth_date"`
+	LegacyBirthDate *string  `json:"birthDate"`
+	Departments     []string `json:"departments"`
+}
+
+// Person validates the request and turns it into the person to store.
+func (r *PersonCreate) Person() (*Person, error) {
+	person := &Person{
+		ExternalID:  r.ExternalID,
+		FirstName:   r.FirstName,
+		LastName:    r.LastName,
+		Departments: []string{},
+	}
+	birthDate := r.BirthDate
+	if birthDate == nil {
+		birthDate = r.LegacyBirthDate
+	}
+	if birthDate != nil {
+		t, err := time.Parse("2006-01-02", *birthDate)
+		if err != nil {
+			return nil, invalidDocument("birth_date must be yyyy-mm-dd")
+		}
+		person.BirthDate = t
+	}
+	for _, d := range r.Departments {
+		if !ValidDepartment(d) {
+			return nil, invalidDocument("unknown department %q", d)
+		}
+		if !person.HasDepartment(d) {
+			person.Departments = append(person.Departments, d)
+		}
+	}
+	return person, nil
+}
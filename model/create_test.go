@@ -0,0 +1,43 @@
+package model_test
+
+import (
+	"encoding/json"
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersonCreate_IgnoresServerFields(t *testing.T) {
+	var req model.PersonCreate
+	body := `{"first_name":"Al","last_name":"Pacino","birthDate":"1940-04-25","departments":["acting"],
+		"version":7,"deleted_at":"2024-01-01T00:00:00Z","headshot":{"original":{"url":"x"}}}`
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	person, err := req.Person()
+
+	require.NoError(t, err)
+	assert.Equal(t, 1940, person.BirthDate.Year())
+	assert.Zero(t, person.Version)
+	assert.Nil(t, person.DeletedAt)
+	assert.Nil(t, person.Headshot)
+}
+
+func TestPersonCreate_RejectsUnknownDepartments(t *testing.T) {
+	req := model.PersonCreate{FirstName: "Al", LastName: "Pacino", Departments: []string{"catering"}}
+
+	_, err := req.Person()
+
+	assert.ErrorIs(t, err, model.ErrInvalidDocument)
+}
+
+func TestMovieCreate_RejectsUnknownCrewDepartments(t *testing.T) {
+	var req model.MovieCreate
+	body := `{"title":"Heat","crew":[{"person_id":"64b7f0c2a1b2c3d4e5f60718","department":"catering","job":"Chef"}]}`
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	_, err := req.Movie()
+
+	assert.ErrorIs(t, err, model.ErrInvalidDocument)
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is wrapped by every "not found with given id" error, so a
+// missing document can be told apart from a failed query.
+var ErrNotFound = errors.New("not found with given id")
+
+// NotFound is the error for a missing document of the given kind, e.g.
+// NotFound("movie") reads "movie not found with given id".
+func NotFound(kind string) error {
+	return fmt.Errorf("%s %w", kind, ErrNotFound)
+}
@@ -0,0 +1,46 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Filmography is everything a person was credited on in one department,
+// grouped by decade, with statistics over the whole career.
+type Filmography struct {
+	Person  *Person             `bson:"-" json:"person"`
+	Decades []FilmographyDecade `bson:"decades" json:"decades"`
+	Stats   CareerStats         `bson:"stats" json:"stats"`
+	Awards  AwardCount          `bson:"-" json:"awards"`
+}
+
+// FilmographyDecade holds the credits of one decade, oldest first. Decade is
+// nil for movies without a release year.
+type FilmographyDecade struct {
+	Decade  *int                `bson:"_id" json:"decade"`
+	Credits []FilmographyCredit `bson:"credits" json:"credits"`
+}
+
+type FilmographyCredit struct {
+	MovieID     primitive.ObjectID `bson:"_id" json:"movie_id"`
+	Title       string             `bson:"title" json:"title"`
+	ReleaseYear int                `bson:"release_year" json:"release_year"`
+	Jobs        []string           `bson:"jobs" json:"jobs"`
+	Rating      *float64           `bson:"rating" json:"rating"`
+	RatingCount int                `bson:"rating_count" json:"rating_count"`
+	Awards      AwardCount         `bson:"-" json:"awards"`
+}
+
+// CareerStats leaves the years and the average rating nil when no credit has
+// a release year or a rating.
+type CareerStats struct {
+	FirstYear        *int           `bson:"first_year" json:"first_year"`
+	LastYear         *int           `bson:"last_year" json:"last_year"`
+	TotalCredits     int            `bson:"total_credits" json:"total_credits"`
+	RatedCredits     int            `bson:"rated_credits" json:"rated_credits"`
+	AverageRating    *float64       `bson:"average_rating" json:"average_rating"`
+	TopCollaborators []Collaborator `bson:"top_collaborators" json:"top_collaborators"`
+}
+
+type Collaborator struct {
+	PersonID     primitive.ObjectID `bson:"_id" json:"person_id"`
+	SharedMovies int                `bson:"shared_movies" json:"shared_movies"`
+	Person       *Person            `bson:"person" json:"person"`
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrPathSearchTooWide is returned when more actors are within reach of a hop
+// than the search may look at.
+var ErrPathSearchTooWide = errors.New("too many actors within reach, try a smaller depth")
+
+// CoStar is an actor who appeared in at least one movie with another actor.
+type CoStar struct {
+	ActorID      primitive.ObjectID   `bson:"_id" json:"actor_id"`
+	SharedMovies int                  `bson:"shared_movies" json:"shared_movies"`
+	MovieIDs     []primitive.ObjectID `bson:"movie_ids" json:"movie_ids"`
+
+	Actor *Actor `bson:"-" json:"actor,omitempty"`
+}
+
+type MovieLink struct {
+	ID          primitive.ObjectID `json:"id"`
+	Title       string             `json:"title"`
+	ReleaseYear int                `json:"release_year"`
+}
+
+// ActorPath is a chain of actors where Movies[i] is a movie both Actors[i]
+// and Actors[i+1] appeared in.
+type ActorPath struct {
+	Degrees int         `json:"degrees"`
+	Actors  []Actor     `json:"actors"`
+	Movies  []MovieLink `json:"movies"`
+}
+
+// ShortestActorPath walks the co-star graph breadth first and returns the
+// actors and linking movies of the shortest chain from one actor to the
+// other. found is false when there is no chain of at most maxDepth movies.
+// A hop that would reach more than maxFrontier new actors ends the search
+// with ErrPathSearchTooWide, zero leaves it unbounded. Each hop asks
+// moviesWith for at most batch actors at a time, so a wide frontier never
+// turns into one huge lookup.
+func ShortestActorPath(
+	from, to primitive.ObjectID,
+	maxDepth, maxFrontier, batch int,
+	moviesWith func(actorIDs []primitive.ObjectID) ([]Movie, error),
+) (actors []primitive.ObjectID, movies []Movie, found bool, err error) {
+	if from == to {
+		return []primitive.ObjectID{from}, []Movie{}, true, nil
+	}
+
+	type edge struct {
+		prev  primitive.ObjectID
+		movie int
+	}
+	var seenMovies []Movie
+	parents := map[primitive.ObjectID]edge{from: {}}
+	frontier := []primitive.ObjectID{from}
+
+	for depth := 0; depth < maxDepth && len(frontier) > 0; depth++ {
+		inFrontier := make(map[primitive.ObjectID]bool, len(frontier))
+		for _, id := range frontier {
+			inFrontier[id] = true
+		}
+
+		linked, err := moviesInBatches(frontier, batch, moviesWith)
+		if err != nil {
+			return nil, nil, false, err
+		}
+
+		var next []primitive.ObjectID
+		for _, m := range linked {
+			var via primitive.ObjectID
+			for _, a := range m.Actors {
+				if inFrontier[a] {
+					via = a
+					break
+				}
+			}
+			if via.IsZero() {
+				continue
+			}
+			seenMovies = append(seenMovies, m)
+			for _, a := range m.Actors {
+				if _, seen := parents[a]; seen {
+					continue
+				}
+				parents[a] = edge{prev: via, movie: len(seenMovies) - 1}
+				next = append(next, a)
+			}
+		}
+
+		if _, reached := parents[to]; reached {
+			for id := to; id != from; id = parents[id].prev {
+				actors = append([]primitive.ObjectID{id}, actors...)
+				movies = append([]Movie{seenMovies[parents[id].movie]}, movies...)
+			}
+			return append([]primitive.ObjectID{from}, actors...), movies, true, nil
+		}
+		if maxFrontier > 0 && len(next) > maxFrontier && depth+1 < maxDepth {
+			return nil, nil, false, ErrPathSearchTooWide
+		}
+		frontier = next
+	}
+	return nil, nil, false, nil
+}
+
+// moviesInBatches looks the movies of the actors up batch actors at a time.
+// A movie shared by actors of different batches is only kept once.
+func moviesInBatches(
+	actorIDs []primitive.ObjectID,
+	batch int,
+	moviesWith func(actorIDs []primitive.ObjectID) ([]Movie, error),
+) ([]Movie, error) {
+	if batch <= 0 {
+		batch = len(actorIDs)
+	}
+	var movies []Movie
+	seen := map[primitive.ObjectID]bool{}
+	for start := 0; start < len(actorIDs); start += batch {
+		end := min(start+batch, len(actorIDs))
+		found, err := moviesWith(actorIDs[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range found {
+			if m.ID.IsZero() || !seen[m.ID] {
+				seen[m.ID] = true
+				movies = append(movies, m)
+			}
+		}
+	}
+	return movies, nil
+}
@@ -0,0 +1,105 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// castIndex answers moviesWith from an in-memory list of movies.
+func castIndex(movies []model.Movie) func([]primitive.ObjectID) ([]model.Movie, error) {
+	return func(ids []primitive.ObjectID) ([]model.Movie, error) {
+		wanted := map[primitive.ObjectID]bool{}
+		for _, id := range ids {
+			wanted[id] = true
+		}
+		var out []model.Movie
+		for _, m := range movies {
+			for _, a := range m.Actors {
+				if wanted[a] {
+					out = append(out, m)
+					break
+				}
+			}
+		}
+		return out, nil
+	}
+}
+
+func TestShortestActorPath(t *testing.T) {
+	a, b, c, d := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	movies := []model.Movie{
+		{Title: "One", Actors: []primitive.ObjectID{a, b}},
+		{Title: "Two", Actors: []primitive.ObjectID{b, c}},
+		{Title: "Three", Actors: []primitive.ObjectID{c, d}},
+		{Title: "Shortcut", Actors: []primitive.ObjectID{a, c}},
+	}
+
+	actors, linking, found, err := model.ShortestActorPath(a, d, 6, 0, 0, castIndex(movies))
+
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []primitive.ObjectID{a, c, d}, actors)
+	require.Len(t, linking, 2)
+	assert.Equal(t, "Shortcut", linking[0].Title)
+	assert.Equal(t, "Three", linking[1].Title)
+}
+
+func TestShortestActorPath_RespectsDepth(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	movies := []model.Movie{
+		{Title: "One", Actors: []primitive.ObjectID{a, b}},
+		{Title: "Two", Actors: []primitive.ObjectID{b, c}},
+	}
+
+	_, _, found, err := model.ShortestActorPath(a, c, 1, 0, 0, castIndex(movies))
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	_, _, found, err = model.ShortestActorPath(a, c, 2, 0, 0, castIndex(movies))
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestShortestActorPath_LooksUpInBatches(t *testing.T) {
+	a, b, c, d := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	shared := primitive.NewObjectID()
+	movies := []model.Movie{
+		{ID: shared, Title: "Ensemble", Actors: []primitive.ObjectID{a, b, c}},
+		{ID: primitive.NewObjectID(), Title: "Sequel", Actors: []primitive.ObjectID{c, d}},
+	}
+	index := castIndex(movies)
+	var sizes []int
+	lookup := func(ids []primitive.ObjectID) ([]model.Movie, error) {
+		sizes = append(sizes, len(ids))
+		return index(ids)
+	}
+
+	actors, linking, found, err := model.ShortestActorPath(a, d, 6, 0, 1, lookup)
+
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, []primitive.ObjectID{a, c, d}, actors)
+	assert.Len(t, linking, 2)
+	for _, size := range sizes {
+		assert.Equal(t, 1, size)
+	}
+}
+
+func TestShortestActorPath_CapsTheFrontier(t *testing.T) {
+	a, b, c, d, e := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	movies := []model.Movie{
+		{Title: "Ensemble", Actors: []primitive.ObjectID{a, b, c, d}},
+		{Title: "Sequel", Actors: []primitive.ObjectID{d, e}},
+	}
+
+	_, _, _, err := model.ShortestActorPath(a, e, 6, 2, 0, castIndex(movies))
+	assert.ErrorIs(t, err, model.ErrPathSearchTooWide)
+
+	_, _, found, err := model.ShortestActorPath(a, e, 6, 3, 0, castIndex(movies))
+	require.NoError(t, err)
+	assert.True(t, found)
+}
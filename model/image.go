@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Image is one stored file. The key is where the storage keeps it and is
+// only needed to clean it up again.
+type Image struct {
+	Key    string `bson:"key" json:"-"`
+	URL    string `bson:"url" json:"url"`
+	Width  int    `bson:"width" json:"width"`
+	Height int    `bson:"height" json:"height"`
+}
+
+// ImageSet is an uploaded image together with its resized variants.
+type ImageSet struct {
+	Original   Image            `bson:"original" json:"original"`
+	Thumbnails map[string]Image `bson:"thumbnails" json:"thumbnails"`
+	UploadedAt time.Time        `bson:"uploaded_at" json:"uploaded_at"`
+}
+
+// Keys lists every stored file of the set.
+func (s *ImageSet) Keys() []string {
+	if s == nil {
+		return nil
+	}
+	keys := []string{s.Original.Key}
+	for _, t := range s.Thumbnails {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
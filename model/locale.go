@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+var (
+	ErrInvalidLocale = errors.New("locale must be a language tag such as en or pt-BR")
+	ErrInvalidSort   = errors.New("sort must be title, -title, release_year or -release_year")
+)
+
+// Translation is a movie's title and overview in one locale. An empty
+// overview falls back to the original one.
+type Translation struct {
+	Title    string `bson:"title" json:"title"`
+	Overview string `bson:"overview,omitempty" json:"overview,omitempty"`
+}
+
+// MovieListQuery describes how a list of movies is localized, searched and
+// sorted. Search and sorting work on the localized titles. A country adds the
+// local release to every movie. Movies the audience may not see are left out,
+// and with Awards so are those without a matching nomination.
+type MovieListQuery struct {
+	Audience        *Audience
+	Languages       []language.Tag
+	DefaultLanguage string
+	Country         string
+	Search          string
+	Sort            string
+	Awards          *AwardFilter
+}
+
+// NormalizeLocale validates a language tag and returns it in canonical form,
+// so "pt-br" and "pt_BR" are stored as "pt-BR".
+func NormalizeLocale(tag string) (string, error) {
+	t, err := language.Parse(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	if err != nil || t == language.Und {
+		return "", ErrInvalidLocale
+	}
+	return t.String(), nil
+}
+
+// ResolveLocale picks the translation that best fits the preferences, most
+// preferred first. A regional preference falls back to the plain language
+// and the other way round, so pt-PT can be served pt and pt pt-BR. It returns
+// "" when the original language fits best or nothing fits at all.
+func ResolveLocale(preferences []language.Tag, original string, available []string) string {
+	originalTag, err := language.Parse(original)
+	if err != nil {
+		originalTag = language.Und
+	}
+
+	locales := make([]string, 0, len(available))
+	for _, locale := range available {
+		if locale != originalTag.String() {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+
+	supported := []language.Tag{originalTag}
+	for _, locale := range locales {
+		supported = append(supported, language.Make(locale))
+	}
+	_, index, confidence := language.NewMatcher(supported).Match(preferences...)
+	if confidence == language.No || index == 0 {
+		return ""
+	}
+	return locales[index-1]
+}
+
+// Localize swaps in the title and overview of the locale that best fits the
+// preferences and records which language the movie is shown in. The
+// original title is kept alongside a translated one.
+func (m *MovieResponse) Localize(preferences []language.Tag, defaultLanguage string) {
+	original := m.OriginalLanguage
+	if original == "" {
+		original = defaultLanguage
+	}
+	m.Language = original
+
+	available := make([]string, 0, len(m.Translations))
+	for locale := range m.Translations {
+		available = append(available, locale)
+	}
+	locale := ResolveLocale(preferences, original, available)
+	if locale == "" {
+		return
+	}
+
+	translation := m.Translations[locale]
+	m.OriginalTitle = m.Title
+	m.Title = translation.Title
+	if translation.Overview != "" {
+		m.Overview = translation.Overview
+	}
+	m.Language = locale
+}
+
+// ValidMovieSort tells whether SortMovies accepts sortBy.
+func ValidMovieSort(sortBy string) bool {
+	switch strings.TrimPrefix(sortBy, "-") {
+	case "", "title", "release_year":
+		return true
+	}
+	return false
+}
+
+// SortMovies orders localized movies. Titles are compared with the collation
+// rules of the given language, so accented titles land where readers of that
+// language expect them.
+func SortMovies(movies []MovieResponse, sortBy string, lang language.Tag) error {
+	desc := strings.HasPrefix(sortBy, "-")
+	field := strings.TrimPrefix(sortBy, "-")
+
+	var less func(a, b *MovieResponse) int
+	switch field {
+	case "":
+		return nil
+	case "title":
+		collator := collate.New(lang, collate.IgnoreCase)
+		less = func(a, b *MovieResponse) int { return collator.CompareString(a.Title, b.Title) }
+	case "release_year":
+		less = func(a, b *MovieResponse) int { return a.ReleaseYear - b.ReleaseYear }
+	default:
+		return ErrInvalidSort
+	}
+
+	sort.SliceStable(movies, func(i, j int) bool {
+		cmp := less(&movies[i], &movies[j])
+		if desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	return nil
+}
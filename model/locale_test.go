@@ -0,0 +1,64 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	locale, err := model.NormalizeLocale("pt_br")
+	require.NoError(t, err)
+	assert.Equal(t, "pt-BR", locale)
+
+	_, err = model.NormalizeLocale("not a tag")
+	assert.ErrorIs(t, err, model.ErrInvalidLocale)
+}
+
+func TestResolveLocale(t *testing.T) {
+	available := []string{"de", "pt-BR", "fr"}
+	tags := func(s ...string) []language.Tag {
+		out := make([]language.Tag, 0, len(s))
+		for _, t := range s {
+			out = append(out, language.MustParse(t))
+		}
+		return out
+	}
+
+	assert.Equal(t, "de", model.ResolveLocale(tags("de"), "en", available))
+	assert.Equal(t, "de", model.ResolveLocale(tags("de-AT"), "en", available))
+	assert.Equal(t, "pt-BR", model.ResolveLocale(tags("pt"), "en", available))
+	assert.Equal(t, "fr", model.ResolveLocale(tags("ja", "fr"), "en", available))
+	assert.Equal(t, "", model.ResolveLocale(tags("en-GB", "de"), "en", available))
+	assert.Equal(t, "", model.ResolveLocale(tags("ja"), "en", available))
+	assert.Equal(t, "", model.ResolveLocale(nil, "en", available))
+}
+
+func TestLocalizeKeepsOriginalTitle(t *testing.T) {
+	movie := &model.MovieResponse{
+		Title:    "The Lives of Others",
+		Overview: "East Berlin, 1984.",
+		Translations: map[string]model.Translation{
+			"de": {Title: "Das Leben der Anderen"},
+		},
+	}
+
+	movie.Localize([]language.Tag{language.German}, "en")
+
+	assert.Equal(t, "Das Leben der Anderen", movie.Title)
+	assert.Equal(t, "The Lives of Others", movie.OriginalTitle)
+	assert.Equal(t, "East Berlin, 1984.", movie.Overview)
+	assert.Equal(t, "de", movie.Language)
+}
+
+func TestSortMoviesByTitleUsesCollation(t *testing.T) {
+	movies := []model.MovieResponse{{Title: "Zorro"}, {Title: "Élan"}, {Title: "apple"}}
+
+	require.NoError(t, model.SortMovies(movies, "title", language.French))
+	assert.Equal(t, []string{"apple", "Élan", "Zorro"}, []string{movies[0].Title, movies[1].Title, movies[2].Title})
+
+	assert.ErrorIs(t, model.SortMovies(movies, "rating", language.French), model.ErrInvalidSort)
+}
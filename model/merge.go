@@ -0,0 +1,196 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MergeKeepSurvivor  = "survivor"
+	MergeTakeDuplicate = "duplicate"
+)
+
+var mergeableFields = map[string]bool{
+	"first_name": true,
+	"last_name":  true,
+	"birth_date": true,
+}
+
+// MergeRequest folds the duplicates into the person named in the URL. Rules
+// choose, per field, where the merged value comes from: "survivor" keeps the
+// surviving value, "duplicate" takes the first non-empty value among the
+// duplicates in the order given, and an ID takes the value of that record.
+type MergeRequest struct {
+	Duplicates []primitive.ObjectID `json:"duplicates"`
+	Rules      map[string]string    `json:"rules"`
+}
+
+func (r *MergeRequest) Validate(survivorID primitive.ObjectID) error {
+	if len(r.Duplicates) == 0 {
+		return invalidDocument("at least one duplicate is required")
+	}
+	seen := map[primitive.ObjectID]bool{}
+	for _, id := range r.Duplicates {
+		if id == survivorID {
+			return invalidDocument("a record cannot be merged into itself")
+		}
+		if seen[id] {
+			return invalidDocument("duplicate %s listed twice", id.Hex())
+		}
+		seen[id] = true
+	}
+	for field, rule := range r.Rules {
+		if !mergeableFields[field] {
+			return invalidDocument("field %q cannot be merged", field)
+		}
+		if rule == MergeKeepSurvivor || rule == MergeTakeDuplicate {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(rule)
+		if err != nil || (id != survivorID && !seen[id]) {
+			return invalidDocument("unknown merge rule %q for %s", rule, field)
+		}
+	}
+	return nil
+}
+
+// Merge applies the rules and returns the surviving person as it should look
+// after the merge. Departments are always combined.
+func (r *MergeRequest) Merge(survivor *Person, duplicates []Person) *Person {
+	merged := *survivor
+	merged.Departments = append([]string{}, survivor.Departments...)
+
+	records := map[primitive.ObjectID]*Person{survivor.ID: survivor}
+	for i := range duplicates {
+		records[duplicates[i].ID] = &duplicates[i]
+		for _, d := range duplicates[i].Departments {
+			if !merged.HasDepartment(d) {
+				merged.Departments = append(merged.Departments, d)
+			}
+		}
+	}
+
+	pick := func(field string, isSet func(p *Person) bool) *Person {
+		rule := r.Rules[field]
+		switch rule {
+		case "", MergeKeepSurvivor:
+			return survivor
+		case MergeTakeDuplicate:
+			for _, id := range r.Duplicates {
+				if p, ok := records[id]; ok && isSet(p) {
+					return p
+				}
+			}
+			return survivor
+		default:
+			id, _ := primitive.ObjectIDFromHex(rule)
+			if p, ok := records[id]; ok {
+				return p
+			}
+			return survivor
+		}
+	}
+
+	merged.FirstName = pick("first_name", func(p *Person) bool { return p.FirstName != "" }).FirstName
+	merged.LastName = pick("last_name", func(p *Person) bool { return p.LastName != "" }).LastName
+	merged.BirthDate = pick("birth_date", func(p *Person) bool { return !p.BirthDate.IsZero() }).BirthDate
+
+	return &merged
+}
+
+// ReplacePeople points every credit held by one of the given people at the
+// replacement instead, dropping credits that become duplicates.
+func (m *Movie) ReplacePeople(from []primitive.ObjectID, to primitive.ObjectID) {
+	m.SyncCrew()
+	m.Crew = replaceCrew(m.Crew, from, to)
+	m.SyncCrew()
+}
+
+// ReplacePeople does the same for the series crew and every episode crew.
+func (s *Series) ReplacePeople(from []primitive.ObjectID, to primitive.ObjectID) {
+	s.Crew = replaceCrew(s.Crew, from, to)
+	for i := range s.Seasons {
+		for j := range s.Seasons[i].Episodes {
+			e := &s.Seasons[i].Episodes[j]
+			e.Crew = replaceCrew(e.Crew, from, to)
+		}
+	}
+}
+
+// ReplacePeople points nominations of the given people at the replacement.
+// Nominations that become duplicates are folded, keeping a win.
+func (a *Award) ReplacePeople(from []primitive.ObjectID, to primitive.ObjectID) {
+	replaced := idSet(from)
+	type nomination struct {
+		movie  primitive.ObjectID
+		person primitive.ObjectID
+	}
+	index := map[nomination]int{}
+	nominees := make([]Nominee, 0, len(a.Nominees))
+	for _, n := range a.Nominees {
+		if n.PersonID != nil && replaced[*n.PersonID] {
+			id := to
+			n.PersonID = &id
+		}
+		key := nomination{movie: n.MovieID}
+		if n.PersonID != nil {
+			key.person = *n.PersonID
+		}
+		if i, ok := index[key]; ok {
+			nominees[i].Won = nominees[i].Won || n.Won
+			continue
+		}
+		index[key] = len(nominees)
+		nominees = append(nominees, n)
+	}
+	a.Nominees = nominees
+}
+
+func replaceCrew(crew []CrewMember, from []primitive.ObjectID, to primitive.ObjectID) []CrewMember {
+	replaced := idSet(from)
+	type credit struct {
+		person     primitive.ObjectID
+		department string
+		job        string
+	}
+	seen := map[credit]bool{}
+	out := make([]CrewMember, 0, len(crew))
+	for _, c := range crew {
+		if replaced[c.PersonID] {
+			c.PersonID = to
+		}
+		key := credit{c.PersonID, c.Department, c.Job}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, c)
+	}
+	return out
+}
+
+func idSet(ids []primitive.ObjectID) map[primitive.ObjectID]bool {
+	set := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+type PersonRedirect struct {
+	ID       primitive.ObjectID `bson:"_id" json:"id"`
+	TargetID primitive.ObjectID `bson:"target_id" json:"target_id"`
+	MergedAt time.Time          `bson:"merged_at" json:"merged_at"`
+}
+
+type AuditEntry struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Action   string             `bson:"action" json:"action"`
+	Entity   string             `bson:"entity" json:"entity"`
+	EntityID primitive.ObjectID `bson:"entity_id" json:"entity_id"`
+	Editor   string             `bson:"editor" json:"editor"`
+	At       time.Time          `bson:"at" json:"at"`
+	Details  bson.M             `bson:"details" json:"details"`
+}
@@ -0,0 +1,79 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMergeRequest_Validate(t *testing.T) {
+	survivor := primitive.NewObjectID()
+	dup := primitive.NewObjectID()
+
+	assert.ErrorIs(t, (&model.MergeRequest{}).Validate(survivor), model.ErrInvalidDocument)
+	assert.Error(t, (&model.MergeRequest{Duplicates: []primitive.ObjectID{survivor}}).Validate(survivor))
+	assert.Error(t, (&model.MergeRequest{
+		Duplicates: []primitive.ObjectID{dup},
+		Rules:      map[string]string{"nickname": model.MergeKeepSurvivor},
+	}).Validate(survivor))
+	assert.Error(t, (&model.MergeRequest{
+		Duplicates: []primitive.ObjectID{dup},
+		Rules:      map[string]string{"first_name": primitive.NewObjectID().Hex()},
+	}).Validate(survivor))
+	assert.NoError(t, (&model.MergeRequest{
+		Duplicates: []primitive.ObjectID{dup},
+		Rules:      map[string]string{"first_name": dup.Hex(), "birth_date": model.MergeTakeDuplicate},
+	}).Validate(survivor))
+}
+
+func TestMergeRequest_Merge(t *testing.T) {
+	born := time.Date(1970, 5, 1, 0, 0, 0, 0, time.UTC)
+	survivor := &model.Person{ID: primitive.NewObjectID(), FirstName: "Jon", LastName: "Smith",
+		Departments: []string{model.DepartmentActing}}
+	dup := model.Person{ID: primitive.NewObjectID(), FirstName: "John", LastName: "Smyth", BirthDate: born,
+		Departments: []string{model.DepartmentDirecting}}
+
+	req := &model.MergeRequest{
+		Duplicates: []primitive.ObjectID{dup.ID},
+		Rules:      map[string]string{"first_name": dup.ID.Hex(), "birth_date": model.MergeTakeDuplicate},
+	}
+	merged := req.Merge(survivor, []model.Person{dup})
+
+	assert.Equal(t, survivor.ID, merged.ID)
+	assert.Equal(t, "John", merged.FirstName)
+	assert.Equal(t, "Smith", merged.LastName)
+	assert.Equal(t, born, merged.BirthDate)
+	assert.ElementsMatch(t, []string{model.DepartmentActing, model.DepartmentDirecting}, merged.Departments)
+	assert.Equal(t, []string{model.DepartmentActing}, survivor.Departments)
+}
+
+func TestReplacePeople_DropsCreditsThatBecomeDuplicates(t *testing.T) {
+	survivor := primitive.NewObjectID()
+	dup := primitive.NewObjectID()
+	movie := model.Movie{DirectorID: dup, Actors: []primitive.ObjectID{survivor, dup}}
+
+	movie.ReplacePeople([]primitive.ObjectID{dup}, survivor)
+
+	assert.Equal(t, survivor, movie.DirectorID)
+	assert.Equal(t, []primitive.ObjectID{survivor}, movie.Actors)
+	assert.Len(t, movie.Crew, 2)
+}
+
+func TestAwardReplacePeople_FoldsNominations(t *testing.T) {
+	survivor := primitive.NewObjectID()
+	dup := primitive.NewObjectID()
+	movie := primitive.NewObjectID()
+	award := model.Award{Nominees: []model.Nominee{
+		{MovieID: movie, PersonID: &survivor},
+		{MovieID: movie, PersonID: &dup, Won: true},
+	}}
+
+	award.ReplacePeople([]primitive.ObjectID{dup}, survivor)
+
+	assert.Len(t, award.Nominees, 1)
+	assert.Equal(t, survivor, *award.Nominees[0].PersonID)
+	assert.True(t, award.Nominees[0].Won)
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -9,17 +10,33 @@ import (
 
 type User struct {
 	gorm.Model
-	Username  string `gorm:"unique" json:"username"`
-	Email     string `gorm:"unique" json:"email"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Age       int    `json:"age"`
-	Password  string `json:"-" form:"password"`
+	ObjectID  primitive.ObjectID `bson:"_id,omitempty" json:"id" gorm:"-"`
+	Username  string             `gorm:"unique" json:"username"`
+	Email     string             `gorm:"unique" json:"email"`
+	FirstName string             `json:"first_name"`
+	LastName  string             `json:"last_name"`
+	Age       int                `json:"age"`
+	Password  string             `json:"-" form:"password"`
+	Role      string             `bson:"role,omitempty" json:"role"`
+	Country   string             `bson:"country,omitempty" json:"country,omitempty"`
+
+	ParentalControl *ParentalControl `bson:"parental_control,omitempty" json:"-" gorm:"-"`
+
+	// Memberships are the organizations whose catalogs the user works with
+	// and the role they have in each. Role above is the user's role outside
+	// any catalog: admins manage users and organizations.
+	Memberships []Membership `bson:"memberships,omitempty" json:"memberships,omitempty" gorm:"-"`
+
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty" gorm:"-"`
+	DeletedBy string     `bson:"deleted_by,omitempty" json:"deleted_by,omitempty" gorm:"-"`
 }
 
 type UserLoginRequest struct {
 	Email    string `json:"email`
 	Password string `json:"password`
+	// Organization picks the catalog the token works with. It can be left
+	// out by users who belong to a single organization.
+	Organization string `json:"organization"`
 }
 
 type UserLoginResponse struct {
@@ -37,32 +54,221 @@ type UserLogoutResponse struct {
 
 type Movie struct {
 	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	ExternalID  string               `bson:"external_id,omitempty" json:"external_id,omitempty"`
 	Title       string               `bson:"title" json:"title"`
+	Overview    string               `bson:"overview,omitempty" json:"overview"`
 	ReleaseYear int                  `bson:"release_year" json:"release_year"`
+	Releases    []Release            `bson:"releases,omitempty" json:"releases"`
+	Genres      []string             `bson:"genres,omitempty" json:"genres"`
 	DirectorID  primitive.ObjectID   `bson:"director_id" json:"director_id"`
 	Actors      []primitive.ObjectID `bson:"actors" json:"actors"`
+	Crew        []CrewMember         `bson:"crew" json:"crew"`
+	Version     int64                `bson:"version" json:"version"`
+	Poster      *ImageSet            `bson:"poster,omitempty" json:"poster,omitempty"`
+
+	// Slug is the movie's readable stand-in for its id in URLs. SlugAliases
+	// are the slugs it had under earlier titles, kept so old links redirect.
+	Slug        string   `bson:"slug,omitempty" json:"slug"`
+	SlugAliases []string `bson:"slug_aliases,omitempty" json:"-"`
+
+	// Status is where the movie is in the editorial workflow, PublishAt when
+	// it becomes visible to viewers and ReviewComment why it was last sent
+	// back to its editor.
+	Status        string     `bson:"status,omitempty" json:"status"`
+	PublishAt     *time.Time `bson:"publish_at,omitempty" json:"publish_at,omitempty"`
+	ReviewComment string     `bson:"review_comment,omitempty" json:"review_comment,omitempty"`
 
-	Director      *Director `bson:"-" json:"director"`
-	ActorsDetails []Actor   `bson:"-" json:"actors_details"`
+	// DeletedAt and DeletedBy are set while the movie is in the trash.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy string     `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+
+	// OriginalLanguage is the language of Title and Overview. Movies without
+	// one are in the configured default language. Translations are keyed by
+	// locale and managed through their own endpoints.
+	OriginalLanguage string                 `bson:"original_language,omitempty" json:"original_language,omitempty"`
+	Translations     map[string]Translation `bson:"translations,omitempty" json:"-"`
+
+	Director      *Director        `bson:"-" json:"director"`
+	ActorsDetails []Actor          `bson:"-" json:"actors_details"`
+	Collections   []CollectionLink `bson:"-" json:"collections,omitempty"`
 }
 
 type MovieResponse struct {
-	Title         string    `bson:"title" json:"title"`
-	ReleaseYear   int       `bson:"release_year" json:"release_year"`
-	Director      *Director `bson:"-" json:"director"`
-	ActorsDetails []Actor   `bson:"-" json:"actors_details"`
+	ID            primitive.ObjectID   `bson:"-" json:"id"`
+	DirectorID    primitive.ObjectID   `bson:"-" json:"director_id"`
+	Actors        []primitive.ObjectID `bson:"-" json:"actors"`
+	Slug          string               `bson:"-" json:"slug"`
+	Title         string               `bson:"title" json:"title"`
+	OriginalTitle string               `bson:"-" json:"original_title,omitempty"`
+	Overview      string               `bson:"-" json:"overview"`
+	ReleaseYear   int                  `bson:"release_year" json:"release_year"`
+	Releases      []Release            `bson:"-" json:"releases"`
+	LocalRelease  *Release             `bson:"-" json:"local_release,omitempty"`
+	Genres        []string             `bson:"-" json:"genres"`
+	Director      *Director            `bson:"-" json:"director"`
+	ActorsDetails []Actor              `bson:"-" json:"actors_details"`
+	Crew          []CrewMember         `bson:"-" json:"crew"`
+	Version       int64                `bson:"-" json:"version"`
+	Poster        *ImageSet            `bson:"-" json:"poster,omitempty"`
+	Collections   []CollectionLink     `bson:"-" json:"collections,omitempty"`
+	Status        string               `bson:"-" json:"status"`
+	PublishAt     *time.Time           `bson:"-" json:"publish_at,omitempty"`
+	ReviewComment string               `bson:"-" json:"review_comment,omitempty"`
+
+	// Language is the locale the title and overview are shown in.
+	Language         string                 `bson:"-" json:"language"`
+	OriginalLanguage string                 `bson:"-" json:"original_language"`
+	Translations     map[string]Translation `bson:"-" json:"-"`
 }
 
-type Actor struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	FirstName string             `json:"first_name" bson:"first_name"`
-	LastName  string             `json:"last_name" bson:"last_name"`
-	BirthDate time.Time          `bson:"birth_date" json:"birth_date"`
+const (
+	DepartmentActing     = "acting"
+	DepartmentDirecting  = "directing"
+	DepartmentWriting    = "writing"
+	DepartmentProduction = "production"
+	DepartmentCamera     = "camera"
+	DepartmentMusic      = "music"
+)
+
+const (
+	JobActor    = "Actor"
+	JobDirector = "Director"
+)
+
+var departments = map[string]bool{
+	DepartmentActing:     true,
+	DepartmentDirecting:  true,
+	DepartmentWriting:    true,
+	DepartmentProduction: true,
+	DepartmentCamera:     true,
+	DepartmentMusic:      true,
+}
+
+func ValidDepartment(department string) bool {
+	return departments[department]
+}
+
+// NormalizeGenres lowercases and trims genre names and drops blanks and
+// duplicates, so "Drama" and " drama" count as the same genre.
+func NormalizeGenres(genres []string) []string {
+	out := make([]string, 0, len(genres))
+	seen := map[string]bool{}
+	for _, g := range genres {
+		g = strings.ToLower(strings.TrimSpace(g))
+		if g == "" || seen[g] {
+			continue
+		}
+		seen[g] = true
+		out = append(out, g)
+	}
+	return out
 }
 
-type Director struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	FirstName string             `json:"first_name" bson:"first_name"`
-	LastName  string             `json:"last_name" bson:"last_name"`
-	BirthDate time.Time          `bson:"birth_date" json:"birth_date"`
+// Person is a single entry in the people collection. Anybody who worked on a
+// movie, in front of or behind the camera, is stored once and the departments
+// they worked in are listed on the document.
+type Person struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ExternalID  string             `bson:"external_id,omitempty" json:"external_id,omitempty"`
+	FirstName   string             `json:"first_name" bson:"first_name"`
+	LastName    string             `json:"last_name" bson:"last_name"`
+	BirthDate   time.Time          `bson:"birth_date" json:"birth_date"`
+	Departments []string           `bson:"departments" json:"departments"`
+	Version     int64              `bson:"version" json:"version"`
+	Headshot    *ImageSet          `bson:"headshot,omitempty" json:"headshot,omitempty"`
+	Slug        string             `bson:"slug,omitempty" json:"slug"`
+	SlugAliases []string           `bson:"slug_aliases,omitempty" json:"-"`
+	DeletedAt   *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy   string             `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+}
+
+func (p *Person) HasDepartment(department string) bool {
+	for _, d := range p.Departments {
+		if d == department {
+			return true
+		}
+	}
+	return false
+}
+
+// FullName is how people are referred to by name in imports and searches.
+func (p *Person) FullName() string {
+	return strings.TrimSpace(p.FirstName + " " + p.LastName)
+}
+
+// IdentityKey is what two records must share to be treated as the same
+// person: the normalized full name and the birth date.
+func (p *Person) IdentityKey() string {
+	return strings.ToLower(strings.TrimSpace(p.FirstName)) + "|" +
+		strings.ToLower(strings.TrimSpace(p.LastName)) + "|" +
+		p.BirthDate.UTC().Format("2006-01-02")
+}
+
+// Actor and Director are views over the people collection kept for the
+// /actor and /director endpoints.
+type Actor = Person
+type Director = Person
+
+type CrewMember struct {
+	PersonID   primitive.ObjectID `bson:"person_id" json:"person_id"`
+	Department string             `bson:"department" json:"department"`
+	Job        string             `bson:"job" json:"job"`
+
+	Person *Person `bson:"-" json:"person,omitempty"`
+}
+
+// SetDirector replaces the credited director in the crew list.
+func (m *Movie) SetDirector(id primitive.ObjectID) {
+	crew := make([]CrewMember, 0, len(m.Crew)+1)
+	if !id.IsZero() {
+		crew = append(crew, CrewMember{PersonID: id, Department: DepartmentDirecting, Job: JobDirector})
+	}
+	for _, c := range m.Crew {
+		if c.Department == DepartmentDirecting && c.Job == JobDirector {
+			continue
+		}
+		crew = append(crew, c)
+	}
+	m.Crew = crew
+}
+
+// SetCast replaces every acting credit in the crew list.
+func (m *Movie) SetCast(ids []primitive.ObjectID) {
+	crew := make([]CrewMember, 0, len(m.Crew)+len(ids))
+	for _, c := range m.Crew {
+		if c.Department != DepartmentActing {
+			crew = append(crew, c)
+		}
+	}
+	for _, id := range ids {
+		crew = append(crew, CrewMember{PersonID: id, Department: DepartmentActing, Job: JobActor})
+	}
+	m.Crew = crew
+}
+
+// SyncCrew keeps the crew list and the legacy director_id/actors fields in
+// agreement. The crew is authoritative once it has entries, otherwise it is
+// built from the legacy fields.
+func (m *Movie) SyncCrew() {
+	if len(m.Crew) == 0 {
+		m.SetCast(m.Actors)
+		m.SetDirector(m.DirectorID)
+	}
+
+	m.DirectorID = primitive.NilObjectID
+	m.Actors = []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, c := range m.Crew {
+		switch c.Department {
+		case DepartmentDirecting:
+			if m.DirectorID.IsZero() && c.Job == JobDirector {
+				m.DirectorID = c.PersonID
+			}
+		case DepartmentActing:
+			if !seen[c.PersonID] {
+				seen[c.PersonID] = true
+				m.Actors = append(m.Actors, c.PersonID)
+			}
+		}
+	}
 }
@@ -0,0 +1,56 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSyncCrew_BuildsCrewFromLegacyFields(t *testing.T) {
+	director := primitive.NewObjectID()
+	actor := primitive.NewObjectID()
+	movie := model.Movie{DirectorID: director, Actors: []primitive.ObjectID{actor}}
+
+	movie.SyncCrew()
+
+	assert.Len(t, movie.Crew, 2)
+	assert.Equal(t, director, movie.DirectorID)
+	assert.Equal(t, []primitive.ObjectID{actor}, movie.Actors)
+}
+
+func TestSyncCrew_CrewIsAuthoritative(t *testing.T) {
+	director := primitive.NewObjectID()
+	writer := primitive.NewObjectID()
+	actor := primitive.NewObjectID()
+	movie := model.Movie{
+		DirectorID: primitive.NewObjectID(),
+		Crew: []model.CrewMember{
+			{PersonID: writer, Department: model.DepartmentWriting, Job: "Screenplay"},
+			{PersonID: director, Department: model.DepartmentDirecting, Job: model.JobDirector},
+			{PersonID: actor, Department: model.DepartmentActing, Job: model.JobActor},
+			{PersonID: actor, Department: model.DepartmentActing, Job: model.JobActor},
+		},
+	}
+
+	movie.SyncCrew()
+
+	assert.Equal(t, director, movie.DirectorID)
+	assert.Equal(t, []primitive.ObjectID{actor}, movie.Actors)
+}
+
+func TestSetDirector_ReplacesOnlyTheDirectorCredit(t *testing.T) {
+	writer := primitive.NewObjectID()
+	newDirector := primitive.NewObjectID()
+	movie := model.Movie{Crew: []model.CrewMember{
+		{PersonID: primitive.NewObjectID(), Department: model.DepartmentDirecting, Job: model.JobDirector},
+		{PersonID: writer, Department: model.DepartmentWriting, Job: "Writer"},
+	}}
+
+	movie.SetDirector(newDirector)
+	movie.SyncCrew()
+
+	assert.Len(t, movie.Crew, 2)
+	assert.Equal(t, newDirector, movie.DirectorID)
+}
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultOrganization owns the catalog that existed before organizations
+// did. New users start out as members of it.
+const DefaultOrganization = "default"
+
+// maxOrganizationSlug keeps the slug short enough to end up in a database
+// name.
+const maxOrganizationSlug = 32
+
+var (
+	ErrNotMember            = errors.New("user is not a member of the organization")
+	ErrOrganizationRequired = errors.New("user belongs to several organizations, choose one")
+)
+
+// Organization is a business unit with a catalog of its own. Slug names it
+// in requests and tokens and never changes.
+type Organization struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Slug      string             `bson:"slug" json:"slug"`
+	Name      string             `bson:"name" json:"name"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	CreatedBy string             `bson:"created_by,omitempty" json:"created_by,omitempty"`
+}
+
+// Validate makes sure the slug can serve as part of a database name: it has
+// to be a slug already, not merely something that turns into one.
+func (o *Organization) Validate() error {
+	if o.Slug == "" || Slugify(o.Slug) != o.Slug {
+		return invalidDocument("slug must be lowercase letters and digits joined by dashes")
+	}
+	if len(o.Slug) > maxOrganizationSlug {
+		return invalidDocument("slug must be at most %d characters", maxOrganizationSlug)
+	}
+	if o.Name == "" {
+		return invalidDocument("name is required")
+	}
+	return nil
+}
+
+// Membership puts a user in an organization with the role they have in its
+// catalog.
+type Membership struct {
+	Organization string `bson:"organization" json:"organization"`
+	Role         string `bson:"role" json:"role"`
+}
+
+// OrganizationSlugs lists the organizations the user belongs to.
+func (u *User) OrganizationSlugs() []string {
+	slugs := make([]string, len(u.Memberships))
+	for i, m := range u.Memberships {
+		slugs[i] = m.Organization
+	}
+	return slugs
+}
+
+// Membership returns the user's membership in the organization, or nil when
+// they do not belong to it.
+func (u *User) Membership(organization string) *Membership {
+	for i := range u.Memberships {
+		if u.Memberships[i].Organization == organization {
+			return &u.Memberships[i]
+		}
+	}
+	return nil
+}
+
+func (u *User) MemberOf(organization string) bool {
+	return u.Membership(organization) != nil
+}
+
+// Join returns the user's memberships with the given one added, or with the
+// role changed when they already belong to the organization.
+func (u *User) Join(organization, role string) []Membership {
+	memberships := make([]Membership, 0, len(u.Memberships)+1)
+	joined := false
+	for _, m := range u.Memberships {
+		if m.Organization == organization {
+			m.Role = role
+			joined = true
+		}
+		memberships = append(memberships, m)
+	}
+	if !joined {
+		memberships = append(memberships, Membership{Organization: organization, Role: role})
+	}
+	return memberships
+}
+
+// Leave returns the user's memberships without the given organization.
+func (u *User) Leave(organization string) []Membership {
+	memberships := []Membership{}
+	for _, m := range u.Memberships {
+		if m.Organization != organization {
+			memberships = append(memberships, m)
+		}
+	}
+	return memberships
+}
@@ -0,0 +1,38 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganizationValidate(t *testing.T) {
+	assert.NoError(t, (&model.Organization{Slug: "acme-films", Name: "Acme Films"}).Validate())
+	assert.ErrorIs(t, (&model.Organization{Slug: "Acme Films", Name: "Acme"}).Validate(), model.ErrInvalidDocument)
+	assert.ErrorIs(t, (&model.Organization{Slug: "acme"}).Validate(), model.ErrInvalidDocument)
+	assert.ErrorIs(t, (&model.Organization{Slug: "", Name: "Acme"}).Validate(), model.ErrInvalidDocument)
+}
+
+func TestUserOrganizations(t *testing.T) {
+	user := &model.User{}
+	assert.Empty(t, user.OrganizationSlugs())
+	assert.False(t, user.MemberOf(model.DefaultOrganization))
+
+	user.Memberships = user.Join(model.DefaultOrganization, model.RoleViewer)
+	user.Memberships = user.Join("acme", model.RoleEditor)
+	assert.Equal(t, []string{model.DefaultOrganization, "acme"}, user.OrganizationSlugs())
+	assert.Equal(t, model.RoleEditor, user.Membership("acme").Role)
+	assert.Nil(t, user.Membership("other"))
+
+	user.Memberships = user.Join("acme", model.RoleAdmin)
+	assert.Len(t, user.Memberships, 2)
+	assert.Equal(t, model.RoleAdmin, user.Membership("acme").Role)
+
+	user.Memberships = user.Leave(model.DefaultOrganization)
+	assert.Equal(t, []string{"acme"}, user.OrganizationSlugs())
+	assert.False(t, user.MemberOf(model.DefaultOrganization))
+
+	user.Memberships = user.Leave("acme")
+	assert.Empty(t, user.Memberships)
+}
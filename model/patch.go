@@ -0,0 +1,218 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidDocument is wrapped by the errors returned when a patched
+// document fails validation.
+var ErrInvalidDocument = errors.New("invalid document")
+
+func invalidDocument(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidDocument, fmt.Sprintf(format, args...))
+}
+
+// MoviePatch is the document PATCH requests on a movie are applied to. It
+// holds every stored field a client may change; id and version are there so
+// JSON Patch can test them but must come out unchanged.
+type MoviePatch struct {
+	ID               primitive.ObjectID   `json:"id"`
+	Version          int64                `json:"version"`
+	ExternalID       string               `json:"external_id"`
+	Title            string               `json:"title"`
+	Overview         string               `json:"overview"`
+	OriginalLanguage string               `json:"original_language"`
+	ReleaseYear      int                  `json:"release_year"`
+	Releases         []Release            `json:"releases"`
+	Genres           []string             `json:"genres"`
+	DirectorID       primitive.ObjectID   `json:"director_id"`
+	Actors           []primitive.ObjectID `json:"actors"`
+	Crew             []CrewMember         `json:"crew"`
+}
+
+func NewMoviePatch(m *Movie) *MoviePatch {
+	movie := *m
+	movie.SyncCrew()
+	return &MoviePatch{
+		ID:               movie.ID,
+		Version:          movie.Version,
+		ExternalID:       movie.ExternalID,
+		Title:            movie.Title,
+		Overview:         movie.Overview,
+		OriginalLanguage: movie.OriginalLanguage,
+		ReleaseYear:      movie.ReleaseYear,
+		Releases:         movie.Releases,
+		Genres:           NormalizeGenres(movie.Genres),
+		DirectorID:       movie.DirectorID,
+		Actors:           movie.Actors,
+		Crew:             movie.Crew,
+	}
+}
+
+// ApplyTo validates the patched document and copies it onto the movie it was
+// made from. When both the crew and the legacy director_id/actors fields were
+// changed the crew wins, and a movie with releases takes its release year
+// from the earliest one.
+func (p *MoviePatch) ApplyTo(m *Movie) error {
+	if p.ID != m.ID {
+		return invalidDocument("id cannot be changed")
+	}
+	if p.Version != m.Version {
+		return invalidDocument("version cannot be changed")
+	}
+	if strings.TrimSpace(p.Title) == "" {
+		return invalidDocument("title is required")
+	}
+	if p.ReleaseYear < 0 {
+		return invalidDocument("release_year cannot be negative")
+	}
+	releases, err := NormalizeReleases(p.Releases)
+	if err != nil {
+		return err
+	}
+	if p.OriginalLanguage != "" {
+		locale, err := NormalizeLocale(p.OriginalLanguage)
+		if err != nil {
+			return invalidDocument("original_language is not a language tag")
+		}
+		p.OriginalLanguage = locale
+	}
+	for i := range p.Crew {
+		if p.Crew[i].PersonID.IsZero() {
+			return invalidDocument("crew[%d] has no person_id", i)
+		}
+		if !ValidDepartment(p.Crew[i].Department) {
+			return invalidDocument("crew[%d] has unknown department %q", i, p.Crew[i].Department)
+		}
+		p.Crew[i].Person = nil
+	}
+
+	original := NewMoviePatch(m)
+	m.ExternalID = p.ExternalID
+	m.Title = p.Title
+	m.Overview = p.Overview
+	m.OriginalLanguage = p.OriginalLanguage
+	m.ReleaseYear = p.ReleaseYear
+	m.SetReleases(releases)
+	m.Genres = NormalizeGenres(p.Genres)
+	m.Crew = original.Crew
+
+	if !sameCrew(p.Crew, original.Crew) {
+		m.Crew = p.Crew
+	} else {
+		if p.DirectorID != original.DirectorID {
+			m.SetDirector(p.DirectorID)
+		}
+		if !sameIDs(p.Actors, original.Actors) {
+			m.SetCast(p.Actors)
+		}
+	}
+	m.SyncCrew()
+	return nil
+}
+
+// PersonPatch is the document PATCH requests on a person are applied to.
+// The birth date is a plain yyyy-mm-dd string, or null when unknown.
+type PersonPatch struct {
+	ID          primitive.ObjectID `json:"id"`
+	Version     int64              `json:"version"`
+	ExternalID  string             `json:"external_id"`
+	FirstName   string             `json:"first_name"`
+	LastName    string             `json:"last_name"`
+	BirthDate   *string            `json:"birth_date"`
+	Departments []string           `json:"departments"`
+}
+
+func NewPersonPatch(p *Person) *PersonPatch {
+	patch := &PersonPatch{
+		ID:          p.ID,
+		Version:     p.Version,
+		ExternalID:  p.ExternalID,
+		FirstName:   p.FirstName,
+		LastName:    p.LastName,
+		Departments: append([]string{}, p.Departments...),
+	}
+	if !p.BirthDate.IsZero() {
+		date := p.BirthDate.UTC().Format("2006-01-02")
+		patch.BirthDate = &date
+	}
+	return patch
+}
+
+// ApplyTo validates the patched document and copies it onto the person it
+// was made from. A non-empty department is one the person has to keep, which
+// is the case when patching through the actor or director endpoints.
+func (p *PersonPatch) ApplyTo(person *Person, department string) error {
+	if p.ID != person.ID {
+		return invalidDocument("id cannot be changed")
+	}
+	if p.Version != person.Version {
+		return invalidDocument("version cannot be changed")
+	}
+	if strings.TrimSpace(p.FirstName) == "" {
+		return invalidDocument("first_name is required")
+	}
+	if strings.TrimSpace(p.LastName) == "" {
+		return invalidDocument("last_name is required")
+	}
+
+	var birthDate time.Time
+	if p.BirthDate != nil {
+		t, err := time.Parse("2006-01-02", *p.BirthDate)
+		if err != nil {
+			return invalidDocument("birth_date must be yyyy-mm-dd")
+		}
+		birthDate = t
+	}
+
+	departments := make([]string, 0, len(p.Departments))
+	seen := map[string]bool{}
+	for _, d := range p.Departments {
+		if !ValidDepartment(d) {
+			return invalidDocument("unknown department %q", d)
+		}
+		if !seen[d] {
+			seen[d] = true
+			departments = append(departments, d)
+		}
+	}
+	if department != "" && !seen[department] {
+		return invalidDocument("departments must include %q", department)
+	}
+
+	person.ExternalID = p.ExternalID
+	person.FirstName = p.FirstName
+	person.LastName = p.LastName
+	person.BirthDate = birthDate
+	person.Departments = departments
+	return nil
+}
+
+func sameCrew(a, b []CrewMember) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].PersonID != b[i].PersonID || a[i].Department != b[i].Department || a[i].Job != b[i].Job {
+			return false
+		}
+	}
+	return true
+}
+
+func sameIDs(a, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
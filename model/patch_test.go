@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMoviePatch_ApplyToEmptiesCast(t *testing.T) {
+	director, actor := primitive.NewObjectID(), primitive.NewObjectID()
+	movie := &model.Movie{ID: primitive.NewObjectID(), Title: "Heat", ReleaseYear: 1995,
+		DirectorID: director, Actors: []primitive.ObjectID{actor}}
+
+	patch := model.NewMoviePatch(movie)
+	patch.ReleaseYear = 0
+	patch.Actors = []primitive.ObjectID{}
+
+	require.NoError(t, patch.ApplyTo(movie))
+	assert.Equal(t, 0, movie.ReleaseYear)
+	assert.Empty(t, movie.Actors)
+	assert.Equal(t, director, movie.DirectorID)
+	require.Len(t, movie.Crew, 1)
+	assert.Equal(t, model.JobDirector, movie.Crew[0].Job)
+}
+
+func TestMoviePatch_ApplyToRejectsInvalidDocument(t *testing.T) {
+	movie := &model.Movie{ID: primitive.NewObjectID(), Title: "Heat", Version: 2}
+
+	patch := model.NewMoviePatch(movie)
+	patch.Title = " "
+	assert.ErrorIs(t, patch.ApplyTo(movie), model.ErrInvalidDocument)
+
+	patch = model.NewMoviePatch(movie)
+	patch.Version = 3
+	assert.ErrorIs(t, patch.ApplyTo(movie), model.ErrInvalidDocument)
+}
+
+func TestPersonPatch_ApplyToKeepsScopedDepartment(t *testing.T) {
+	person := &model.Person{ID: primitive.NewObjectID(), FirstName: "Al", LastName: "Pacino",
+		Departments: []string{model.DepartmentActing}}
+
+	patch := model.NewPersonPatch(person)
+	patch.Departments = []string{model.DepartmentDirecting}
+	assert.ErrorIs(t, patch.ApplyTo(person, model.DepartmentActing), model.ErrInvalidDocument)
+
+	date := "1940-04-25"
+	patch = model.NewPersonPatch(person)
+	patch.BirthDate = &date
+	require.NoError(t, patch.ApplyTo(person, model.DepartmentActing))
+	assert.Equal(t, 1940, person.BirthDate.Year())
+}
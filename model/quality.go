@@ -0,0 +1,290 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// What the data-quality check finds.
+const (
+	IssueMissingDirector  = "missing_director"
+	IssueMissingActor     = "missing_actor"
+	IssueTrashedCredit    = "credit_to_trashed_person"
+	IssueIdlePerson       = "person_without_movies"
+	IssueDuplicatePerson  = "duplicate_person"
+	IssueNoReleaseYear    = "missing_release_year"
+	IssueNoCast           = "missing_cast"
+	IssueBornAfterRelease = "born_after_release"
+)
+
+// QualityFinding is one problem in the catalog. ID stays the same between
+// checks as long as the problem does, so a fix can name it. Fixable findings
+// can be repaired in bulk with the suggested fix; the rest need an editor.
+type QualityFinding struct {
+	ID           string               `json:"id"`
+	Code         string               `json:"code"`
+	Entity       string               `json:"entity"`
+	EntityID     primitive.ObjectID   `json:"entity_id"`
+	Name         string               `json:"name"`
+	Link         string               `json:"link"`
+	Message      string               `json:"message"`
+	SuggestedFix string               `json:"suggested_fix"`
+	Fixable      bool                 `json:"fixable"`
+	Related      []primitive.ObjectID `json:"related,omitempty"`
+}
+
+// QualityReport is the outcome of one data-quality check.
+type QualityReport struct {
+	CheckedAt time.Time        `json:"checked_at"`
+	Movies    int              `json:"movies"`
+	People    int              `json:"people"`
+	Counts    map[string]int   `json:"counts"`
+	Findings  []QualityFinding `json:"findings"`
+}
+
+// QualityFixRequest picks the findings to repair, by ID or by code. Only
+// fixable findings are repaired.
+type QualityFixRequest struct {
+	IDs   []string `json:"ids"`
+	Codes []string `json:"codes"`
+}
+
+func (r *QualityFixRequest) Validate() error {
+	if len(r.IDs) == 0 && len(r.Codes) == 0 {
+		return invalidDocument("ids or codes are required")
+	}
+	return nil
+}
+
+// Selects reports whether the request asks for the finding.
+func (r *QualityFixRequest) Selects(f *QualityFinding) bool {
+	return containsString(r.IDs, f.ID) || containsString(r.Codes, f.Code)
+}
+
+// QualityFixResult tells what became of the findings a fix asked for.
+// Findings that are not fixable are skipped, failures carry their error.
+type QualityFixResult struct {
+	Fixed   []string          `json:"fixed"`
+	Skipped []string          `json:"skipped"`
+	Failed  map[string]string `json:"failed"`
+}
+
+func movieLinkPath(id primitive.ObjectID) string {
+	return "/api/movie/" + id.Hex()
+}
+
+func personLinkPath(id primitive.ObjectID) string {
+	return "/api/person/" + id.Hex()
+}
+
+func movieFinding(code string, m *Movie) QualityFinding {
+	return QualityFinding{
+		ID:       code + ":" + m.ID.Hex(),
+		Code:     code,
+		Entity:   EntityMovie,
+		EntityID: m.ID,
+		Name:     m.Title,
+		Link:     movieLinkPath(m.ID),
+	}
+}
+
+func personFinding(code string, p *Person) QualityFinding {
+	return QualityFinding{
+		ID:       code + ":" + p.ID.Hex(),
+		Code:     code,
+		Entity:   EntityPerson,
+		EntityID: p.ID,
+		Name:     p.FullName(),
+		Link:     personLinkPath(p.ID),
+	}
+}
+
+func hexList(ids []primitive.ObjectID) string {
+	hex := make([]string, len(ids))
+	for i, id := range ids {
+		hex[i] = id.Hex()
+	}
+	return strings.Join(hex, ", ")
+}
+
+// CheckQuality looks for problems in the movies and people of a catalog.
+// Credits of people in the trash are not dangling, a restore would bring
+// them back, but are reported so an editor decides between the two. Movies
+// in the trash are not checked themselves, but their credits keep people
+// from counting as idle for the same reason. seriesPeople are those credited
+// on a series, who are not missing movies.
+func CheckQuality(movies []Movie, people []Person, seriesPeople []primitive.ObjectID, now time.Time) *QualityReport {
+	report := &QualityReport{CheckedAt: now, Counts: map[string]int{}}
+
+	exists := make(map[primitive.ObjectID]bool, len(people))
+	byID := make(map[primitive.ObjectID]*Person, len(people))
+	for i := range people {
+		exists[people[i].ID] = true
+		if people[i].DeletedAt == nil {
+			byID[people[i].ID] = &people[i]
+		}
+	}
+	report.People = len(byID)
+
+	credited := map[primitive.ObjectID]bool{}
+	for _, id := range seriesPeople {
+		credited[id] = true
+	}
+
+	for i := range movies {
+		m := &movies[i]
+		for _, id := range m.PeopleIDs() {
+			credited[id] = true
+		}
+		if m.DeletedAt != nil {
+			continue
+		}
+		report.Movies++
+		report.Findings = append(report.Findings, checkMovie(m, exists, byID)...)
+	}
+
+	var live []*Person
+	for _, p := range byID {
+		live = append(live, p)
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].ID.Hex() < live[j].ID.Hex() })
+
+	dupes := map[string][]*Person{}
+	var keys []string
+	for _, p := range live {
+		if (p.HasDepartment(DepartmentActing) || p.HasDepartment(DepartmentDirecting)) && !credited[p.ID] {
+			f := personFinding(IssueIdlePerson, p)
+			f.Message = "credited on no movie or series"
+			f.SuggestedFix = "move the person to the trash"
+			f.Fixable = true
+			report.Findings = append(report.Findings, f)
+		}
+		if p.BirthDate.IsZero() || p.FullName() == "" {
+			continue
+		}
+		key := p.IdentityKey()
+		if _, ok := dupes[key]; !ok {
+			keys = append(keys, key)
+		}
+		dupes[key] = append(dupes[key], p)
+	}
+	for _, key := range keys {
+		group := dupes[key]
+		if len(group) < 2 {
+			continue
+		}
+		// The oldest record survives, its ID is the one most likely linked.
+		f := personFinding(IssueDuplicatePerson, group[0])
+		for _, p := range group[1:] {
+			f.Related = append(f.Related, p.ID)
+		}
+		f.Message = fmt.Sprintf("same name and birth date as %s", hexList(f.Related))
+		f.SuggestedFix = "merge the duplicates into this person"
+		f.Fixable = true
+		report.Findings = append(report.Findings, f)
+	}
+
+	for _, f := range report.Findings {
+		report.Counts[f.Code]++
+	}
+	if report.Findings == nil {
+		report.Findings = []QualityFinding{}
+	}
+	return report
+}
+
+func checkMovie(m *Movie, exists map[primitive.ObjectID]bool, live map[primitive.ObjectID]*Person) []QualityFinding {
+	var findings []QualityFinding
+
+	if !m.DirectorID.IsZero() && !exists[m.DirectorID] {
+		f := movieFinding(IssueMissingDirector, m)
+		f.Related = []primitive.ObjectID{m.DirectorID}
+		f.Message = "director_id points to a person who does not exist: " + m.DirectorID.Hex()
+		f.SuggestedFix = "remove the director credit"
+		f.Fixable = true
+		findings = append(findings, f)
+	}
+
+	var missing []primitive.ObjectID
+	for _, id := range m.Actors {
+		if !exists[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		f := movieFinding(IssueMissingActor, m)
+		f.Related = missing
+		f.Message = "actors point to people who do not exist: " + hexList(missing)
+		f.SuggestedFix = "remove the missing actors from the cast"
+		f.Fixable = true
+		findings = append(findings, f)
+	}
+
+	var inTrash []primitive.ObjectID
+	for _, id := range m.PeopleIDs() {
+		if exists[id] && live[id] == nil {
+			inTrash = append(inTrash, id)
+		}
+	}
+	if len(inTrash) > 0 {
+		f := movieFinding(IssueTrashedCredit, m)
+		f.Related = inTrash
+		f.Message = "credits people who are in the trash: " + hexList(inTrash)
+		f.SuggestedFix = "restore the people from the trash or remove their credits"
+		findings = append(findings, f)
+	}
+
+	if m.ReleaseYear == 0 {
+		f := movieFinding(IssueNoReleaseYear, m)
+		f.Message = "release_year is not set"
+		f.SuggestedFix = "set release_year on the movie"
+		if year := m.FirstReleaseYear(); year > 0 {
+			f.SuggestedFix = fmt.Sprintf("set release_year to %d, the year of its first release", year)
+			f.Fixable = true
+		}
+		findings = append(findings, f)
+	}
+
+	if len(m.Actors) == 0 {
+		f := movieFinding(IssueNoCast, m)
+		f.Message = "the movie has no cast"
+		f.SuggestedFix = "add the cast on the movie"
+		findings = append(findings, f)
+	}
+
+	if m.ReleaseYear > 0 {
+		for _, id := range m.PeopleIDs() {
+			p, ok := live[id]
+			if !ok || p.BirthDate.IsZero() || p.BirthDate.Year() <= m.ReleaseYear {
+				continue
+			}
+			f := personFinding(IssueBornAfterRelease, p)
+			f.ID += ":" + m.ID.Hex()
+			f.Related = []primitive.ObjectID{m.ID}
+			f.Message = fmt.Sprintf("born in %d but credited on %q released in %d",
+				p.BirthDate.Year(), m.Title, m.ReleaseYear)
+			f.SuggestedFix = "correct the person's birth date or the movie's release year"
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// FirstReleaseYear is the year of the earliest dated release, 0 when none
+// has a date.
+func (m *Movie) FirstReleaseYear() int {
+	year := 0
+	for _, r := range m.Releases {
+		if r.Date.IsZero() {
+			continue
+		}
+		if year == 0 || r.Date.Year() < year {
+			year = r.Date.Year()
+		}
+	}
+	return year
+}
@@ -0,0 +1,125 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func findingsOf(report *model.QualityReport, code string) []model.QualityFinding {
+	var out []model.QualityFinding
+	for _, f := range report.Findings {
+		if f.Code == code {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func TestCheckQuality(t *testing.T) {
+	born := time.Date(1970, 5, 1, 0, 0, 0, 0, time.UTC)
+	director := model.Person{ID: primitive.NewObjectID(), FirstName: "Ann", LastName: "Lee",
+		BirthDate: born, Departments: []string{model.DepartmentDirecting}}
+	actor := model.Person{ID: primitive.NewObjectID(), FirstName: "Bo", LastName: "Kim",
+		BirthDate: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), Departments: []string{model.DepartmentActing}}
+	copyOf := model.Person{ID: primitive.NewObjectID(), FirstName: " ann", LastName: "LEE",
+		BirthDate: born, Departments: []string{model.DepartmentDirecting}}
+	deleted := time.Now()
+	trashed := model.Person{ID: primitive.NewObjectID(), FirstName: "Cy", DeletedAt: &deleted,
+		Departments: []string{model.DepartmentActing}}
+	missing := primitive.NewObjectID()
+
+	movie := model.Movie{ID: primitive.NewObjectID(), Title: "Dawn", ReleaseYear: 1999,
+		DirectorID: director.ID, Actors: []primitive.ObjectID{actor.ID, trashed.ID, missing}}
+	undated := model.Movie{ID: primitive.NewObjectID(), Title: "Dusk", DirectorID: missing,
+		Releases: []model.Release{{Country: "US", Date: time.Date(2004, 3, 1, 0, 0, 0, 0, time.UTC)}}}
+
+	report := model.CheckQuality(
+		[]model.Movie{movie, undated},
+		[]model.Person{director, actor, copyOf, trashed},
+		nil,
+		time.Now(),
+	)
+	assert.Equal(t, 2, report.Movies)
+	assert.Equal(t, 3, report.People)
+
+	actors := findingsOf(report, model.IssueMissingActor)
+	if assert.Len(t, actors, 1) {
+		assert.Equal(t, movie.ID, actors[0].EntityID)
+		assert.Equal(t, []primitive.ObjectID{missing}, actors[0].Related)
+		assert.Equal(t, "/api/movie/"+movie.ID.Hex(), actors[0].Link)
+		assert.True(t, actors[0].Fixable)
+	}
+
+	inTrash := findingsOf(report, model.IssueTrashedCredit)
+	if assert.Len(t, inTrash, 1) {
+		assert.Equal(t, movie.ID, inTrash[0].EntityID)
+		assert.Equal(t, []primitive.ObjectID{trashed.ID}, inTrash[0].Related)
+		assert.False(t, inTrash[0].Fixable)
+	}
+
+	directors := findingsOf(report, model.IssueMissingDirector)
+	if assert.Len(t, directors, 1) {
+		assert.Equal(t, undated.ID, directors[0].EntityID)
+	}
+
+	years := findingsOf(report, model.IssueNoReleaseYear)
+	if assert.Len(t, years, 1) {
+		assert.True(t, years[0].Fixable)
+		assert.Contains(t, years[0].SuggestedFix, "2004")
+	}
+
+	cast := findingsOf(report, model.IssueNoCast)
+	if assert.Len(t, cast, 1) {
+		assert.Equal(t, undated.ID, cast[0].EntityID)
+		assert.False(t, cast[0].Fixable)
+	}
+
+	late := findingsOf(report, model.IssueBornAfterRelease)
+	if assert.Len(t, late, 1) {
+		assert.Equal(t, actor.ID, late[0].EntityID)
+		assert.Equal(t, "/api/person/"+actor.ID.Hex(), late[0].Link)
+	}
+
+	dupes := findingsOf(report, model.IssueDuplicatePerson)
+	if assert.Len(t, dupes, 1) {
+		assert.Equal(t, director.ID, dupes[0].EntityID)
+		assert.Equal(t, []primitive.ObjectID{copyOf.ID}, dupes[0].Related)
+	}
+
+	idle := findingsOf(report, model.IssueIdlePerson)
+	if assert.Len(t, idle, 1) {
+		assert.Equal(t, copyOf.ID, idle[0].EntityID)
+	}
+	assert.Equal(t, 1, report.Counts[model.IssueIdlePerson])
+}
+
+func TestCheckQualitySeriesCredits(t *testing.T) {
+	person := model.Person{ID: primitive.NewObjectID(), Departments: []string{model.DepartmentActing}}
+
+	report := model.CheckQuality(nil, []model.Person{person}, []primitive.ObjectID{person.ID}, time.Now())
+	assert.Empty(t, report.Findings)
+}
+
+func TestCheckQualityTrashedMovies(t *testing.T) {
+	person := model.Person{ID: primitive.NewObjectID(), Departments: []string{model.DepartmentActing}}
+	deleted := time.Now()
+	movie := model.Movie{ID: primitive.NewObjectID(), Title: "Dawn", DeletedAt: &deleted,
+		Actors: []primitive.ObjectID{person.ID, primitive.NewObjectID()}}
+
+	report := model.CheckQuality([]model.Movie{movie}, []model.Person{person}, nil, time.Now())
+	assert.Equal(t, 0, report.Movies)
+	assert.Empty(t, report.Findings)
+}
+
+func TestQualityFixRequest(t *testing.T) {
+	assert.ErrorIs(t, (&model.QualityFixRequest{}).Validate(), model.ErrInvalidDocument)
+
+	f := &model.QualityFinding{ID: "missing_actor:abc", Code: model.IssueMissingActor}
+	assert.True(t, (&model.QualityFixRequest{Codes: []string{model.IssueMissingActor}}).Selects(f))
+	assert.True(t, (&model.QualityFixRequest{IDs: []string{"missing_actor:abc"}}).Selects(f))
+	assert.False(t, (&model.QualityFixRequest{Codes: []string{model.IssueNoCast}}).Selects(f))
+}
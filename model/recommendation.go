@@ -0,0 +1,176 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MinRatingScore = 1
+	MaxRatingScore = 5
+)
+
+// Rating is one user's score for a movie, one per user and movie.
+type Rating struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User    string             `bson:"user" json:"user"`
+	MovieID primitive.ObjectID `bson:"movie_id" json:"movie_id"`
+	Score   int                `bson:"score" json:"score"`
+	RatedAt time.Time          `bson:"rated_at" json:"rated_at"`
+}
+
+type WatchlistEntry struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	User    string             `bson:"user" json:"user"`
+	MovieID primitive.ObjectID `bson:"movie_id" json:"movie_id"`
+	AddedAt time.Time          `bson:"added_at" json:"added_at"`
+}
+
+// Recommendation is a suggested movie with the score it got and the reasons
+// that make up that score, most important first.
+type Recommendation struct {
+	Movie   MovieLink `json:"movie"`
+	Score   float64   `json:"score"`
+	Reasons []string  `json:"reasons"`
+}
+
+// Weights of the similarity signals.
+const (
+	sharedDirectorWeight = 3.0
+	sharedActorWeight    = 1.0
+	sharedGenreWeight    = 1.5
+	eraWeight            = 2.0
+	eraSpan              = 20
+)
+
+type weightedReason struct {
+	weight float64
+	text   string
+}
+
+// Similarity scores how alike two movies are from shared directors, shared
+// cast, shared genres and how close their release years are. It returns the
+// score and the reasons behind it, strongest first.
+func Similarity(target, candidate *Movie) (float64, []string) {
+	var reasons []weightedReason
+
+	directors := sharedPeople(target, candidate, DepartmentDirecting)
+	if n := len(directors); n > 0 {
+		reasons = append(reasons, weightedReason{float64(n) * sharedDirectorWeight, "same director"})
+	}
+
+	actors := sharedPeople(target, candidate, DepartmentActing)
+	if n := len(actors); n > 0 {
+		text := "shares 1 actor"
+		if n > 1 {
+			text = fmt.Sprintf("shares %d actors", n)
+		}
+		reasons = append(reasons, weightedReason{float64(n) * sharedActorWeight, text})
+	}
+
+	genres := sharedGenres(target.Genres, candidate.Genres)
+	if n := len(genres); n > 0 {
+		reasons = append(reasons, weightedReason{
+			float64(n) * sharedGenreWeight,
+			"shares genres: " + strings.Join(genres, ", "),
+		})
+	}
+
+	if target.ReleaseYear > 0 && candidate.ReleaseYear > 0 {
+		gap := target.ReleaseYear - candidate.ReleaseYear
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap < eraSpan {
+			text := "released the same year"
+			if gap > 0 {
+				text = fmt.Sprintf("released %d years apart", gap)
+			}
+			reasons = append(reasons, weightedReason{eraWeight * float64(eraSpan-gap) / eraSpan, text})
+		}
+	}
+
+	sort.SliceStable(reasons, func(i, j int) bool { return reasons[i].weight > reasons[j].weight })
+	score := 0.0
+	texts := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		score += r.weight
+		texts = append(texts, r.text)
+	}
+	return score, texts
+}
+
+// creditedIn lists the people credited on the movie in a department, taking
+// the legacy fields into account for movies without a crew list.
+func creditedIn(m *Movie, department string) map[primitive.ObjectID]bool {
+	people := map[primitive.ObjectID]bool{}
+	for _, c := range m.Crew {
+		if c.Department == department {
+			people[c.PersonID] = true
+		}
+	}
+	if len(m.Crew) == 0 {
+		switch department {
+		case DepartmentDirecting:
+			if !m.DirectorID.IsZero() {
+				people[m.DirectorID] = true
+			}
+		case DepartmentActing:
+			for _, id := range m.Actors {
+				people[id] = true
+			}
+		}
+	}
+	return people
+}
+
+func sharedPeople(a, b *Movie, department string) []primitive.ObjectID {
+	inA := creditedIn(a, department)
+	var shared []primitive.ObjectID
+	for id := range creditedIn(b, department) {
+		if inA[id] {
+			shared = append(shared, id)
+		}
+	}
+	return shared
+}
+
+func sharedGenres(a, b []string) []string {
+	inA := map[string]bool{}
+	for _, g := range NormalizeGenres(a) {
+		inA[g] = true
+	}
+	var shared []string
+	for _, g := range NormalizeGenres(b) {
+		if inA[g] {
+			shared = append(shared, g)
+		}
+	}
+	return shared
+}
+
+// RankRecommendations orders recommendations by score, best first, breaking
+// ties by title so results are stable, and keeps the first limit of them.
+func RankRecommendations(recs []Recommendation, limit int) []Recommendation {
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].Score != recs[j].Score {
+			return recs[i].Score > recs[j].Score
+		}
+		return recs[i].Movie.Title < recs[j].Movie.Title
+	})
+	if len(recs) > limit {
+		recs = recs[:limit]
+	}
+	return recs
+}
+
+// MovieRating is the average of all user ratings of a movie.
+type MovieRating struct {
+	MovieID primitive.ObjectID `bson:"_id" json:"movie_id"`
+	Average float64            `bson:"average" json:"average"`
+	Count   int                `bson:"count" json:"count"`
+}
@@ -0,0 +1,55 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSimilarityExplainsScore(t *testing.T) {
+	director := primitive.NewObjectID()
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	target := &model.Movie{
+		DirectorID:  director,
+		Actors:      []primitive.ObjectID{a, b, c},
+		Genres:      []string{"crime", "drama"},
+		ReleaseYear: 1995,
+	}
+	candidate := &model.Movie{
+		DirectorID:  director,
+		Actors:      []primitive.ObjectID{a, b},
+		Genres:      []string{"Crime"},
+		ReleaseYear: 1995,
+	}
+
+	score, reasons := model.Similarity(target, candidate)
+
+	assert.InDelta(t, 3+2+1.5+2, score, 0.001)
+	assert.Equal(t, []string{"same director", "shares 2 actors", "released the same year", "shares genres: crime"}, reasons)
+}
+
+func TestSimilarityIgnoresDistantEras(t *testing.T) {
+	score, reasons := model.Similarity(
+		&model.Movie{ReleaseYear: 1950},
+		&model.Movie{ReleaseYear: 2020},
+	)
+
+	assert.Zero(t, score)
+	assert.Empty(t, reasons)
+}
+
+func TestRankRecommendations(t *testing.T) {
+	recs := []model.Recommendation{
+		{Movie: model.MovieLink{Title: "B"}, Score: 2},
+		{Movie: model.MovieLink{Title: "C"}, Score: 5},
+		{Movie: model.MovieLink{Title: "A"}, Score: 2},
+	}
+
+	ranked := model.RankRecommendations(recs, 2)
+
+	assert.Len(t, ranked, 2)
+	assert.Equal(t, "C", ranked[0].Movie.Title)
+	assert.Equal(t, "A", ranked[1].Movie.Title)
+}
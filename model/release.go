@@ -0,0 +1,179 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Release types a movie can come out as.
+const (
+	ReleasePremiere   = "premiere"
+	ReleaseFestival   = "festival"
+	ReleaseTheatrical = "theatrical"
+	ReleaseDigital    = "digital"
+	ReleasePhysical   = "physical"
+	ReleaseTV         = "tv"
+)
+
+var releaseTypes = map[string]bool{
+	ReleasePremiere:   true,
+	ReleaseFestival:   true,
+	ReleaseTheatrical: true,
+	ReleaseDigital:    true,
+	ReleasePhysical:   true,
+	ReleaseTV:         true,
+}
+
+// Release is a movie coming out in one country. Certification is the local
+// age rating, such as PG-13 in the US or FSK 12 in Germany, and MinAge the
+// age it requires, worked out when the release is stored.
+type Release struct {
+	Country       string    `bson:"country" json:"country"`
+	Date          time.Time `bson:"date" json:"date"`
+	Type          string    `bson:"type" json:"type"`
+	Certification string    `bson:"certification,omitempty" json:"certification,omitempty"`
+	MinAge        int       `bson:"min_age" json:"min_age,omitempty"`
+}
+
+type releaseJSON struct {
+	Country       string `json:"country"`
+	Date          string `json:"date"`
+	Type          string `json:"type"`
+	Certification string `json:"certification,omitempty"`
+	MinAge        int    `json:"min_age,omitempty"`
+}
+
+// MarshalJSON writes the date as yyyy-mm-dd, a release has no time of day.
+func (r Release) MarshalJSON() ([]byte, error) {
+	return json.Marshal(releaseJSON{
+		Country:       r.Country,
+		Date:          r.Date.UTC().Format("2006-01-02"),
+		Type:          r.Type,
+		Certification: r.Certification,
+		MinAge:        r.MinAge,
+	})
+}
+
+func (r *Release) UnmarshalJSON(data []byte) error {
+	var raw releaseJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	date, err := time.Parse("2006-01-02", raw.Date)
+	if err != nil {
+		return invalidDocument("release date must be yyyy-mm-dd")
+	}
+	*r = Release{Country: raw.Country, Date: date, Type: raw.Type, Certification: raw.Certification}
+	return nil
+}
+
+// NormalizeCountry validates an ISO 3166-1 alpha-2 country code and returns
+// it upper-cased.
+func NormalizeCountry(code string) (string, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 2 {
+		return "", false
+	}
+	region, err := language.ParseRegion(code)
+	if err != nil || !region.IsCountry() {
+		return "", false
+	}
+	return code, true
+}
+
+// NormalizeReleases validates the releases and sorts them by date. A release
+// without a type is theatrical. Each country can have one release per type.
+func NormalizeReleases(releases []Release) ([]Release, error) {
+	out := make([]Release, 0, len(releases))
+	seen := map[string]bool{}
+	for i, r := range releases {
+		country, ok := NormalizeCountry(r.Country)
+		if !ok {
+			return nil, invalidDocument("releases[%d] has unknown country %q", i, r.Country)
+		}
+		if r.Date.IsZero() {
+			return nil, invalidDocument("releases[%d] has no date", i)
+		}
+		kind := strings.ToLower(strings.TrimSpace(r.Type))
+		if kind == "" {
+			kind = ReleaseTheatrical
+		}
+		if !releaseTypes[kind] {
+			return nil, invalidDocument("releases[%d] has unknown type %q", i, r.Type)
+		}
+		if seen[country+"/"+kind] {
+			return nil, invalidDocument("releases[%d] repeats the %s release in %s", i, kind, country)
+		}
+		seen[country+"/"+kind] = true
+
+		certification := strings.TrimSpace(r.Certification)
+		out = append(out, Release{
+			Country:       country,
+			Date:          time.Date(r.Date.Year(), r.Date.Month(), r.Date.Day(), 0, 0, 0, 0, time.UTC),
+			Type:          kind,
+			Certification: certification,
+			MinAge:        CertificationMinAge(country, certification),
+		})
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Date.Equal(out[j].Date) {
+			return out[i].Date.Before(out[j].Date)
+		}
+		return out[i].Country < out[j].Country
+	})
+	return out, nil
+}
+
+// SetReleases stores normalized releases and derives the release year from
+// the earliest of them. Without releases the release year is left alone.
+func (m *Movie) SetReleases(releases []Release) {
+	m.Releases = releases
+	if len(releases) > 0 {
+		m.ReleaseYear = releases[0].Date.Year()
+	}
+}
+
+// LocalRelease is the release audiences in the country got. Festival
+// screenings and premieres only count when nothing else came out there. The
+// certification falls back to any other release in the country that has one.
+// It returns nil when the movie was not released in the country.
+func LocalRelease(releases []Release, country string) *Release {
+	var local, screening *Release
+	certification := ""
+	for i := range releases {
+		r := &releases[i]
+		if r.Country != country {
+			continue
+		}
+		if certification == "" {
+			certification = r.Certification
+		}
+		if r.Type == ReleaseFestival || r.Type == ReleasePremiere {
+			if screening == nil {
+				screening = r
+			}
+			continue
+		}
+		if local == nil {
+			local = r
+		}
+	}
+	if local == nil {
+		local = screening
+	}
+	if local == nil {
+		return nil
+	}
+
+	result := *local
+	if result.Certification == "" {
+		result.Certification = certification
+		result.MinAge = CertificationMinAge(country, certification)
+	}
+	return &result
+}
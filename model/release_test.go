@@ -0,0 +1,71 @@
+package model_test
+
+import (
+	"encoding/json"
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestNormalizeReleasesDerivesReleaseYear(t *testing.T) {
+	releases, err := model.NormalizeReleases([]model.Release{
+		{Country: "us", Date: day("2010-07-16"), Certification: "PG-13"},
+		{Country: "FR", Date: day("2010-05-13"), Type: "Festival"},
+		{Country: "de", Date: day("2010-07-29"), Type: "theatrical", Certification: "FSK 12"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "FR", releases[0].Country)
+	assert.Equal(t, model.ReleaseTheatrical, releases[1].Type)
+
+	movie := &model.Movie{ReleaseYear: 2011}
+	movie.SetReleases(releases)
+	assert.Equal(t, 2010, movie.ReleaseYear)
+}
+
+func TestNormalizeReleasesRejectsBadEntries(t *testing.T) {
+	_, err := model.NormalizeReleases([]model.Release{{Country: "XX", Date: day("2010-01-01")}})
+	assert.ErrorIs(t, err, model.ErrInvalidDocument)
+
+	_, err = model.NormalizeReleases([]model.Release{{Country: "US", Date: day("2010-01-01"), Type: "drive-in"}})
+	assert.ErrorIs(t, err, model.ErrInvalidDocument)
+
+	_, err = model.NormalizeReleases([]model.Release{
+		{Country: "US", Date: day("2010-01-01")},
+		{Country: "us", Date: day("2010-02-01")},
+	})
+	assert.ErrorIs(t, err, model.ErrInvalidDocument)
+}
+
+func TestLocalRelease(t *testing.T) {
+	releases := []model.Release{
+		{Country: "DE", Date: day("2010-02-11"), Type: model.ReleaseFestival},
+		{Country: "DE", Date: day("2010-07-29"), Type: model.ReleaseTheatrical},
+		{Country: "DE", Date: day("2010-12-02"), Type: model.ReleasePhysical, Certification: "FSK 12"},
+		{Country: "JP", Date: day("2010-05-01"), Type: model.ReleasePremiere},
+	}
+
+	local := model.LocalRelease(releases, "DE")
+	require.NotNil(t, local)
+	assert.Equal(t, day("2010-07-29"), local.Date)
+	assert.Equal(t, "FSK 12", local.Certification)
+
+	assert.Equal(t, model.ReleasePremiere, model.LocalRelease(releases, "JP").Type)
+	assert.Nil(t, model.LocalRelease(releases, "US"))
+}
+
+func TestReleaseJSONUsesPlainDates(t *testing.T) {
+	data, err := json.Marshal(model.Release{Country: "US", Date: day("2010-07-16"), Type: model.ReleaseDigital})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"country":"US","date":"2010-07-16","type":"digital"}`, string(data))
+
+	var r model.Release
+	assert.Error(t, json.Unmarshal([]byte(`{"country":"US","date":"16/07/2010"}`), &r))
+}
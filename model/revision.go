@@ -0,0 +1,132 @@
+package model
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RevisionCreate = "create"
+	RevisionUpdate = "update"
+	RevisionDelete = "delete"
+	RevisionRevert = "revert"
+	// RevisionRestore is a document coming back out of the trash.
+	RevisionRestore = "restore"
+
+	EntityMovie  = "movie"
+	EntityPerson = "person"
+	EntityUser   = "user"
+
+	EntityCollection = "collection"
+	EntityAward      = "award"
+	EntitySeries     = "series"
+
+	EntityOrganization = "organization"
+)
+
+type FieldChange struct {
+	Field  string      `bson:"field" json:"field"`
+	Before interface{} `bson:"before" json:"before"`
+	After  interface{} `bson:"after" json:"after"`
+}
+
+// Revision records one write to a catalog entity or a user. Snapshot holds the
+// document as it was after the write, or just before it for deletes, and is
+// what a revert restores.
+type Revision struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Entity   string             `bson:"entity" json:"entity"`
+	EntityID primitive.ObjectID `bson:"entity_id" json:"entity_id"`
+	Action   string             `bson:"action" json:"action"`
+	Editor   string             `bson:"editor" json:"editor"`
+	At       time.Time          `bson:"at" json:"at"`
+	Changes  []FieldChange      `bson:"changes" json:"changes"`
+	Snapshot bson.M             `bson:"snapshot" json:"snapshot"`
+}
+
+// ToDocument turns a model into the bson.M form that is stored, so that
+// snapshots and diffs use the same field names as the collections.
+func ToDocument(v interface{}) (bson.M, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// DiffDocuments lists the top-level fields that differ between two documents,
+// sorted by name. A nil document counts as empty, so creates and deletes list
+// every field.
+func DiffDocuments(before, after bson.M) []FieldChange {
+	fields := map[string]bool{}
+	for k := range before {
+		fields[k] = true
+	}
+	for k := range after {
+		fields[k] = true
+	}
+	delete(fields, "_id")
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, name := range names {
+		b, inBefore := before[name]
+		a, inAfter := after[name]
+		if inBefore && inAfter && sameValue(b, a) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Before: b, After: a})
+	}
+	return changes
+}
+
+func sameValue(a, b interface{}) bool {
+	ra, errA := bson.Marshal(bson.D{{Key: "v", Value: a}})
+	rb, errB := bson.Marshal(bson.D{{Key: "v", Value: b}})
+	return errA == nil && errB == nil && bytes.Equal(ra, rb)
+}
+
+// ImageKeys lists the stored files of every image set the revision holds
+// under field, in its snapshot and on either side of its changes.
+func (r *Revision) ImageKeys(field string) []string {
+	values := []interface{}{r.Snapshot[field]}
+	for _, c := range r.Changes {
+		if c.Field == field {
+			values = append(values, c.Before, c.After)
+		}
+	}
+	var keys []string
+	for _, v := range values {
+		if v == nil {
+			continue
+		}
+		raw, err := bson.Marshal(bson.M{"set": v})
+		if err != nil {
+			continue
+		}
+		var doc struct {
+			Set *ImageSet `bson:"set"`
+		}
+		if bson.Unmarshal(raw, &doc) != nil {
+			continue
+		}
+		keys = append(keys, doc.Set.Keys()...)
+	}
+	return keys
+}
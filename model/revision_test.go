@@ -0,0 +1,54 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestDiffDocuments_ListsChangedFieldsOnly(t *testing.T) {
+	actor := primitive.NewObjectID()
+	before, err := model.ToDocument(&model.Movie{Title: "Heat", ReleaseYear: 1995, Actors: []primitive.ObjectID{actor}})
+	require.NoError(t, err)
+	after, err := model.ToDocument(&model.Movie{Title: "Heat", ReleaseYear: 1996, Actors: []primitive.ObjectID{actor}})
+	require.NoError(t, err)
+
+	changes := model.DiffDocuments(before, after)
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, "release_year", changes[0].Field)
+	assert.EqualValues(t, 1995, changes[0].Before)
+	assert.EqualValues(t, 1996, changes[0].After)
+}
+
+func TestDiffDocuments_CreateListsEveryFieldButID(t *testing.T) {
+	after := bson.M{"_id": primitive.NewObjectID(), "first_name": "Al", "last_name": "Pacino"}
+
+	changes := model.DiffDocuments(nil, after)
+
+	require.Len(t, changes, 2)
+	assert.Equal(t, "first_name", changes[0].Field)
+	assert.Nil(t, changes[0].Before)
+	assert.Equal(t, "last_name", changes[1].Field)
+}
+
+func TestRevisionImageKeys_ReadsSnapshotAndChanges(t *testing.T) {
+	old := &model.ImageSet{Original: model.Image{Key: "old.jpg"}, Thumbnails: map[string]model.Image{"200": {Key: "old-200.jpg"}}}
+	current := &model.ImageSet{Original: model.Image{Key: "new.jpg"}}
+	snapshot, err := model.ToDocument(&model.Movie{Title: "Heat", Poster: current})
+	require.NoError(t, err)
+	oldDoc, err := model.ToDocument(old)
+	require.NoError(t, err)
+
+	revision := model.Revision{
+		Snapshot: snapshot,
+		Changes:  []model.FieldChange{{Field: "poster", Before: oldDoc, After: snapshot["poster"]}},
+	}
+
+	assert.ElementsMatch(t, []string{"new.jpg", "old.jpg", "old-200.jpg", "new.jpg"}, revision.ImageKeys("poster"))
+	assert.Empty(t, revision.ImageKeys("headshot"))
+}
@@ -0,0 +1,425 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Title types told apart in person credit lists.
+const (
+	TitleMovie  = "movie"
+	TitleSeries = "series"
+)
+
+const JobGuestStar = "Guest Star"
+
+var (
+	ErrSeasonNotFound  = errors.New("season not found with given number")
+	ErrEpisodeNotFound = errors.New("episode not found with given number")
+)
+
+// Series is a TV show. Crew holds the people credited on the whole show,
+// such as the main cast and its creators; everybody who only worked on some
+// episodes is credited on those. Seasons are kept in order of their number.
+type Series struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title    string             `bson:"title" json:"title"`
+	Overview string             `bson:"overview,omitempty" json:"overview"`
+	Genres   []string           `bson:"genres,omitempty" json:"genres"`
+	Crew     []CrewMember       `bson:"crew" json:"crew"`
+	Seasons  []Season           `bson:"seasons" json:"seasons"`
+	Version  int64              `bson:"version" json:"version"`
+}
+
+type Season struct {
+	Number   int       `bson:"number" json:"number"`
+	Title    string    `bson:"title,omitempty" json:"title,omitempty"`
+	Overview string    `bson:"overview,omitempty" json:"overview,omitempty"`
+	Episodes []Episode `bson:"episodes" json:"episodes"`
+}
+
+// Episode has its own crew for guest stars, directors and writers. Runtime
+// is in minutes; AirDate is nil until the episode is scheduled.
+type Episode struct {
+	Number   int          `bson:"number" json:"number"`
+	Title    string       `bson:"title" json:"title"`
+	Overview string       `bson:"overview,omitempty" json:"overview,omitempty"`
+	AirDate  *time.Time   `bson:"air_date,omitempty" json:"air_date"`
+	Runtime  int          `bson:"runtime,omitempty" json:"runtime"`
+	Crew     []CrewMember `bson:"crew" json:"crew"`
+}
+
+type episodeJSON struct {
+	Number   int          `json:"number"`
+	Title    string       `json:"title"`
+	Overview string       `json:"overview,omitempty"`
+	AirDate  *string      `json:"air_date"`
+	Runtime  int          `json:"runtime"`
+	Crew     []CrewMember `json:"crew"`
+}
+
+// MarshalJSON writes the air date as yyyy-mm-dd.
+func (e Episode) MarshalJSON() ([]byte, error) {
+	raw := episodeJSON{
+		Number:   e.Number,
+		Title:    e.Title,
+		Overview: e.Overview,
+		Runtime:  e.Runtime,
+		Crew:     e.Crew,
+	}
+	if e.AirDate != nil {
+		date := e.AirDate.UTC().Format("2006-01-02")
+		raw.AirDate = &date
+	}
+	return json.Marshal(raw)
+}
+
+func (e *Episode) UnmarshalJSON(data []byte) error {
+	var raw episodeJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*e = Episode{
+		Number:   raw.Number,
+		Title:    raw.Title,
+		Overview: raw.Overview,
+		Runtime:  raw.Runtime,
+		Crew:     raw.Crew,
+	}
+	if raw.AirDate != nil && *raw.AirDate != "" {
+		date, err := time.Parse("2006-01-02", *raw.AirDate)
+		if err != nil {
+			return invalidDocument("air_date must be yyyy-mm-dd")
+		}
+		e.AirDate = &date
+	}
+	return nil
+}
+
+// Normalize checks the series and everything in it and puts seasons and
+// episodes in order.
+func (s *Series) Normalize() error {
+	s.Title = strings.TrimSpace(s.Title)
+	if s.Title == "" {
+		return invalidDocument("title is required")
+	}
+	s.Genres = NormalizeGenres(s.Genres)
+	crew, err := normalizeCrew(s.Crew)
+	if err != nil {
+		return err
+	}
+	s.Crew = crew
+	if s.Seasons == nil {
+		s.Seasons = []Season{}
+	}
+	seen := map[int]bool{}
+	for i := range s.Seasons {
+		if seen[s.Seasons[i].Number] {
+			return invalidDocument("season %d is listed twice", s.Seasons[i].Number)
+		}
+		seen[s.Seasons[i].Number] = true
+		if err := s.Seasons[i].Normalize(); err != nil {
+			return err
+		}
+	}
+	sort.Slice(s.Seasons, func(i, j int) bool { return s.Seasons[i].Number < s.Seasons[j].Number })
+	return nil
+}
+
+func (s *Season) Normalize() error {
+	if s.Number < 0 {
+		return invalidDocument("season number cannot be negative")
+	}
+	s.Title = strings.TrimSpace(s.Title)
+	if s.Episodes == nil {
+		s.Episodes = []Episode{}
+	}
+	seen := map[int]bool{}
+	for i := range s.Episodes {
+		if seen[s.Episodes[i].Number] {
+			return invalidDocument("episode %d of season %d is listed twice", s.Episodes[i].Number, s.Number)
+		}
+		seen[s.Episodes[i].Number] = true
+		if err := s.Episodes[i].Normalize(); err != nil {
+			return err
+		}
+	}
+	sort.Slice(s.Episodes, func(i, j int) bool { return s.Episodes[i].Number < s.Episodes[j].Number })
+	return nil
+}
+
+func (e *Episode) Normalize() error {
+	if e.Number <= 0 {
+		return invalidDocument("episode number must be positive")
+	}
+	if e.Runtime < 0 {
+		return invalidDocument("runtime cannot be negative")
+	}
+	e.Title = strings.TrimSpace(e.Title)
+	crew, err := normalizeCrew(e.Crew)
+	if err != nil {
+		return err
+	}
+	e.Crew = crew
+	return nil
+}
+
+func normalizeCrew(crew []CrewMember) ([]CrewMember, error) {
+	out := make([]CrewMember, 0, len(crew))
+	for i, c := range crew {
+		if c.PersonID.IsZero() {
+			return nil, invalidDocument("crew[%d] has no person_id", i)
+		}
+		if !ValidDepartment(c.Department) {
+			return nil, invalidDocument("crew[%d] has unknown department %q", i, c.Department)
+		}
+		out = append(out, CrewMember{PersonID: c.PersonID, Department: c.Department, Job: strings.TrimSpace(c.Job)})
+	}
+	return out, nil
+}
+
+// FirstAired is the year of the earliest scheduled episode, 0 when none is.
+func (s *Series) FirstAired() int {
+	var first *time.Time
+	for _, season := range s.Seasons {
+		for _, e := range season.Episodes {
+			if e.AirDate != nil && (first == nil || e.AirDate.Before(*first)) {
+				first = e.AirDate
+			}
+		}
+	}
+	if first == nil {
+		return 0
+	}
+	return first.Year()
+}
+
+func (s *Series) Season(number int) (*Season, error) {
+	for i := range s.Seasons {
+		if s.Seasons[i].Number == number {
+			return &s.Seasons[i], nil
+		}
+	}
+	return nil, ErrSeasonNotFound
+}
+
+// PutSeason adds the season or replaces the one with the same number. A
+// replaced season keeps its episodes unless new ones are given.
+func (s *Series) PutSeason(season Season) error {
+	if existing, err := s.Season(season.Number); err == nil {
+		if season.Episodes == nil {
+			season.Episodes = existing.Episodes
+		}
+		if err := season.Normalize(); err != nil {
+			return err
+		}
+		*existing = season
+		return nil
+	}
+	if err := season.Normalize(); err != nil {
+		return err
+	}
+	s.Seasons = append(s.Seasons, season)
+	sort.Slice(s.Seasons, func(i, j int) bool { return s.Seasons[i].Number < s.Seasons[j].Number })
+	return nil
+}
+
+func (s *Series) RemoveSeason(number int) error {
+	for i := range s.Seasons {
+		if s.Seasons[i].Number == number {
+			s.Seasons = append(s.Seasons[:i], s.Seasons[i+1:]...)
+			return nil
+		}
+	}
+	return ErrSeasonNotFound
+}
+
+// NextSeason is the number a season added without one gets.
+func (s *Series) NextSeason() int {
+	if len(s.Seasons) == 0 {
+		return 1
+	}
+	return s.Seasons[len(s.Seasons)-1].Number + 1
+}
+
+func (s *Season) Episode(number int) (*Episode, error) {
+	for i := range s.Episodes {
+		if s.Episodes[i].Number == number {
+			return &s.Episodes[i], nil
+		}
+	}
+	return nil, ErrEpisodeNotFound
+}
+
+// PutEpisode adds the episode or replaces the one with the same number.
+func (s *Season) PutEpisode(episode Episode) error {
+	if err := episode.Normalize(); err != nil {
+		return err
+	}
+	if existing, err := s.Episode(episode.Number); err == nil {
+		*existing = episode
+		return nil
+	}
+	s.Episodes = append(s.Episodes, episode)
+	sort.Slice(s.Episodes, func(i, j int) bool { return s.Episodes[i].Number < s.Episodes[j].Number })
+	return nil
+}
+
+func (s *Season) RemoveEpisode(number int) error {
+	for i := range s.Episodes {
+		if s.Episodes[i].Number == number {
+			s.Episodes = append(s.Episodes[:i], s.Episodes[i+1:]...)
+			return nil
+		}
+	}
+	return ErrEpisodeNotFound
+}
+
+// NextEpisode is the number an episode added without one gets.
+func (s *Season) NextEpisode() int {
+	if len(s.Episodes) == 0 {
+		return 1
+	}
+	return s.Episodes[len(s.Episodes)-1].Number + 1
+}
+
+// People lists everybody credited on the series or one of its episodes.
+func (s *Series) People() []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, c := range s.Crew {
+		ids = append(ids, c.PersonID)
+	}
+	for _, season := range s.Seasons {
+		for _, e := range season.Episodes {
+			for _, c := range e.Crew {
+				ids = append(ids, c.PersonID)
+			}
+		}
+	}
+	return uniqueIDs(ids)
+}
+
+// SeriesUpdate is the body of a PUT on a series. Fields left out keep their
+// value. Seasons are changed through their own endpoints.
+type SeriesUpdate struct {
+	Title    *string      `json:"title"`
+	Overview *string      `json:"overview"`
+	Genres   []string     `json:"genres"`
+	Crew     []CrewMember `json:"crew"`
+}
+
+// Document turns the update into the fields to set.
+func (u *SeriesUpdate) Document() (bson.M, error) {
+	update := bson.M{}
+	if u.Title != nil {
+		title := strings.TrimSpace(*u.Title)
+		if title == "" {
+			return nil, invalidDocument("title is required")
+		}
+		update["title"] = title
+	}
+	if u.Overview != nil {
+		update["overview"] = strings.TrimSpace(*u.Overview)
+	}
+	if u.Genres != nil {
+		update["genres"] = NormalizeGenres(u.Genres)
+	}
+	if u.Crew != nil {
+		crew, err := normalizeCrew(u.Crew)
+		if err != nil {
+			return nil, err
+		}
+		update["crew"] = crew
+	}
+	return update, nil
+}
+
+// Credit is one title a person worked on, a movie or a series as Type
+// tells. For series Episodes lists the episodes they are credited on
+// without being part of the whole show.
+type Credit struct {
+	Type     string             `json:"type"`
+	ID       primitive.ObjectID `json:"id"`
+	Title    string             `json:"title"`
+	Year     int                `json:"year"`
+	Jobs     []string           `json:"jobs"`
+	Episodes []EpisodeCredit    `json:"episodes,omitempty"`
+}
+
+type EpisodeCredit struct {
+	Season  int      `json:"season"`
+	Episode int      `json:"episode"`
+	Title   string   `json:"title"`
+	Jobs    []string `json:"jobs"`
+}
+
+// MovieCredit describes the person's work in one department on a movie.
+// Movies stored before crew lists existed only name the director and cast.
+func MovieCredit(m *Movie, personID primitive.ObjectID, department string) Credit {
+	credit := Credit{Type: TitleMovie, ID: m.ID, Title: m.Title, Year: m.ReleaseYear}
+	credit.Jobs = creditedJobs(m.Crew, personID, department)
+	if len(credit.Jobs) == 0 {
+		switch {
+		case department == DepartmentDirecting && m.DirectorID == personID:
+			credit.Jobs = []string{JobDirector}
+		case department == DepartmentActing:
+			credit.Jobs = []string{JobActor}
+		}
+	}
+	return credit
+}
+
+// SeriesCredit describes the person's work in one department on a series:
+// the jobs on the whole show and the episodes they are credited on.
+func SeriesCredit(s *Series, personID primitive.ObjectID, department string) Credit {
+	credit := Credit{
+		Type:  TitleSeries,
+		ID:    s.ID,
+		Title: s.Title,
+		Year:  s.FirstAired(),
+		Jobs:  creditedJobs(s.Crew, personID, department),
+	}
+	for _, season := range s.Seasons {
+		for _, e := range season.Episodes {
+			if jobs := creditedJobs(e.Crew, personID, department); len(jobs) > 0 {
+				credit.Episodes = append(credit.Episodes, EpisodeCredit{
+					Season:  season.Number,
+					Episode: e.Number,
+					Title:   e.Title,
+					Jobs:    jobs,
+				})
+			}
+		}
+	}
+	return credit
+}
+
+func creditedJobs(crew []CrewMember, personID primitive.ObjectID, department string) []string {
+	jobs := []string{}
+	for _, c := range crew {
+		if c.PersonID == personID && c.Department == department {
+			jobs = append(jobs, c.Job)
+		}
+	}
+	return jobs
+}
+
+// SortCredits puts the newest titles first, titles without a year last.
+func SortCredits(credits []Credit) {
+	sort.SliceStable(credits, func(i, j int) bool {
+		a, b := credits[i], credits[j]
+		if (a.Year == 0) != (b.Year == 0) {
+			return b.Year == 0
+		}
+		if a.Year != b.Year {
+			return a.Year > b.Year
+		}
+		return a.Title < b.Title
+	})
+}
@@ -0,0 +1,89 @@
+package model_test
+
+import (
+	"encoding/json"
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSeriesPutSeasonKeepsEpisodes(t *testing.T) {
+	series := &model.Series{Title: "Show"}
+	require.NoError(t, series.PutSeason(model.Season{Number: 2}))
+	require.NoError(t, series.PutSeason(model.Season{Number: 1, Episodes: []model.Episode{{Number: 2}, {Number: 1}}}))
+	assert.Equal(t, 1, series.Seasons[0].Number)
+	assert.Equal(t, 1, series.Seasons[0].Episodes[0].Number)
+	assert.Equal(t, 3, series.NextSeason())
+
+	require.NoError(t, series.PutSeason(model.Season{Number: 1, Title: "Pilot season"}))
+	season, err := series.Season(1)
+	require.NoError(t, err)
+	assert.Equal(t, "Pilot season", season.Title)
+	assert.Len(t, season.Episodes, 2)
+
+	assert.ErrorIs(t, series.RemoveSeason(5), model.ErrSeasonNotFound)
+}
+
+func TestSeasonPutEpisodeValidates(t *testing.T) {
+	season := &model.Season{Number: 1}
+	assert.ErrorIs(t, season.PutEpisode(model.Episode{Number: 0}), model.ErrInvalidDocument)
+	assert.ErrorIs(t, season.PutEpisode(model.Episode{Number: 1, Runtime: -5}), model.ErrInvalidDocument)
+	assert.ErrorIs(t, season.PutEpisode(model.Episode{
+		Number: 1,
+		Crew:   []model.CrewMember{{PersonID: primitive.NewObjectID(), Department: "catering"}},
+	}), model.ErrInvalidDocument)
+
+	require.NoError(t, season.PutEpisode(model.Episode{Number: 3}))
+	require.NoError(t, season.PutEpisode(model.Episode{Number: 1}))
+	assert.Equal(t, 4, season.NextEpisode())
+	assert.ErrorIs(t, season.RemoveEpisode(2), model.ErrEpisodeNotFound)
+}
+
+func TestEpisodeAirDateJSON(t *testing.T) {
+	var episode model.Episode
+	require.NoError(t, json.Unmarshal([]byte(`{"number":1,"title":"Pilot","air_date":"2008-01-20","runtime":58}`), &episode))
+	require.NotNil(t, episode.AirDate)
+	assert.Equal(t, 2008, episode.AirDate.Year())
+
+	out, err := json.Marshal(episode)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"air_date":"2008-01-20"`)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"number":1,"air_date":"20 Jan 2008"}`), &episode))
+}
+
+func TestCreditsCoverMoviesAndSeries(t *testing.T) {
+	person := primitive.NewObjectID()
+	movie := &model.Movie{ID: primitive.NewObjectID(), Title: "Film", ReleaseYear: 2005, Actors: []primitive.ObjectID{person}}
+	series := &model.Series{ID: primitive.NewObjectID(), Title: "Show", Seasons: []model.Season{{
+		Number: 1,
+		Episodes: []model.Episode{
+			{Number: 1, AirDate: ptrTime(day("2010-03-01"))},
+			{Number: 2, Title: "Guest", Crew: []model.CrewMember{
+				{PersonID: person, Department: model.DepartmentActing, Job: model.JobGuestStar},
+			}},
+		},
+	}}}
+
+	credits := []model.Credit{
+		model.MovieCredit(movie, person, model.DepartmentActing),
+		model.SeriesCredit(series, person, model.DepartmentActing),
+	}
+	model.SortCredits(credits)
+
+	require.Len(t, credits, 2)
+	assert.Equal(t, model.TitleSeries, credits[0].Type)
+	assert.Equal(t, 2010, credits[0].Year)
+	require.Len(t, credits[0].Episodes, 1)
+	assert.Equal(t, []string{model.JobGuestStar}, credits[0].Episodes[0].Jobs)
+	assert.Equal(t, model.TitleMovie, credits[1].Type)
+	assert.Equal(t, []string{model.JobActor}, credits[1].Jobs)
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SlugMatch is what a slug resolves to. Alias is set when the slug is one
+// the document had before it was renamed, in which case Slug holds the
+// current one.
+type SlugMatch struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Slug  string             `bson:"slug"`
+	Alias bool               `bson:"-"`
+}
+
+var stripMarks = transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// Slugify turns text into lowercase ASCII words joined by dashes, dropping
+// accents on the way, so "Amélie" becomes "amelie". Text without any letters
+// or digits left gives an empty slug.
+func Slugify(text string) string {
+	plain, _, err := transform.String(stripMarks, text)
+	if err != nil {
+		plain = text
+	}
+
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(plain) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// MovieSlug is the slug a movie starts from, its title followed by the
+// release year.
+func MovieSlug(title string, year int) string {
+	slug := Slugify(title)
+	if slug == "" {
+		slug = "movie"
+	}
+	if year > 0 {
+		slug += "-" + strconv.Itoa(year)
+	}
+	return slug
+}
+
+// PersonSlug is the slug a person starts from, their full name.
+func PersonSlug(firstName, lastName string) string {
+	slug := Slugify(firstName + " " + lastName)
+	if slug == "" {
+		slug = "person"
+	}
+	return slug
+}
+
+// NumberedSlug is the nth candidate for a base slug: the base itself first,
+// then base-2, base-3 and so on for collisions.
+func NumberedSlug(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	return base + "-" + strconv.Itoa(n)
+}
+
+// SlugHasBase reports whether slug was made from base, either as is or with
+// a collision suffix.
+func SlugHasBase(slug, base string) bool {
+	if slug == base {
+		return true
+	}
+	suffix, ok := strings.CutPrefix(slug, base+"-")
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(suffix)
+	return err == nil && n > 1 && strconv.Itoa(n) == suffix
+}
+
+// LooksLikeID reports whether a path segment is an ObjectID rather than a
+// slug. Slugs that would look like one are never handed out.
+func LooksLikeID(segment string) bool {
+	_, err := primitive.ObjectIDFromHex(segment)
+	return err == nil
+}
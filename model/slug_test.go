@@ -0,0 +1,40 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "amelie", model.Slugify("Amélie"))
+	assert.Equal(t, "the-good-the-bad-and-the-ugly", model.Slugify("  The Good, the Bad and the Ugly "))
+	assert.Equal(t, "wall-e", model.Slugify("WALL·E"))
+	assert.Equal(t, "", model.Slugify("千と千尋"))
+}
+
+func TestMovieAndPersonSlugs(t *testing.T) {
+	assert.Equal(t, "heat-1995", model.MovieSlug("Heat", 1995))
+	assert.Equal(t, "movie-2001", model.MovieSlug("千と千尋", 2001))
+	assert.Equal(t, "untitled", model.MovieSlug("Untitled", 0))
+	assert.Equal(t, "penelope-cruz", model.PersonSlug("Penélope", "Cruz"))
+	assert.Equal(t, "person", model.PersonSlug("", ""))
+}
+
+func TestSlugHasBase(t *testing.T) {
+	assert.Equal(t, "heat-1995", model.NumberedSlug("heat-1995", 1))
+	assert.Equal(t, "heat-1995-3", model.NumberedSlug("heat-1995", 3))
+
+	assert.True(t, model.SlugHasBase("heat-1995", "heat-1995"))
+	assert.True(t, model.SlugHasBase("heat-1995-3", "heat-1995"))
+	assert.False(t, model.SlugHasBase("heat-1995-1", "heat-1995"))
+	assert.False(t, model.SlugHasBase("heat-1995-03", "heat-1995"))
+	assert.False(t, model.SlugHasBase("heat-1995-x", "heat-1995"))
+	assert.False(t, model.SlugHasBase("heat-1996", "heat-1995"))
+}
+
+func TestLooksLikeID(t *testing.T) {
+	assert.True(t, model.LooksLikeID("692035ff46a473472ef22f5b"))
+	assert.False(t, model.LooksLikeID("heat-1995"))
+}
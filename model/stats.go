@@ -0,0 +1,92 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Growth intervals accepted by the catalog growth statistics.
+const (
+	GrowthByMonth = "month"
+	GrowthByYear  = "year"
+)
+
+var ErrInvalidStatsFilter = errors.New("invalid statistics filter")
+
+// StatsFilter narrows the movies statistics are computed over. AddedFrom and
+// AddedTo bound when a movie was added to the catalog, FromYear and ToYear
+// its release year. Zero values leave that side open.
+type StatsFilter struct {
+	AddedFrom time.Time
+	AddedTo   time.Time
+	FromYear  int
+	ToYear    int
+}
+
+func (f StatsFilter) Validate() error {
+	if !f.AddedFrom.IsZero() && !f.AddedTo.IsZero() && f.AddedTo.Before(f.AddedFrom) {
+		return ErrInvalidStatsFilter
+	}
+	if f.FromYear < 0 || f.ToYear < 0 || (f.ToYear > 0 && f.ToYear < f.FromYear) {
+		return ErrInvalidStatsFilter
+	}
+	return nil
+}
+
+// YearCount is the number of movies released in a year, or in a decade when
+// it comes from the per-decade statistics.
+type YearCount struct {
+	Year  int `bson:"_id" json:"year"`
+	Count int `bson:"count" json:"count"`
+}
+
+type ReleaseStats struct {
+	Years   []YearCount `json:"years"`
+	Decades []YearCount `json:"decades"`
+}
+
+// ProlificPerson is somebody credited on many movies in one department.
+type ProlificPerson struct {
+	PersonID primitive.ObjectID `bson:"_id" json:"person_id"`
+	Movies   int                `bson:"movies" json:"movies"`
+	Person   *Person            `bson:"person" json:"person"`
+}
+
+// CastSizeStats describes how many actors movies credit. Movies without any
+// actor are counted too.
+type CastSizeStats struct {
+	Movies  int     `bson:"movies" json:"movies"`
+	Average float64 `bson:"average" json:"average"`
+	Min     int     `bson:"min" json:"min"`
+	Max     int     `bson:"max" json:"max"`
+}
+
+// Partnership is a director and an actor who worked on the same movies.
+type Partnership struct {
+	DirectorID primitive.ObjectID `bson:"director_id" json:"director_id"`
+	ActorID    primitive.ObjectID `bson:"actor_id" json:"actor_id"`
+	Movies     int                `bson:"movies" json:"movies"`
+	Director   *Person            `bson:"director" json:"director"`
+	Actor      *Person            `bson:"actor" json:"actor"`
+}
+
+// GrowthPoint is the number of movies added in one period and the size of
+// the catalog at its end.
+type GrowthPoint struct {
+	Period string `bson:"_id" json:"period"`
+	Added  int    `bson:"added" json:"added"`
+	Total  int    `bson:"-" json:"total"`
+}
+
+// AccumulateGrowth fills in the running totals of points sorted by period,
+// starting from the movies that were already in the catalog before.
+func AccumulateGrowth(points []GrowthPoint, before int) []GrowthPoint {
+	total := before
+	for i := range points {
+		total += points[i].Added
+		points[i].Total = total
+	}
+	return points
+}
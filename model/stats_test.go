@@ -0,0 +1,28 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsFilterValidate(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, model.StatsFilter{}.Validate())
+	assert.NoError(t, model.StatsFilter{AddedFrom: day, AddedTo: day.AddDate(0, 1, 0), FromYear: 1990}.Validate())
+	assert.ErrorIs(t, model.StatsFilter{AddedFrom: day, AddedTo: day.AddDate(0, 0, -1)}.Validate(), model.ErrInvalidStatsFilter)
+	assert.ErrorIs(t, model.StatsFilter{FromYear: 2000, ToYear: 1990}.Validate(), model.ErrInvalidStatsFilter)
+}
+
+func TestAccumulateGrowth(t *testing.T) {
+	points := model.AccumulateGrowth([]model.GrowthPoint{
+		{Period: "2024-01", Added: 3},
+		{Period: "2024-02", Added: 0},
+		{Period: "2024-03", Added: 2},
+	}, 10)
+
+	assert.Equal(t, []int{13, 13, 15}, []int{points[0].Total, points[1].Total, points[2].Total})
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// What can be in the trash. Actors and directors are people, so they are
+// listed and restored as such.
+const (
+	TrashMovie  = "movie"
+	TrashPerson = "person"
+	TrashUser   = "user"
+)
+
+func ValidTrashKind(kind string) bool {
+	return kind == TrashMovie || kind == TrashPerson || kind == TrashUser
+}
+
+// ErrMissingReferences is returned when a movie in the trash credits people
+// who are deleted themselves or gone for good, so restoring it would leave
+// broken credits.
+var ErrMissingReferences = errors.New("movie credits people who no longer exist")
+
+// TrashItem is one deleted document as the trash lists it. PurgeAt is when
+// the purge job removes it for good.
+type TrashItem struct {
+	Kind        string             `json:"kind"`
+	ID          primitive.ObjectID `json:"id"`
+	Name        string             `json:"name"`
+	Departments []string           `json:"departments,omitempty"`
+	DeletedAt   time.Time          `json:"deleted_at"`
+	DeletedBy   string             `json:"deleted_by"`
+	PurgeAt     time.Time          `json:"purge_at"`
+}
+
+func trashItem(
+	kind string,
+	id primitive.ObjectID,
+	name string,
+	deletedAt *time.Time,
+	by string,
+	retention time.Duration,
+) TrashItem {
+	item := TrashItem{Kind: kind, ID: id, Name: name, DeletedBy: by}
+	if deletedAt != nil {
+		item.DeletedAt = *deletedAt
+		item.PurgeAt = deletedAt.Add(retention)
+	}
+	return item
+}
+
+func (m *Movie) TrashItem(retention time.Duration) TrashItem {
+	return trashItem(TrashMovie, m.ID, m.Title, m.DeletedAt, m.DeletedBy, retention)
+}
+
+func (p *Person) TrashItem(retention time.Duration) TrashItem {
+	item := trashItem(TrashPerson, p.ID, p.FullName(), p.DeletedAt, p.DeletedBy, retention)
+	item.Departments = p.Departments
+	return item
+}
+
+func (u *User) TrashItem(retention time.Duration) TrashItem {
+	return trashItem(TrashUser, u.ObjectID, u.Email, u.DeletedAt, u.DeletedBy, retention)
+}
+
+// SortTrash puts the items that are purged first at the top.
+func SortTrash(items []TrashItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].DeletedAt.Before(items[j].DeletedAt)
+	})
+}
+
+// PeopleIDs lists everybody the movie credits, as director, in the cast or
+// in the crew, once each.
+func (m *Movie) PeopleIDs() []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	seen := map[primitive.ObjectID]bool{}
+	add := func(id primitive.ObjectID) {
+		if !id.IsZero() && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	add(m.DirectorID)
+	for _, id := range m.Actors {
+		add(id)
+	}
+	for _, c := range m.Crew {
+		add(c.PersonID)
+	}
+	return ids
+}
+
+// DropPeople removes the credits of the given people, as is done when a
+// movie is restored on purpose without the people it lost.
+func (m *Movie) DropPeople(gone map[primitive.ObjectID]bool) {
+	m.SyncCrew()
+	crew := make([]CrewMember, 0, len(m.Crew))
+	for _, c := range m.Crew {
+		if !gone[c.PersonID] {
+			crew = append(crew, c)
+		}
+	}
+	m.Crew = crew
+	m.SyncCrew()
+}
@@ -0,0 +1,44 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestTrashItemPurgeAt(t *testing.T) {
+	deleted := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	movie := &model.Movie{ID: primitive.NewObjectID(), Title: "Heat", DeletedAt: &deleted, DeletedBy: "admin@example.com"}
+	person := &model.Person{FirstName: "Al", LastName: "Pacino", Departments: []string{model.DepartmentActing}}
+
+	items := []model.TrashItem{movie.TrashItem(30 * 24 * time.Hour), person.TrashItem(time.Hour)}
+	assert.Equal(t, "Heat", items[0].Name)
+	assert.Equal(t, deleted.AddDate(0, 0, 30), items[0].PurgeAt)
+	assert.Equal(t, "Al Pacino", items[1].Name)
+	assert.True(t, items[1].PurgeAt.IsZero())
+
+	model.SortTrash(items)
+	assert.Equal(t, model.TrashPerson, items[0].Kind)
+}
+
+func TestMovieDropPeople(t *testing.T) {
+	director, kept, gone := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	movie := &model.Movie{DirectorID: director, Actors: []primitive.ObjectID{kept, gone}}
+
+	assert.ElementsMatch(t, []primitive.ObjectID{director, kept, gone}, movie.PeopleIDs())
+
+	movie.DropPeople(map[primitive.ObjectID]bool{director: true, gone: true})
+	assert.True(t, movie.DirectorID.IsZero())
+	assert.Equal(t, []primitive.ObjectID{kept}, movie.Actors)
+	require.Len(t, movie.Crew, 1)
+	assert.Equal(t, kept, movie.Crew[0].PersonID)
+}
+
+func TestValidTrashKind(t *testing.T) {
+	assert.True(t, model.ValidTrashKind(model.TrashMovie))
+	assert.False(t, model.ValidTrashKind("actor"))
+}
@@ -0,0 +1,11 @@
+package model
+
+import "errors"
+
+// AnyVersion is passed as the expected version when a write should go through
+// regardless of what the stored document looks like.
+const AnyVersion int64 = -1
+
+// ErrVersionMismatch is returned when a write names a version that is no
+// longer the stored one, i.e. somebody else changed the document first.
+var ErrVersionMismatch = errors.New("resource was modified by another request")
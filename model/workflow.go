@@ -0,0 +1,132 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Where a movie is in the editorial workflow. New movies start as drafts,
+// are submitted for review and only reach viewers once an admin publishes
+// them. Movies stored before the workflow existed have no status and count
+// as published.
+const (
+	StatusDraft     = "draft"
+	StatusInReview  = "in_review"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+// What can be done to a movie's status.
+const (
+	WorkflowSubmit  = "submit"
+	WorkflowApprove = "approve"
+	WorkflowReject  = "reject"
+	WorkflowArchive = "archive"
+	WorkflowReopen  = "reopen"
+)
+
+var (
+	ErrInvalidTransition   = errors.New("movie cannot take this step from its current status")
+	ErrTransitionForbidden = errors.New("role may not take this workflow step")
+)
+
+// workflowStep is one allowed transition and the roles that may take it.
+type workflowStep struct {
+	from  string
+	to    string
+	roles []string
+}
+
+// Editors move their drafts along, admins decide what gets published and
+// when it is taken down again.
+var workflowSteps = map[string]workflowStep{
+	WorkflowSubmit:  {StatusDraft, StatusInReview, []string{RoleEditor, RoleAdmin}},
+	WorkflowApprove: {StatusInReview, StatusPublished, []string{RoleAdmin}},
+	WorkflowReject:  {StatusInReview, StatusDraft, []string{RoleAdmin}},
+	WorkflowArchive: {StatusPublished, StatusArchived, []string{RoleAdmin}},
+	WorkflowReopen:  {StatusArchived, StatusDraft, []string{RoleEditor, RoleAdmin}},
+}
+
+// Transition asks to move a movie on in the workflow. Comment is required
+// when rejecting and PublishAt, when approving, schedules the publication
+// instead of publishing right away.
+type Transition struct {
+	Action    string     `json:"action"`
+	Comment   string     `json:"comment"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+// WorkflowStatus is the movie's status, published for movies stored before
+// there was one.
+func (m *Movie) WorkflowStatus() string {
+	if m.Status == "" {
+		return StatusPublished
+	}
+	return m.Status
+}
+
+// Published reports whether viewers may see the movie at the given time:
+// it has to be published and past its publish date.
+func (m *Movie) Published(now time.Time) bool {
+	if m.WorkflowStatus() != StatusPublished {
+		return false
+	}
+	return m.PublishAt == nil || !m.PublishAt.After(now)
+}
+
+// Apply takes the workflow step for a user with the given role. Rejections
+// leave their comment on the movie for the editor, any other step clears it.
+func (m *Movie) Apply(t Transition, role string, now time.Time) error {
+	step, ok := workflowSteps[t.Action]
+	if !ok {
+		return invalidDocument("action must be submit, approve, reject, archive or reopen")
+	}
+	if !containsString(step.roles, role) {
+		return ErrTransitionForbidden
+	}
+	if m.WorkflowStatus() != step.from {
+		return ErrInvalidTransition
+	}
+
+	if t.PublishAt != nil && t.Action != WorkflowApprove {
+		return invalidDocument("publish_at can only be given when approving")
+	}
+	comment := strings.TrimSpace(t.Comment)
+	if t.Action == WorkflowReject && comment == "" {
+		return invalidDocument("a rejection needs a comment")
+	}
+	if t.Action == WorkflowApprove {
+		publishAt := now
+		if t.PublishAt != nil && t.PublishAt.After(now) {
+			publishAt = *t.PublishAt
+		}
+		m.PublishAt = &publishAt
+	}
+
+	m.Status = step.to
+	m.ReviewComment = comment
+	return nil
+}
+
+// Redraft sends an edit of a published movie by anyone but an admin back
+// through review: the movie returns to draft, out of viewers' sight, until
+// an admin approves it again. It reports whether the movie was sent back.
+func (m *Movie) Redraft(role string) bool {
+	if role == RoleAdmin || m.WorkflowStatus() != StatusPublished {
+		return false
+	}
+	m.Status = StatusDraft
+	m.PublishAt = nil
+	m.ReviewComment = ""
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package model_test
+
+import (
+	"gin-demo/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovieApply(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	movie := &model.Movie{Status: model.StatusDraft}
+
+	assert.ErrorIs(t, movie.Apply(model.Transition{Action: model.WorkflowSubmit}, model.RoleViewer, now),
+		model.ErrTransitionForbidden)
+	assert.ErrorIs(t, movie.Apply(model.Transition{Action: model.WorkflowApprove}, model.RoleAdmin, now),
+		model.ErrInvalidTransition)
+	assert.ErrorIs(t, movie.Apply(model.Transition{Action: "publish"}, model.RoleAdmin, now),
+		model.ErrInvalidDocument)
+
+	assert.NoError(t, movie.Apply(model.Transition{Action: model.WorkflowSubmit}, model.RoleEditor, now))
+	assert.Equal(t, model.StatusInReview, movie.Status)
+
+	assert.ErrorIs(t, movie.Apply(model.Transition{Action: model.WorkflowApprove}, model.RoleEditor, now),
+		model.ErrTransitionForbidden)
+	assert.ErrorIs(t, movie.Apply(model.Transition{Action: model.WorkflowReject}, model.RoleAdmin, now),
+		model.ErrInvalidDocument)
+	assert.Equal(t, model.StatusInReview, movie.Status)
+
+	reject := model.Transition{Action: model.WorkflowReject, Comment: "needs a poster"}
+	assert.NoError(t, movie.Apply(reject, model.RoleAdmin, now))
+	assert.Equal(t, model.StatusDraft, movie.Status)
+	assert.Equal(t, "needs a poster", movie.ReviewComment)
+
+	assert.NoError(t, movie.Apply(model.Transition{Action: model.WorkflowSubmit}, model.RoleEditor, now))
+	assert.Empty(t, movie.ReviewComment)
+	assert.NoError(t, movie.Apply(model.Transition{Action: model.WorkflowApprove}, model.RoleAdmin, now))
+	assert.Equal(t, model.StatusPublished, movie.Status)
+	assert.Equal(t, now, *movie.PublishAt)
+}
+
+func TestMoviePublished(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	later := now.Add(48 * time.Hour)
+
+	assert.True(t, (&model.Movie{}).Published(now))
+	assert.False(t, (&model.Movie{Status: model.StatusDraft}).Published(now))
+
+	movie := &model.Movie{Status: model.StatusInReview}
+	approve := model.Transition{Action: model.WorkflowApprove, PublishAt: &later}
+	assert.NoError(t, movie.Apply(approve, model.RoleAdmin, now))
+	assert.False(t, movie.Published(now))
+	assert.True(t, movie.Published(later))
+}
+
+func TestAudienceAllowsPublishedOnly(t *testing.T) {
+	draft := &model.Movie{Status: model.StatusDraft}
+
+	assert.False(t, (&model.Audience{Unrestricted: true, Role: model.RoleViewer}).Allows(draft))
+	assert.True(t, (&model.Audience{Unrestricted: true, Role: model.RoleEditor}).Allows(draft))
+	assert.True(t, (&model.Audience{Role: model.RoleViewer}).Allows(&model.Movie{Status: model.StatusPublished}))
+}
+
+func TestMovieRedraft(t *testing.T) {
+	publishAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	published := model.Movie{Status: model.StatusPublished, PublishAt: &publishAt}
+
+	byAdmin := published
+	assert.False(t, byAdmin.Redraft(model.RoleAdmin))
+	assert.Equal(t, model.StatusPublished, byAdmin.Status)
+
+	byEditor := published
+	assert.True(t, byEditor.Redraft(model.RoleEditor))
+	assert.Equal(t, model.StatusDraft, byEditor.Status)
+	assert.Nil(t, byEditor.PublishAt)
+
+	legacy := model.Movie{}
+	assert.True(t, legacy.Redraft(model.RoleEditor))
+
+	inReview := model.Movie{Status: model.StatusInReview}
+	assert.False(t, inReview.Redraft(model.RoleEditor))
+}
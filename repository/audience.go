@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"gin-demo/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// withAudience narrows a movie query to what the audience may see. It is the
+// query form of model.Audience.Allows, applied in the database so that
+// pagination and counts never include hidden movies.
+func withAudience(filter bson.M, audience *model.Audience) bson.M {
+	if audience == nil {
+		return filter
+	}
+	if !audience.Editorial() {
+		published(filter)
+	}
+	return forAge(filter, audience)
+}
+
+// forAge hides the movies rated above the audience's age in its country,
+// leaving the workflow status to the caller.
+func forAge(filter bson.M, audience *model.Audience) bson.M {
+	if audience == nil || audience.Unrestricted {
+		return filter
+	}
+	filter["releases"] = bson.M{"$not": bson.M{"$elemMatch": bson.M{
+		"country": audience.Country,
+		"min_age": bson.M{"$gt": audience.MaxAge},
+	}}}
+	return filter
+}
+
+// published narrows a movie query to what viewers may see now: movies that
+// are published and past their publish date. Movies stored before the
+// workflow have no status and count as published. The condition is added
+// with $and so that it leaves any $or already in the filter alone.
+func published(filter bson.M) bson.M {
+	clause := bson.M{"$or": bson.A{
+		bson.M{"status": bson.M{"$exists": false}},
+		bson.M{
+			"status":     model.StatusPublished,
+			"publish_at": bson.M{"$not": bson.M{"$gt": time.Now()}},
+		},
+	}}
+	and, _ := filter["$and"].(bson.A)
+	filter["$and"] = append(and, clause)
+	return filter
+}
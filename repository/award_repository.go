@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-demo/model"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IAwardRepository interface {
+	Create(award *model.Award) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Award, error)
+	GetAll(ceremony string, year int) ([]model.Award, error)
+	GetByMovie(movieID primitive.ObjectID) ([]model.Award, error)
+	GetByPerson(personID primitive.ObjectID) ([]model.Award, error)
+	Nominated(filter *model.AwardFilter, field string) ([]primitive.ObjectID, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+	Delete(id primitive.ObjectID, version int64) error
+	RemoveMovie(movieID primitive.ObjectID) error
+	Restore(id primitive.ObjectID, doc bson.M) error
+}
+
+type AwardRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAwardRepository(db *mongo.Database) IAwardRepository {
+	collection := db.Collection("awards")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "ceremony", Value: 1}, {Key: "year", Value: 1}, {Key: "category", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "nominees.movie_id", Value: 1}}},
+		{Keys: bson.D{{Key: "nominees.person_id", Value: 1}}},
+	})
+	return &AwardRepository{collection: collection}
+}
+
+func (r *AwardRepository) notFound() error {
+	return model.NotFound("award")
+}
+
+// duplicateAward turns a unique index violation into an error telling the
+// client the category already exists for that edition.
+func duplicateAward(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("%w: award already exists for this ceremony, year and category", model.ErrInvalidDocument)
+	}
+	return err
+}
+
+func (r *AwardRepository) Create(award *model.Award) (primitive.ObjectID, error) {
+	award.ID = primitive.NewObjectID()
+	award.Version = 1
+	_, err := r.collection.InsertOne(context.Background(), award)
+	return award.ID, duplicateAward(err)
+}
+
+func (r *AwardRepository) GetByID(id primitive.ObjectID) (*model.Award, error) {
+	var award model.Award
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&award)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, r.notFound()
+	}
+	return &award, err
+}
+
+// GetAll lists the awards, narrowed to one ceremony, matched regardless of
+// case, and one year when they are given.
+func (r *AwardRepository) GetAll(ceremony string, year int) ([]model.Award, error) {
+	filter := bson.M{}
+	if ceremony != "" {
+		filter["ceremony"] = sameText(ceremony)
+	}
+	if year > 0 {
+		filter["year"] = year
+	}
+	return r.find(filter)
+}
+
+func (r *AwardRepository) GetByMovie(movieID primitive.ObjectID) ([]model.Award, error) {
+	return r.find(bson.M{"nominees.movie_id": movieID})
+}
+
+func (r *AwardRepository) GetByPerson(personID primitive.ObjectID) ([]model.Award, error) {
+	return r.find(bson.M{"nominees.person_id": personID})
+}
+
+func (r *AwardRepository) find(filter bson.M) ([]model.Award, error) {
+	cursor, err := r.collection.Find(context.Background(), filter, options.Find().SetSort(bson.D{
+		{Key: "year", Value: -1},
+		{Key: "ceremony", Value: 1},
+		{Key: "category", Value: 1},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	awards := []model.Award{}
+	if err := cursor.All(context.Background(), &awards); err != nil {
+		return nil, err
+	}
+	return awards, nil
+}
+
+// Nominated returns the distinct movie_id or person_id values of the
+// nominations the filter matches.
+func (r *AwardRepository) Nominated(filter *model.AwardFilter, field string) ([]primitive.ObjectID, error) {
+	match := bson.M{}
+	if filter.Ceremony != "" {
+		match["ceremony"] = sameText(filter.Ceremony)
+	}
+	if filter.Year > 0 {
+		match["year"] = filter.Year
+	}
+	nominee := bson.M{"nominees." + field: bson.M{"$ne": nil}}
+	if filter.WonOnly {
+		nominee["nominees.won"] = true
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$unwind", Value: "$nominees"}},
+		{{Key: "$match", Value: nominee}},
+		{{Key: "$group", Value: bson.M{"_id": "$nominees." + field}}},
+	}
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(context.Background(), &rows); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+	return ids, nil
+}
+
+// Update applies the changes only if the award is still at the given version
+// and moves it to the next one.
+func (r *AwardRepository) Update(id primitive.ObjectID, update bson.M, version int64) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		withVersion(bson.M{"_id": id}, version),
+		bumpVersion(bson.M{"$set": update}),
+	)
+	if err != nil {
+		return duplicateAward(err)
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.collection, bson.M{"_id": id}, version, r.notFound())
+	}
+	return nil
+}
+
+func (r *AwardRepository) Delete(id primitive.ObjectID, version int64) error {
+	res, err := r.collection.DeleteOne(context.Background(), withVersion(bson.M{"_id": id}, version))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return missedWrite(r.collection, bson.M{"_id": id}, version, r.notFound())
+	}
+	return nil
+}
+
+// RemoveMovie drops the nominations of a deleted movie.
+func (r *AwardRepository) RemoveMovie(movieID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(context.Background(),
+		bson.M{"nominees.movie_id": movieID},
+		bumpVersion(bson.M{"$pull": bson.M{"nominees": bson.M{"movie_id": movieID}}}),
+	)
+	return err
+}
+
+// Restore writes a stored snapshot back as the whole document, recreating it
+// if it was deleted.
+func (r *AwardRepository) Restore(id primitive.ObjectID, doc bson.M) error {
+	version, err := restoredVersion(r.collection, id, doc)
+	if err != nil {
+		return err
+	}
+	doc["version"] = version
+	_, err = r.collection.ReplaceOne(context.Background(), bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+	return duplicateAward(err)
+}
+
+// sameText matches a string field regardless of case.
+func sameText(s string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(s) + "$", Options: "i"}
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ICollectionRepository interface {
+	Create(collection *model.Collection) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Collection, error)
+	GetAll() ([]model.Collection, error)
+	GetByMovie(movieID primitive.ObjectID) ([]model.Collection, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+	Delete(id primitive.ObjectID, version int64) error
+	RemoveMovie(movieID primitive.ObjectID) error
+	Restore(id primitive.ObjectID, doc bson.M) error
+}
+
+type CollectionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCollectionRepository(db *mongo.Database) ICollectionRepository {
+	collection := db.Collection("collections")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{{Key: "movie_ids", Value: 1}},
+	})
+	return &CollectionRepository{collection: collection}
+}
+
+func (r *CollectionRepository) notFound() error {
+	return model.NotFound("collection")
+}
+
+func (r *CollectionRepository) Create(collection *model.Collection) (primitive.ObjectID, error) {
+	collection.ID = primitive.NewObjectID()
+	collection.Version = 1
+	if collection.MovieIDs == nil {
+		collection.MovieIDs = []primitive.ObjectID{}
+	}
+	_, err := r.collection.InsertOne(context.Background(), collection)
+	return collection.ID, err
+}
+
+func (r *CollectionRepository) GetByID(id primitive.ObjectID) (*model.Collection, error) {
+	var collection model.Collection
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&collection)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, r.notFound()
+	}
+	return &collection, err
+}
+
+func (r *CollectionRepository) GetAll() ([]model.Collection, error) {
+	return r.find(bson.M{}, options.Find().SetSort(bson.M{"name": 1}))
+}
+
+// GetByMovie returns the collections the movie is a member of.
+func (r *CollectionRepository) GetByMovie(movieID primitive.ObjectID) ([]model.Collection, error) {
+	return r.find(bson.M{"movie_ids": movieID}, options.Find().
+		SetProjection(bson.M{"name": 1, "movie_ids": 1}).
+		SetSort(bson.M{"name": 1}))
+}
+
+func (r *CollectionRepository) find(filter bson.M, opts *options.FindOptions) ([]model.Collection, error) {
+	cursor, err := r.collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	collections := []model.Collection{}
+	if err := cursor.All(context.Background(), &collections); err != nil {
+		return nil, err
+	}
+	return collections, nil
+}
+
+// Update applies the changes only if the collection is still at the given
+// version and moves it to the next one.
+func (r *CollectionRepository) Update(id primitive.ObjectID, update bson.M, version int64) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		withVersion(bson.M{"_id": id}, version),
+		bumpVersion(bson.M{"$set": update}),
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.collection, bson.M{"_id": id}, version, r.notFound())
+	}
+	return nil
+}
+
+func (r *CollectionRepository) Delete(id primitive.ObjectID, version int64) error {
+	res, err := r.collection.DeleteOne(context.Background(), withVersion(bson.M{"_id": id}, version))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return missedWrite(r.collection, bson.M{"_id": id}, version, r.notFound())
+	}
+	return nil
+}
+
+// RemoveMovie takes a deleted movie out of every collection it was in.
+func (r *CollectionRepository) RemoveMovie(movieID primitive.ObjectID) error {
+	_, err := r.collection.UpdateMany(context.Background(),
+		bson.M{"movie_ids": movieID},
+		bumpVersion(bson.M{"$pull": bson.M{"movie_ids": movieID}}),
+	)
+	return err
+}
+
+// Restore writes a stored snapshot back as the whole document, recreating it
+// if it was deleted.
+func (r *CollectionRepository) Restore(id primitive.ObjectID, doc bson.M) error {
+	version, err := restoredVersion(r.collection, id, doc)
+	if err != nil {
+		return err
+	}
+	doc["version"] = version
+	_, err = r.collection.ReplaceOne(context.Background(), bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const exportBatchSize = 200
+
+// IExportRepository walks a whole collection through a cursor, handing the
+// documents to fn one at a time instead of loading them all like GetAll.
+type IExportRepository interface {
+	StreamPeople(department string, fn func(*model.Person) error) error
+	StreamMovies(filter bson.M, sort bson.D, audience *model.Audience, fn func(*model.Movie) error) error
+}
+
+type ExportRepository struct {
+	people *mongo.Collection
+	movies *mongo.Collection
+}
+
+func NewExportRepository(db *mongo.Database) IExportRepository {
+	return &ExportRepository{
+		people: db.Collection("people"),
+		movies: db.Collection("movie"),
+	}
+}
+
+func (r *ExportRepository) StreamPeople(department string, fn func(*model.Person) error) error {
+	filter := live(bson.M{})
+	if department != "" {
+		filter["departments"] = department
+	}
+	cursor, err := r.people.Find(context.Background(), filter, exportFindOptions())
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var p model.Person
+		if err := cursor.Decode(&p); err != nil {
+			return err
+		}
+		if err := fn(&p); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// StreamMovies goes through the movies matching the filter that the audience
+// may see, all of them for a nil audience, in the given order and by id
+// after that.
+func (r *ExportRepository) StreamMovies(
+	filter bson.M,
+	sort bson.D,
+	audience *model.Audience,
+	fn func(*model.Movie) error,
+) error {
+	opts := exportFindOptions().SetSort(append(append(bson.D{}, sort...), bson.E{Key: "_id", Value: 1}))
+	cursor, err := r.movies.Find(context.Background(), withAudience(live(filter), audience), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	for cursor.Next(context.Background()) {
+		var m model.Movie
+		if err := cursor.Decode(&m); err != nil {
+			return err
+		}
+		if err := fn(&m); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func exportFindOptions() *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetBatchSize(exportBatchSize)
+}
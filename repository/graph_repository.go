@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IGraphRepository reads the collaboration graph formed by the acting credits
+// of the movies the audience may see: actors are the nodes and every shared
+// movie is an edge.
+type IGraphRepository interface {
+	CoStars(actorID primitive.ObjectID, limit int64, audience *model.Audience) ([]model.CoStar, error)
+	MoviesWithActors(actorIDs []primitive.ObjectID, audience *model.Audience) ([]model.Movie, error)
+}
+
+type GraphRepository struct {
+	movies *mongo.Collection
+}
+
+// NewGraphRepository also makes sure the credits are indexed, every graph
+// query looks movies up by actor. The repository is usable when indexing
+// fails, only slower, so it is returned along with the error.
+func NewGraphRepository(db *mongo.Database) (IGraphRepository, error) {
+	movies := db.Collection("movie")
+	_, err := movies.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "crew.person_id", Value: 1}}},
+		{Keys: bson.D{{Key: "actors", Value: 1}}},
+	})
+	return &GraphRepository{movies: movies}, err
+}
+
+// actingIn matches the movies any of the actors has an acting credit in.
+// Movies stored before the crew list existed only have the actors field.
+func actingIn(actorIDs []primitive.ObjectID) bson.M {
+	return bson.M{"$or": bson.A{
+		bson.M{"crew": bson.M{"$elemMatch": bson.M{
+			"person_id":  bson.M{"$in": actorIDs},
+			"department": model.DepartmentActing,
+		}}},
+		bson.M{"actors": bson.M{"$in": actorIDs}, "crew.0": bson.M{"$exists": false}},
+	}}
+}
+
+// castExpr evaluates to the distinct actors of a movie, from the crew list
+// when it has entries and from the legacy actors field otherwise.
+var castExpr = bson.M{"$setUnion": bson.A{bson.M{"$cond": bson.A{
+	bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$crew", bson.A{}}}}, 0}},
+	bson.M{"$map": bson.M{
+		"input": bson.M{"$filter": bson.M{
+			"input": "$crew",
+			"cond":  bson.M{"$eq": bson.A{"$$this.department", model.DepartmentActing}},
+		}},
+		"in": "$$this.person_id",
+	}},
+	bson.M{"$ifNull": bson.A{"$actors", bson.A{}}},
+}}}}
+
+// CoStars ranks everybody who shared a movie with the actor by the number of
+// movies they shared.
+func (r *GraphRepository) CoStars(actorID primitive.ObjectID, limit int64, audience *model.Audience) ([]model.CoStar, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: withAudience(live(actingIn([]primitive.ObjectID{actorID})), audience)}},
+		{{Key: "$project", Value: bson.M{"cast": castExpr}}},
+		{{Key: "$unwind", Value: "$cast"}},
+		{{Key: "$match", Value: bson.M{"cast": bson.M{"$ne": actorID}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           "$cast",
+			"shared_movies": bson.M{"$sum": 1},
+			"movie_ids":     bson.M{"$addToSet": "$_id"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "shared_movies", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	coStars := []model.CoStar{}
+	if err := cursor.All(context.Background(), &coStars); err != nil {
+		return nil, err
+	}
+	return coStars, nil
+}
+
+// MoviesWithActors returns the movies any of the actors appeared in, with
+// only the fields needed to walk the graph. Actors holds the cast as taken
+// from the credits.
+func (r *GraphRepository) MoviesWithActors(actorIDs []primitive.ObjectID, audience *model.Audience) ([]model.Movie, error) {
+	opts := options.Find().
+		SetProjection(bson.M{"title": 1, "release_year": 1, "director_id": 1, "actors": 1, "crew": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := r.movies.Find(context.Background(), withAudience(live(actingIn(actorIDs)), audience), opts)
+	if err != nil {
+		return nil, err
+	}
+	var movies []model.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return nil, err
+	}
+	for i := range movies {
+		movies[i].SyncCrew()
+	}
+	return movies, nil
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const importBatchSize = 500
+
+// IImportRepository writes imported records. The write methods report how
+// many records were inserted and updated, also when they fail part way.
+type IImportRepository interface {
+	PeopleIndex() ([]model.Person, error)
+	MovieIndex() ([]model.Movie, error)
+	People(ids []primitive.ObjectID) ([]model.Person, error)
+	Movies(ids []primitive.ObjectID) ([]model.Movie, error)
+	InsertPeople(people []model.Person) (int, error)
+	UpsertPeople(inserts []model.Person, updates []model.Person) (int, int, error)
+	InsertMovies(movies []model.Movie) (int, error)
+	UpsertMovies(inserts []model.Movie, updates []model.Movie) (int, int, error)
+	AddDepartments(departments map[primitive.ObjectID][]string) error
+}
+
+// ImportRepository writes in bulk without slugs and gives the new and renamed
+// records theirs once a write is done, one at a time so that records from
+// the same file cannot collide.
+type ImportRepository struct {
+	people      *mongo.Collection
+	movies      *mongo.Collection
+	peopleSlugs *slugs[model.Person]
+	movieSlugs  *slugs[model.Movie]
+}
+
+func NewImportRepository(db *mongo.Database) IImportRepository {
+	people := db.Collection("people")
+	movies := db.Collection("movie")
+	return &ImportRepository{
+		people:      people,
+		movies:      movies,
+		peopleSlugs: newSlugs(people, "person", personSlug, "first_name", "last_name"),
+		movieSlugs:  newSlugs(movies, "movie", movieSlug, "title", "release_year"),
+	}
+}
+
+// PeopleIndex loads the fields needed to match imported rows to existing people.
+func (r *ImportRepository) PeopleIndex() ([]model.Person, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"external_id": 1, "first_name": 1, "last_name": 1, "birth_date": 1,
+	})
+	cursor, err := r.people.Find(context.Background(), live(bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	var people []model.Person
+	if err := cursor.All(context.Background(), &people); err != nil {
+		return nil, err
+	}
+	return people, nil
+}
+
+// MovieIndex loads the fields needed to match imported rows to existing
+// movies, the credits an upsert keeps when its row leaves them out and the
+// workflow fields an upsert may send back to draft.
+func (r *ImportRepository) MovieIndex() ([]model.Movie, error) {
+	opts := options.Find().SetProjection(bson.M{
+		"external_id": 1, "title": 1, "release_year": 1, "director_id": 1, "actors": 1, "crew": 1,
+		"status": 1, "publish_at": 1, "review_comment": 1,
+	})
+	cursor, err := r.movies.Find(context.Background(), live(bson.M{}), opts)
+	if err != nil {
+		return nil, err
+	}
+	var movies []model.Movie
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// People and Movies load whole records a batch at a time, for the revisions
+// of an import.
+func (r *ImportRepository) People(ids []primitive.ObjectID) ([]model.Person, error) {
+	return findInBatches[model.Person](r.people, ids)
+}
+
+func (r *ImportRepository) Movies(ids []primitive.ObjectID) ([]model.Movie, error) {
+	return findInBatches[model.Movie](r.movies, ids)
+}
+
+func (r *ImportRepository) InsertPeople(people []model.Person) (int, error) {
+	docs := make([]interface{}, 0, len(people))
+	for i := range people {
+		people[i].Version = 1
+		docs = append(docs, people[i])
+	}
+	inserted, err := insertInBatches(r.people, docs)
+	if err != nil {
+		return inserted, err
+	}
+	return inserted, r.peopleSlugs.backfill()
+}
+
+func (r *ImportRepository) UpsertPeople(inserts []model.Person, updates []model.Person) (int, int, error) {
+	writes := make([]mongo.WriteModel, 0, len(inserts)+len(updates))
+	for i := range inserts {
+		inserts[i].Version = 1
+		writes = append(writes, mongo.NewInsertOneModel().SetDocument(inserts[i]))
+	}
+	for _, p := range updates {
+		set := bson.M{"first_name": p.FirstName, "last_name": p.LastName}
+		if !p.BirthDate.IsZero() {
+			set["birth_date"] = p.BirthDate
+		}
+		if p.ExternalID != "" {
+			set["external_id"] = p.ExternalID
+		}
+		update := bson.M{"$set": set}
+		if len(p.Departments) > 0 {
+			update["$addToSet"] = bson.M{"departments": bson.M{"$each": p.Departments}}
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": p.ID}).
+			SetUpdate(bumpVersion(update)))
+	}
+	inserted, updated, err := writeInBatches(r.people, writes)
+	if err != nil {
+		return inserted, updated, err
+	}
+	for _, p := range updates {
+		if err := r.peopleSlugs.refresh(p.ID); err != nil {
+			return inserted, updated, err
+		}
+	}
+	return inserted, updated, r.peopleSlugs.backfill()
+}
+
+func (r *ImportRepository) InsertMovies(movies []model.Movie) (int, error) {
+	docs := make([]interface{}, 0, len(movies))
+	for i := range movies {
+		movies[i].Version = 1
+		docs = append(docs, movies[i])
+	}
+	inserted, err := insertInBatches(r.movies, docs)
+	if err != nil {
+		return inserted, err
+	}
+	return inserted, r.movieSlugs.backfill()
+}
+
+// UpsertMovies only sets what the rows provided: the service fills the
+// credits a row left out from the stored movie, and a release year of zero or
+// an empty overview, language, genre, release or translation column leaves
+// the stored value alone. An update with a status is one the service
+// sent back to draft, and its other workflow fields are written with it.
+func (r *ImportRepository) UpsertMovies(inserts []model.Movie, updates []model.Movie) (int, int, error) {
+	writes := make([]mongo.WriteModel, 0, len(inserts)+len(updates))
+	for i := range inserts {
+		inserts[i].Version = 1
+		writes = append(writes, mongo.NewInsertOneModel().SetDocument(inserts[i]))
+	}
+	for _, m := range updates {
+		set := bson.M{
+			"title":       m.Title,
+			"director_id": m.DirectorID,
+			"actors":      m.Actors,
+			"crew":        m.Crew,
+		}
+		if m.ReleaseYear != 0 {
+			set["release_year"] = m.ReleaseYear
+		}
+		if m.ExternalID != "" {
+			set["external_id"] = m.ExternalID
+		}
+		if m.Overview != "" {
+			set["overview"] = m.Overview
+		}
+		if m.OriginalLanguage != "" {
+			set["original_language"] = m.OriginalLanguage
+		}
+		if len(m.Genres) > 0 {
+			set["genres"] = m.Genres
+		}
+		if len(m.Releases) > 0 {
+			set["releases"] = m.Releases
+		}
+		if len(m.Translations) > 0 {
+			set["translations"] = m.Translations
+		}
+		if m.Status != "" {
+			set["status"] = m.Status
+			set["publish_at"] = m.PublishAt
+			set["review_comment"] = m.ReviewComment
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(live(bson.M{"_id": m.ID})).
+			SetUpdate(bumpVersion(bson.M{"$set": set})))
+	}
+	inserted, updated, err := writeInBatches(r.movies, writes)
+	if err != nil {
+		return inserted, updated, err
+	}
+	for _, m := range updates {
+		if err := r.movieSlugs.refresh(m.ID); err != nil {
+			return inserted, updated, err
+		}
+	}
+	return inserted, updated, r.movieSlugs.backfill()
+}
+
+// AddDepartments gives people the departments they are credited in by the
+// imported movies, so the movies also show under /actor and /director.
+func (r *ImportRepository) AddDepartments(departments map[primitive.ObjectID][]string) error {
+	writes := make([]mongo.WriteModel, 0, len(departments))
+	for id, list := range departments {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(live(bson.M{"_id": id, "departments": bson.M{"$not": bson.M{"$all": list}}})).
+			SetUpdate(bumpVersion(bson.M{"$addToSet": bson.M{"departments": bson.M{"$each": list}}})))
+	}
+	_, _, err := writeInBatches(r.people, writes)
+	return err
+}
+
+func findInBatches[T any](collection *mongo.Collection, ids []primitive.ObjectID) ([]T, error) {
+	docs := []T{}
+	for start := 0; start < len(ids); start += importBatchSize {
+		end := min(start+importBatchSize, len(ids))
+		cursor, err := collection.Find(context.Background(), live(bson.M{"_id": bson.M{"$in": ids[start:end]}}))
+		if err != nil {
+			return nil, err
+		}
+		var batch []T
+		if err := cursor.All(context.Background(), &batch); err != nil {
+			return nil, err
+		}
+		docs = append(docs, batch...)
+	}
+	return docs, nil
+}
+
+// insertInBatches reports how many documents made it in, also when a batch
+// fails.
+func insertInBatches(collection *mongo.Collection, docs []interface{}) (int, error) {
+	inserted := 0
+	for start := 0; start < len(docs); start += importBatchSize {
+		end := min(start+importBatchSize, len(docs))
+		res, err := collection.InsertMany(context.Background(), docs[start:end])
+		if res != nil {
+			inserted += len(res.InsertedIDs)
+		}
+		if err != nil {
+			return inserted, err
+		}
+	}
+	return inserted, nil
+}
+
+// writeInBatches reports how many documents were inserted and matched for an
+// update, also when a batch fails.
+func writeInBatches(collection *mongo.Collection, writes []mongo.WriteModel) (int, int, error) {
+	inserted, updated := 0, 0
+	for start := 0; start < len(writes); start += importBatchSize {
+		end := min(start+importBatchSize, len(writes))
+		res, err := collection.BulkWrite(context.Background(), writes[start:end])
+		if res != nil {
+			inserted += int(res.InsertedCount)
+			updated += int(res.MatchedCount)
+		}
+		if err != nil {
+			return inserted, updated, err
+		}
+	}
+	return inserted, updated, nil
+}
@@ -5,22 +5,32 @@ import (
 	"gin-demo/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type HydrationOptions struct {
 	ForceActors   bool
 	ForceDirector bool
+	ForceCrew     bool
+
+	// Collections are only looked up on request, list endpoints leave them
+	// out.
+	ForceCollections bool
 }
 
+// MovieHydrator fills in the people and collections a movie points at.
+// People are looked up in the people collection whatever their departments,
+// and credits of people who are missing or in the trash are left without
+// details instead of failing the read.
 type MovieHydrator struct {
-	DirectorsRepo IDirectorRepository
-	ActorsRepo    IActorRepository
+	PeopleRepo      IPersonRepository
+	CollectionsRepo ICollectionRepository
 }
 
-func NewMovieHydrator(dRepo IDirectorRepository, aRepo IActorRepository) *MovieHydrator {
+func NewMovieHydrator(pRepo IPersonRepository, cRepo ICollectionRepository) *MovieHydrator {
 	return &MovieHydrator{
-		DirectorsRepo: dRepo,
-		ActorsRepo:    aRepo,
+		PeopleRepo:      pRepo,
+		CollectionsRepo: cRepo,
 	}
 }
 
@@ -36,27 +46,58 @@ func (h *MovieHydrator) Hydrate(
 			utils.FieldIncluded(projection, "director") ||
 			utils.FieldIncluded(projection, "director_id")
 
-	if hydrateDirector && !m.DirectorID.IsZero() {
-		director, err := h.DirectorsRepo.GetByID(m.DirectorID)
-		if err != nil {
-			return err
-		}
-		m.Director = director
-	}
-
-	// --- ACTORS ---
 	hydrateActors :=
 		opts.ForceActors ||
 			projection == nil ||
 			utils.FieldIncluded(projection, "actors") ||
 			utils.FieldIncluded(projection, "actors_details")
 
-	if hydrateActors && len(m.Actors) > 0 {
-		actors, err := h.ActorsRepo.GetByIDs(m.Actors)
+	hydrateCrew :=
+		opts.ForceCrew ||
+			projection == nil ||
+			utils.FieldIncluded(projection, "crew")
+
+	if hydrateDirector || hydrateActors || hydrateCrew {
+		people, err := h.PeopleRepo.GetByIDs(m.PeopleIDs())
+		if err != nil {
+			return err
+		}
+		byID := make(map[primitive.ObjectID]*model.Person, len(people))
+		for i := range people {
+			byID[people[i].ID] = &people[i]
+		}
+
+		// --- DIRECTOR ---
+		if hydrateDirector && !m.DirectorID.IsZero() {
+			m.Director = byID[m.DirectorID]
+		}
+
+		// --- ACTORS ---
+		if hydrateActors && len(m.Actors) > 0 {
+			actors := make([]model.Actor, 0, len(m.Actors))
+			for _, id := range m.Actors {
+				if actor, ok := byID[id]; ok {
+					actors = append(actors, *actor)
+				}
+			}
+			m.ActorsDetails = actors
+		}
+
+		// --- CREW ---
+		if hydrateCrew {
+			for i := range m.Crew {
+				m.Crew[i].Person = byID[m.Crew[i].PersonID]
+			}
+		}
+	}
+
+	// --- COLLECTIONS ---
+	if opts.ForceCollections {
+		collections, err := h.CollectionsRepo.GetByMovie(m.ID)
 		if err != nil {
 			return err
 		}
-		m.ActorsDetails = actors
+		m.Collections = model.LinkCollections(m.ID, collections)
 	}
 
 	return nil
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"gin-demo/model"
 	"gin-demo/utils"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -17,53 +18,83 @@ type IMovieRepository interface {
 	Create(movie *model.Movie) (primitive.ObjectID, error)
 	GetByID(id primitive.ObjectID) (*model.Movie, error)
 	GetAll() ([]model.Movie, error)
-	Update(id primitive.ObjectID, update bson.M) error
-	Delete(id primitive.ObjectID) error
-	CountByDirectorID(id primitive.ObjectID) (int64, error)
-	CountByActorID(id primitive.ObjectID) (int64, error)
+	GetByIDs(ids []primitive.ObjectID) ([]model.Movie, error)
+	SimilarCandidates(
+		seeds []model.Movie,
+		exclude []primitive.ObjectID,
+		limit int64,
+		audience *model.Audience,
+	) ([]model.Movie, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+	Delete(id primitive.ObjectID, version int64, by string) error
+	Restore(id primitive.ObjectID, doc bson.M) error
+	CountByDirectorID(id primitive.ObjectID, audience *model.Audience) (int64, error)
+	CountByActorID(id primitive.ObjectID, audience *model.Audience) (int64, error)
 	GetByActor(
 		actorID primitive.ObjectID,
 		pagination *utils.Pagination,
 		projection bson.M,
+		audience *model.Audience,
 	) ([]bson.M, error)
 	GetByDirector(
 		directorID primitive.ObjectID,
 		pagination *utils.Pagination,
 		projection bson.M,
+		audience *model.Audience,
 	) ([]bson.M, error)
+	Filmography(personID primitive.ObjectID, department string, audience *model.Audience) (*model.Filmography, error)
+	GetCredited(personID primitive.ObjectID, department string, audience *model.Audience) ([]model.Movie, error)
+	ResolveSlug(slug string) (*model.SlugMatch, error)
+	BackfillSlugs() error
+	Trashed() ([]model.Movie, error)
+	GetTrashed(id primitive.ObjectID) (*model.Movie, error)
+	Undelete(id primitive.ObjectID) error
+	Purge(cutoff time.Time) ([]model.Movie, error)
 }
 
 type MovieRepository struct {
-	movies    *mongo.Collection
-	actors    *mongo.Collection
-	directors *mongo.Collection
+	movies *mongo.Collection
+	people *mongo.Collection
+	slugs  *slugs[model.Movie]
 }
 
 func NewMovieRepository(db *mongo.Database) IMovieRepository {
+	movies := db.Collection("movie")
 	return &MovieRepository{
-		movies:    db.Collection("movie"),
-		actors:    db.Collection("actors"),
-		directors: db.Collection("directors"),
+		movies: movies,
+		people: db.Collection("people"),
+		slugs:  newSlugs(movies, "movie", movieSlug, "title", "release_year"),
 	}
 }
 
+func movieSlug(movie *model.Movie) string {
+	return model.MovieSlug(movie.Title, movie.ReleaseYear)
+}
+
 func (r *MovieRepository) Create(movie *model.Movie) (primitive.ObjectID, error) {
 	movie.ID = primitive.NewObjectID()
-	_, err := r.movies.InsertOne(context.Background(), movie)
+	movie.Version = 1
+	slug, err := r.slugs.assign(movie, movie.ID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	movie.Slug = slug
+	movie.SlugAliases = nil
+	_, err = r.movies.InsertOne(context.Background(), movie)
 	return movie.ID, err
 }
 
 func (r *MovieRepository) GetByID(id primitive.ObjectID) (*model.Movie, error) {
 	var movie model.Movie
-	err := r.movies.FindOne(context.Background(), bson.M{"_id": id}).Decode(&movie)
+	err := r.movies.FindOne(context.Background(), live(bson.M{"_id": id})).Decode(&movie)
 	if errors.Is(err, mongo.ErrNoDocuments) {
-		return nil, fmt.Errorf("movie not found with given id")
+		return nil, model.NotFound("movie")
 	}
 	return &movie, err
 }
 
 func (r *MovieRepository) GetAll() ([]model.Movie, error) {
-	cursor, err := r.movies.Find(context.Background(), bson.M{})
+	cursor, err := r.movies.Find(context.Background(), live(bson.M{}))
 	if err != nil {
 		return nil, err
 	}
@@ -74,26 +105,128 @@ func (r *MovieRepository) GetAll() ([]model.Movie, error) {
 	return movies, nil
 }
 
-func (r *MovieRepository) Update(id primitive.ObjectID, update bson.M) error {
-	_, err := r.movies.UpdateOne(context.Background(), bson.M{"_id": id}, bson.M{"$set": update})
-	if errors.Is(err, mongo.ErrNoDocuments) {
-		return fmt.Errorf("movie not found with given id")
+func (r *MovieRepository) GetByIDs(ids []primitive.ObjectID) ([]model.Movie, error) {
+	if len(ids) == 0 {
+		return []model.Movie{}, nil
 	}
-	return err
+	cursor, err := r.movies.Find(context.Background(), live(bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return nil, err
+	}
+	movies := []model.Movie{}
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
 }
 
-func (r *MovieRepository) Delete(id primitive.ObjectID) error {
-	_, err := r.movies.DeleteOne(context.Background(), bson.M{"_id": id})
-	if errors.Is(err, mongo.ErrNoDocuments) {
-		return fmt.Errorf("movie not found with given id")
+// SimilarCandidates finds movies sharing a director, an actor or a genre with
+// any of the seeds. They are only candidates, scoring happens afterwards.
+// Recommendations are cached for everybody, so only published movies count.
+func (r *MovieRepository) SimilarCandidates(
+	seeds []model.Movie,
+	exclude []primitive.ObjectID,
+	limit int64,
+	audience *model.Audience,
+) ([]model.Movie, error) {
+	var people []primitive.ObjectID
+	var genres []string
+	for _, m := range seeds {
+		for _, c := range m.Crew {
+			if c.Department == model.DepartmentActing || c.Department == model.DepartmentDirecting {
+				people = append(people, c.PersonID)
+			}
+		}
+		if !m.DirectorID.IsZero() {
+			people = append(people, m.DirectorID)
+		}
+		people = append(people, m.Actors...)
+		genres = append(genres, m.Genres...)
 	}
-	return err
+	if len(people) == 0 && len(genres) == 0 {
+		return []model.Movie{}, nil
+	}
+	if exclude == nil {
+		exclude = []primitive.ObjectID{}
+	}
+
+	filter := forAge(published(live(bson.M{
+		"_id": bson.M{"$nin": exclude},
+		"$or": bson.A{
+			bson.M{"crew.person_id": bson.M{"$in": people}},
+			bson.M{"director_id": bson.M{"$in": people}},
+			bson.M{"actors": bson.M{"$in": people}},
+			bson.M{"genres": bson.M{"$in": model.NormalizeGenres(genres)}},
+		},
+	})), audience)
+	opts := options.Find().SetLimit(limit).SetProjection(bson.M{
+		"title": 1, "release_year": 1, "genres": 1, "director_id": 1, "actors": 1, "crew": 1,
+	})
+	cursor, err := r.movies.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	movies := []model.Movie{}
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// Update applies the changes only if the movie is still at the given version
+// and moves it to the next one. A new title or year moves the slug along.
+func (r *MovieRepository) Update(id primitive.ObjectID, update bson.M, version int64) error {
+	res, err := r.movies.UpdateOne(context.Background(),
+		withVersion(live(bson.M{"_id": id}), version),
+		bumpVersion(bson.M{"$set": update}),
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.movies, live(bson.M{"_id": id}), version, model.NotFound("movie"))
+	}
+	if r.slugs.touches(update) {
+		return r.slugs.refresh(id)
+	}
+	return nil
+}
+
+// Delete moves the movie to the trash. It stays there, out of every read,
+// until it is restored or purged.
+func (r *MovieRepository) Delete(id primitive.ObjectID, version int64, by string) error {
+	res, err := softDelete(r.movies, withVersion(live(bson.M{"_id": id}), version), by)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.movies, live(bson.M{"_id": id}), version, model.NotFound("movie"))
+	}
+	return nil
+}
+
+// Restore writes a stored snapshot back as the whole document, recreating it
+// if it was deleted. It refuses to write over a document in the trash.
+func (r *MovieRepository) Restore(id primitive.ObjectID, doc bson.M) error {
+	version, err := restoredVersion(r.movies, id, doc)
+	if err != nil {
+		return err
+	}
+	doc["version"] = version
+	if err := r.slugs.keep(id, doc); err != nil {
+		return err
+	}
+	_, err = r.movies.ReplaceOne(context.Background(), live(bson.M{"_id": id}), doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	return r.slugs.refresh(id)
 }
 
-func (r *MovieRepository) CountByDirectorID(id primitive.ObjectID) (int64, error) {
-	exists, err := r.directors.CountDocuments(
+func (r *MovieRepository) CountByDirectorID(id primitive.ObjectID, audience *model.Audience) (int64, error) {
+	exists, err := r.people.CountDocuments(
 		context.Background(),
-		bson.M{"_id": id},
+		live(bson.M{"_id": id, "departments": model.DepartmentDirecting}),
 	)
 	if err != nil {
 		return 0, err
@@ -102,14 +235,14 @@ func (r *MovieRepository) CountByDirectorID(id primitive.ObjectID) (int64, error
 		return 0, fmt.Errorf("director not found with id: %s", id.Hex())
 	}
 
-	filter := bson.M{"director_id": id}
+	filter := withAudience(live(bson.M{"director_id": id}), audience)
 	return r.movies.CountDocuments(context.Background(), filter)
 }
 
-func (r *MovieRepository) CountByActorID(id primitive.ObjectID) (int64, error) {
-	exists, err := r.actors.CountDocuments(
+func (r *MovieRepository) CountByActorID(id primitive.ObjectID, audience *model.Audience) (int64, error) {
+	exists, err := r.people.CountDocuments(
 		context.Background(),
-		bson.M{"_id": id},
+		live(bson.M{"_id": id, "departments": model.DepartmentActing}),
 	)
 	if err != nil {
 		return 0, err
@@ -118,9 +251,9 @@ func (r *MovieRepository) CountByActorID(id primitive.ObjectID) (int64, error) {
 		return 0, fmt.Errorf("actor not found with id: %s", id.Hex())
 	}
 
-	filter := bson.M{
+	filter := withAudience(live(bson.M{
 		"actors": bson.M{"$in": []primitive.ObjectID{id}},
-	}
+	}), audience)
 
 	return r.movies.CountDocuments(context.Background(), filter)
 }
@@ -129,6 +262,7 @@ func (r *MovieRepository) GetByActor(
 	actorID primitive.ObjectID,
 	pagination *utils.Pagination,
 	projection bson.M,
+	audience *model.Audience,
 ) ([]bson.M, error) {
 
 	opts := options.Find()
@@ -139,9 +273,9 @@ func (r *MovieRepository) GetByActor(
 		opts.SetProjection(projection)
 	}
 
-	filter := bson.M{
+	filter := withAudience(live(bson.M{
 		"actors": bson.M{"$in": []primitive.ObjectID{actorID}},
-	}
+	}), audience)
 
 	cursor, err := r.movies.Find(context.Background(), filter, opts)
 	if err != nil {
@@ -160,6 +294,7 @@ func (r *MovieRepository) GetByDirector(
 	directorID primitive.ObjectID,
 	pagination *utils.Pagination,
 	projection bson.M,
+	audience *model.Audience,
 ) ([]bson.M, error) {
 
 	opts := options.Find()
@@ -171,7 +306,7 @@ func (r *MovieRepository) GetByDirector(
 	}
 
 	cursor, err := r.movies.Find(context.Background(),
-		bson.M{"director_id": directorID},
+		withAudience(live(bson.M{"director_id": directorID}), audience),
 		opts,
 	)
 	if err != nil {
@@ -185,3 +320,194 @@ func (r *MovieRepository) GetByDirector(
 
 	return movies, nil
 }
+
+// filmographyCollaborators is how many collaborators the filmography lists.
+const filmographyCollaborators = 10
+
+// GetCredited returns the movies the person worked on in the department.
+// Movies stored before crew lists existed are found through their director
+// and cast.
+func (r *MovieRepository) GetCredited(
+	personID primitive.ObjectID,
+	department string,
+	audience *model.Audience,
+) ([]model.Movie, error) {
+	credited := bson.A{bson.M{"crew": bson.M{"$elemMatch": bson.M{
+		"person_id":  personID,
+		"department": department,
+	}}}}
+	switch department {
+	case model.DepartmentDirecting:
+		credited = append(credited, bson.M{"director_id": personID})
+	case model.DepartmentActing:
+		credited = append(credited, bson.M{"actors": personID})
+	}
+
+	cursor, err := r.movies.Find(context.Background(), withAudience(live(bson.M{"$or": credited}), audience))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	movies := []model.Movie{}
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+// Filmography builds a person's credits and career statistics in a single
+// pipeline: ratings are joined in, then one facet groups the credits by
+// decade, one computes the totals and one ranks collaborators not in the
+// trash.
+func (r *MovieRepository) Filmography(
+	personID primitive.ObjectID,
+	department string,
+	audience *model.Audience,
+) (*model.Filmography, error) {
+	year := "$release_year"
+	knownYear := bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{year, 0}}, year, nil}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: withAudience(live(bson.M{"crew": bson.M{"$elemMatch": bson.M{
+			"person_id":  personID,
+			"department": department,
+		}}}), audience)}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "ratings",
+			"localField":   "_id",
+			"foreignField": "movie_id",
+			"as":           "ratings",
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"rating":       bson.M{"$avg": "$ratings.score"},
+			"rating_count": bson.M{"$size": "$ratings"},
+			"jobs": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": "$crew",
+					"cond": bson.M{"$and": bson.A{
+						bson.M{"$eq": bson.A{"$$this.person_id", personID}},
+						bson.M{"$eq": bson.A{"$$this.department", department}},
+					}},
+				}},
+				"in": "$$this.job",
+			}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"decades": bson.A{
+				bson.M{"$sort": bson.D{{Key: "release_year", Value: 1}, {Key: "title", Value: 1}}},
+				bson.M{"$group": bson.M{
+					"_id": bson.M{"$cond": bson.A{
+						bson.M{"$gt": bson.A{year, 0}},
+						bson.M{"$subtract": bson.A{year, bson.M{"$mod": bson.A{year, 10}}}},
+						nil,
+					}},
+					"credits": bson.M{"$push": bson.M{
+						"_id":          "$_id",
+						"title":        "$title",
+						"release_year": year,
+						"jobs":         "$jobs",
+						"rating":       "$rating",
+						"rating_count": "$rating_count",
+					}},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":            nil,
+					"first_year":     bson.M{"$min": knownYear},
+					"last_year":      bson.M{"$max": knownYear},
+					"total_credits":  bson.M{"$sum": 1},
+					"average_rating": bson.M{"$avg": "$rating"},
+					"rated_credits": bson.M{"$sum": bson.M{"$cond": bson.A{
+						bson.M{"$gt": bson.A{"$rating_count", 0}}, 1, 0,
+					}}},
+				}},
+			},
+			"collaborators": bson.A{
+				bson.M{"$unwind": "$crew"},
+				bson.M{"$match": bson.M{"crew.person_id": bson.M{"$ne": personID}}},
+				bson.M{"$group": bson.M{"_id": "$crew.person_id", "movies": bson.M{"$addToSet": "$_id"}}},
+				bson.M{"$project": bson.M{"shared_movies": bson.M{"$size": "$movies"}}},
+				bson.M{"$sort": bson.D{{Key: "shared_movies", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$lookup": liveLookup("people", "_id", "person")},
+				bson.M{"$unwind": "$person"},
+				bson.M{"$limit": filmographyCollaborators},
+			},
+		}}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var result []struct {
+		Decades       []model.FilmographyDecade `bson:"decades"`
+		Totals        []model.CareerStats       `bson:"totals"`
+		Collaborators []model.Collaborator      `bson:"collaborators"`
+	}
+	if err := cursor.All(context.Background(), &result); err != nil {
+		return nil, err
+	}
+
+	filmography := &model.Filmography{Decades: []model.FilmographyDecade{}}
+	filmography.Stats.TopCollaborators = []model.Collaborator{}
+	if len(result) == 0 {
+		return filmography, nil
+	}
+	filmography.Decades = result[0].Decades
+	if len(result[0].Totals) > 0 {
+		filmography.Stats = result[0].Totals[0]
+	}
+	filmography.Stats.TopCollaborators = result[0].Collaborators
+	return filmography, nil
+}
+
+// ResolveSlug finds the movie a slug belongs to, now or before a rename.
+func (r *MovieRepository) ResolveSlug(slug string) (*model.SlugMatch, error) {
+	return r.slugs.resolve(slug)
+}
+
+// BackfillSlugs gives a slug to every movie stored before slugs existed.
+func (r *MovieRepository) BackfillSlugs() error {
+	return r.slugs.backfill()
+}
+
+// Trashed lists the movies in the trash, oldest deletion first.
+func (r *MovieRepository) Trashed() ([]model.Movie, error) {
+	movies := []model.Movie{}
+	return movies, findTrashed(r.movies, time.Time{}, &movies)
+}
+
+func (r *MovieRepository) GetTrashed(id primitive.ObjectID) (*model.Movie, error) {
+	var movie model.Movie
+	err := r.movies.FindOne(context.Background(), trashed(bson.M{"_id": id})).Decode(&movie)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("movie not found in trash with given id")
+	}
+	return &movie, err
+}
+
+// Undelete takes the movie out of the trash.
+func (r *MovieRepository) Undelete(id primitive.ObjectID) error {
+	found, err := undelete(r.movies, id)
+	if err == nil && !found {
+		return fmt.Errorf("movie not found in trash with given id")
+	}
+	return err
+}
+
+// Purge removes the movies deleted before the cutoff for good and returns
+// them, so whatever pointed at them can be cleaned up too.
+func (r *MovieRepository) Purge(cutoff time.Time) ([]model.Movie, error) {
+	movies := []model.Movie{}
+	if err := findTrashed(r.movies, cutoff, &movies); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(movies))
+	for i := range movies {
+		ids[i] = movies[i].ID
+	}
+	return movies, purge(r.movies, ids)
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-demo/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TenantDatabase is where an organization's catalog lives. The default
+// organization keeps the configured database, so the catalog from before
+// organizations existed stays where it is; every other organization gets a
+// database of its own. Repositories built on it cannot reach another
+// organization's documents whatever their queries look like.
+func TenantDatabase(client *mongo.Client, name, organization string) *mongo.Database {
+	if organization != model.DefaultOrganization {
+		name += "_" + organization
+	}
+	return client.Database(name)
+}
+
+type IOrganizationRepository interface {
+	Create(org *model.Organization) (primitive.ObjectID, error)
+	GetBySlug(slug string) (*model.Organization, error)
+	GetAll() ([]model.Organization, error)
+	EnsureDefault() error
+}
+
+// OrganizationRepository keeps the organizations in the shared database,
+// next to the users who belong to them.
+type OrganizationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrganizationRepository(db *mongo.Database) IOrganizationRepository {
+	collection := db.Collection("organizations")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "slug", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &OrganizationRepository{collection: collection}
+}
+
+func (r *OrganizationRepository) Create(org *model.Organization) (primitive.ObjectID, error) {
+	org.ID = primitive.NewObjectID()
+	org.CreatedAt = time.Now().UTC()
+	_, err := r.collection.InsertOne(context.Background(), org)
+	if mongo.IsDuplicateKeyError(err) {
+		return org.ID, fmt.Errorf("%w: organization already exists with this slug", model.ErrInvalidDocument)
+	}
+	return org.ID, err
+}
+
+func (r *OrganizationRepository) GetBySlug(slug string) (*model.Organization, error) {
+	var org model.Organization
+	err := r.collection.FindOne(context.Background(), bson.M{"slug": slug}).Decode(&org)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("organization not found with given slug")
+	}
+	return &org, err
+}
+
+func (r *OrganizationRepository) GetAll() ([]model.Organization, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "slug", Value: 1}})
+	cursor, err := r.collection.Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	orgs := []model.Organization{}
+	if err := cursor.All(context.Background(), &orgs); err != nil {
+		return nil, err
+	}
+	return orgs, nil
+}
+
+// EnsureDefault stores the default organization unless it is there already,
+// so it can be listed and managed like any other.
+func (r *OrganizationRepository) EnsureDefault() error {
+	_, err := r.collection.UpdateOne(context.Background(),
+		bson.M{"slug": model.DefaultOrganization},
+		bson.M{"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"name":       "Default",
+			"created_at": time.Now().UTC(),
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
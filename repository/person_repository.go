@@ -0,0 +1,473 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-demo/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IPersonRepository interface {
+	Create(person *model.Person) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Person, error)
+	GetByIDs(ids []primitive.ObjectID) ([]model.Person, error)
+	GetAll() ([]model.Person, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+	AddDepartment(id primitive.ObjectID, department string) error
+	Delete(id primitive.ObjectID, version int64, by string) error
+	Merge(survivorID primitive.ObjectID, duplicates []primitive.ObjectID, merge MergeFunc) (*model.Person, error)
+	Restore(id primitive.ObjectID, doc bson.M) error
+	ResolveSlug(slug string) (*model.SlugMatch, error)
+	BackfillSlugs() error
+	Trashed() ([]model.Person, error)
+	GetTrashed(id primitive.ObjectID) (*model.Person, error)
+	Undelete(id primitive.ObjectID) error
+	Purge(cutoff time.Time) ([]model.Person, error)
+}
+
+// PersonRepository works on the people collection. When department is set
+// the repository only sees people credited in that department, which is how
+// the actor and director repositories are built on top of it. People in the
+// trash are never seen.
+type PersonRepository struct {
+	collection *mongo.Collection
+	redirects  *mongo.Collection
+	movies     *mongo.Collection
+	awards     *mongo.Collection
+	series     *mongo.Collection
+	audit      *mongo.Collection
+	slugs      *slugs[model.Person]
+	department string
+	entity     string
+}
+
+func NewPersonRepository(db *mongo.Database) IPersonRepository {
+	return newPersonRepository(db, "", "person")
+}
+
+func newPersonRepository(db *mongo.Database, department, entity string) *PersonRepository {
+	people := db.Collection("people")
+	return &PersonRepository{
+		collection: people,
+		redirects:  db.Collection("person_redirects"),
+		movies:     db.Collection("movie"),
+		awards:     db.Collection("awards"),
+		series:     db.Collection("series"),
+		audit:      db.Collection("audit_log"),
+		slugs:      newSlugs(people, entity, personSlug, "first_name", "last_name"),
+		department: department,
+		entity:     entity,
+	}
+}
+
+func personSlug(person *model.Person) string {
+	return model.PersonSlug(person.FirstName, person.LastName)
+}
+
+func (r *PersonRepository) scoped(filter bson.M) bson.M {
+	if r.department != "" {
+		filter["departments"] = r.department
+	}
+	return live(filter)
+}
+
+func (r *PersonRepository) notFound() error {
+	return model.NotFound(r.entity)
+}
+
+func (r *PersonRepository) Create(person *model.Person) (primitive.ObjectID, error) {
+	person.ID = primitive.NewObjectID()
+	person.Version = 1
+	if r.department != "" && !person.HasDepartment(r.department) {
+		person.Departments = append(person.Departments, r.department)
+	}
+	if person.Departments == nil {
+		person.Departments = []string{}
+	}
+	slug, err := r.slugs.assign(person, person.ID)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	person.Slug = slug
+	person.SlugAliases = nil
+	_, err = r.collection.InsertOne(context.Background(), person)
+	return person.ID, err
+}
+
+// GetByID also follows the redirect left behind when the person was merged
+// into another record.
+func (r *PersonRepository) GetByID(id primitive.ObjectID) (*model.Person, error) {
+	return r.find(context.Background(), id)
+}
+
+func (r *PersonRepository) find(ctx context.Context, id primitive.ObjectID) (*model.Person, error) {
+	var person model.Person
+	err := r.collection.FindOne(ctx, r.scoped(bson.M{"_id": id})).Decode(&person)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		var redirect model.PersonRedirect
+		if r.redirects.FindOne(ctx, bson.M{"_id": id}).Decode(&redirect) == nil {
+			err = r.collection.FindOne(ctx, r.scoped(bson.M{"_id": redirect.TargetID})).Decode(&person)
+		}
+	}
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, r.notFound()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &person, nil
+}
+
+func (r *PersonRepository) GetByIDs(ids []primitive.ObjectID) ([]model.Person, error) {
+	if len(ids) == 0 {
+		return []model.Person{}, nil
+	}
+	cursor, err := r.collection.Find(context.Background(), r.scoped(bson.M{
+		"_id": bson.M{"$in": ids},
+	}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var people []model.Person
+	if err := cursor.All(context.Background(), &people); err != nil {
+		return nil, err
+	}
+
+	return people, nil
+}
+
+func (r *PersonRepository) GetAll() ([]model.Person, error) {
+	cursor, err := r.collection.Find(context.Background(), r.scoped(bson.M{}))
+	if err != nil {
+		return nil, err
+	}
+	var people []model.Person
+	if err = cursor.All(context.Background(), &people); err != nil {
+		return nil, err
+	}
+	return people, nil
+}
+
+// Update applies the changes only if the person is still at the given version
+// and moves them to the next one. A new name moves the slug along.
+func (r *PersonRepository) Update(id primitive.ObjectID, update bson.M, version int64) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		withVersion(r.scoped(bson.M{"_id": id}), version),
+		bumpVersion(bson.M{"$set": update}),
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.collection, r.scoped(bson.M{"_id": id}), version, r.notFound())
+	}
+	if r.slugs.touches(update) {
+		return r.slugs.refresh(id)
+	}
+	return nil
+}
+
+// AddDepartment gives the person the department of a credit they got,
+// whatever version they are at.
+func (r *PersonRepository) AddDepartment(id primitive.ObjectID, department string) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		r.scoped(bson.M{"_id": id}),
+		bumpVersion(bson.M{"$addToSet": bson.M{"departments": department}}),
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return r.notFound()
+	}
+	return nil
+}
+
+// Delete moves the person to the trash. Through the actor or director
+// repository it only drops that department, unless it is the last one the
+// person has, in which case they go to the trash as they are.
+func (r *PersonRepository) Delete(id primitive.ObjectID, version int64, by string) error {
+	if r.department != "" {
+		res, err := r.collection.UpdateOne(context.Background(),
+			withVersion(r.scoped(bson.M{"_id": id, "departments.1": bson.M{"$exists": true}}), version),
+			bumpVersion(bson.M{"$pull": bson.M{"departments": r.department}}),
+		)
+		if err != nil {
+			return err
+		}
+		if res.MatchedCount > 0 {
+			return nil
+		}
+	}
+
+	res, err := softDelete(r.collection, withVersion(r.scoped(bson.M{"_id": id}), version), by)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.collection, r.scoped(bson.M{"_id": id}), version, r.notFound())
+	}
+	return nil
+}
+
+// Restore writes a stored snapshot back as the whole document, recreating it
+// if it was deleted. It refuses to write over a document in the trash.
+func (r *PersonRepository) Restore(id primitive.ObjectID, doc bson.M) error {
+	version, err := restoredVersion(r.collection, id, doc)
+	if err != nil {
+		return err
+	}
+	doc["version"] = version
+	if err := r.slugs.keep(id, doc); err != nil {
+		return err
+	}
+	_, err = r.collection.ReplaceOne(context.Background(), live(bson.M{"_id": id}), doc, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	return r.slugs.refresh(id)
+}
+
+// MergeFunc works out the merged person and the audit entry of a merge from
+// the records as they are read inside the merge transaction.
+type MergeFunc func(survivor *model.Person, duplicates []model.Person) (*model.Person, *model.AuditEntry)
+
+// Merge folds the duplicates into the survivor in a single transaction: both
+// are read, the survivor is rewritten, movie credits are moved over, the
+// duplicates are replaced by redirects and the audit entry is stored. The
+// duplicates' slugs become aliases of the survivor so links to them keep
+// working. Mongo only runs transactions on a replica set or sharded cluster,
+// so merging needs one; on a standalone server the merge fails untouched.
+func (r *PersonRepository) Merge(
+	survivorID primitive.ObjectID,
+	duplicates []primitive.ObjectID,
+	merge MergeFunc,
+) (*model.Person, error) {
+	ctx := context.Background()
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.EndSession(ctx)
+
+	var merged *model.Person
+	var aliases []string
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		survivor, err := r.find(sc, survivorID)
+		if err != nil {
+			return nil, err
+		}
+		// The survivor may itself have been merged into one of the duplicates.
+		for _, id := range duplicates {
+			if id == survivor.ID {
+				return nil, fmt.Errorf("%w: %s was merged into %s", model.ErrInvalidDocument, survivorID.Hex(), id.Hex())
+			}
+		}
+		cursor, err := r.collection.Find(sc, r.scoped(bson.M{"_id": bson.M{"$in": duplicates}}))
+		if err != nil {
+			return nil, err
+		}
+		var records []model.Person
+		if err := cursor.All(sc, &records); err != nil {
+			return nil, err
+		}
+		if len(records) != len(duplicates) {
+			return nil, r.notFound()
+		}
+
+		var audit *model.AuditEntry
+		merged, audit = merge(survivor, records)
+		merged.Version = survivor.Version + 1
+		aliases = aliases[:0]
+		for _, d := range records {
+			if d.Slug != "" {
+				aliases = append(aliases, d.Slug)
+			}
+			aliases = append(aliases, d.SlugAliases...)
+		}
+
+		res, err := r.collection.ReplaceOne(sc, bson.M{"_id": merged.ID}, merged)
+		if err != nil {
+			return nil, err
+		}
+		if res.MatchedCount == 0 {
+			return nil, r.notFound()
+		}
+
+		if _, err := r.collection.DeleteMany(sc, bson.M{"_id": bson.M{"$in": duplicates}}); err != nil {
+			return nil, err
+		}
+
+		cursor, err = r.movies.Find(sc, bson.M{"$or": []bson.M{
+			{"director_id": bson.M{"$in": duplicates}},
+			{"actors": bson.M{"$in": duplicates}},
+			{"crew.person_id": bson.M{"$in": duplicates}},
+		}})
+		if err != nil {
+			return nil, err
+		}
+		var movies []model.Movie
+		if err := cursor.All(sc, &movies); err != nil {
+			return nil, err
+		}
+		for i := range movies {
+			m := &movies[i]
+			m.ReplacePeople(duplicates, merged.ID)
+			_, err := r.movies.UpdateOne(sc, bson.M{"_id": m.ID}, bumpVersion(bson.M{"$set": bson.M{
+				"director_id": m.DirectorID,
+				"actors":      m.Actors,
+				"crew":        m.Crew,
+			}}))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		awards, err := r.mergeAwards(sc, duplicates, merged.ID)
+		if err != nil {
+			return nil, err
+		}
+		series, err := r.mergeSeries(sc, duplicates, merged.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Earlier merges may have pointed at one of the duplicates.
+		_, err = r.redirects.UpdateMany(sc,
+			bson.M{"target_id": bson.M{"$in": duplicates}},
+			bson.M{"$set": bson.M{"target_id": merged.ID}},
+		)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range duplicates {
+			redirect := model.PersonRedirect{ID: id, TargetID: merged.ID, MergedAt: audit.At}
+			_, err := r.redirects.ReplaceOne(sc, bson.M{"_id": id}, redirect, options.Replace().SetUpsert(true))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		audit.Details["movies_updated"] = len(movies)
+		audit.Details["awards_updated"] = awards
+		audit.Details["series_updated"] = series
+		_, err = r.audit.InsertOne(sc, audit)
+		return nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.slugs.absorb(merged.ID, aliases); err != nil {
+		return nil, err
+	}
+	if err := r.slugs.refresh(merged.ID); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func (r *PersonRepository) mergeAwards(sc mongo.SessionContext, duplicates []primitive.ObjectID, to primitive.ObjectID) (int, error) {
+	cursor, err := r.awards.Find(sc, bson.M{"nominees.person_id": bson.M{"$in": duplicates}})
+	if err != nil {
+		return 0, err
+	}
+	var awards []model.Award
+	if err := cursor.All(sc, &awards); err != nil {
+		return 0, err
+	}
+	for i := range awards {
+		a := &awards[i]
+		a.ReplacePeople(duplicates, to)
+		_, err := r.awards.UpdateOne(sc, bson.M{"_id": a.ID}, bumpVersion(bson.M{"$set": bson.M{
+			"nominees": a.Nominees,
+		}}))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(awards), nil
+}
+
+func (r *PersonRepository) mergeSeries(sc mongo.SessionContext, duplicates []primitive.ObjectID, to primitive.ObjectID) (int, error) {
+	cursor, err := r.series.Find(sc, bson.M{"$or": []bson.M{
+		{"crew.person_id": bson.M{"$in": duplicates}},
+		{"seasons.episodes.crew.person_id": bson.M{"$in": duplicates}},
+	}})
+	if err != nil {
+		return 0, err
+	}
+	var series []model.Series
+	if err := cursor.All(sc, &series); err != nil {
+		return 0, err
+	}
+	for i := range series {
+		s := &series[i]
+		s.ReplacePeople(duplicates, to)
+		_, err := r.series.UpdateOne(sc, bson.M{"_id": s.ID}, bumpVersion(bson.M{"$set": bson.M{
+			"crew":    s.Crew,
+			"seasons": s.Seasons,
+		}}))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(series), nil
+}
+
+// ResolveSlug finds the person a slug belongs to, now or before a rename or
+// merge. It is not scoped to the department; GetByID is.
+func (r *PersonRepository) ResolveSlug(slug string) (*model.SlugMatch, error) {
+	return r.slugs.resolve(slug)
+}
+
+// BackfillSlugs gives a slug to everybody stored before slugs existed.
+func (r *PersonRepository) BackfillSlugs() error {
+	return r.slugs.backfill()
+}
+
+// Trashed lists the people in the trash, oldest deletion first, whatever
+// their department.
+func (r *PersonRepository) Trashed() ([]model.Person, error) {
+	people := []model.Person{}
+	return people, findTrashed(r.collection, time.Time{}, &people)
+}
+
+func (r *PersonRepository) GetTrashed(id primitive.ObjectID) (*model.Person, error) {
+	var person model.Person
+	err := r.collection.FindOne(context.Background(), trashed(bson.M{"_id": id})).Decode(&person)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%s not found in trash with given id", r.entity)
+	}
+	return &person, err
+}
+
+// Undelete takes the person out of the trash.
+func (r *PersonRepository) Undelete(id primitive.ObjectID) error {
+	found, err := undelete(r.collection, id)
+	if err == nil && !found {
+		return fmt.Errorf("%s not found in trash with given id", r.entity)
+	}
+	return err
+}
+
+// Purge removes the people deleted before the cutoff for good and returns
+// them.
+func (r *PersonRepository) Purge(cutoff time.Time) ([]model.Person, error) {
+	people := []model.Person{}
+	if err := findTrashed(r.collection, cutoff, &people); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(people))
+	for i := range people {
+		ids[i] = people[i].ID
+	}
+	return people, purge(r.collection, ids)
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IQualityRepository reads what the data-quality check looks at. Unlike the
+// other repositories Movies and People include the trash: a credit of a
+// deleted person is not dangling, and a person credited on a deleted movie
+// is not idle, restoring either brings the credit back.
+type IQualityRepository interface {
+	Movies() ([]model.Movie, error)
+	People() ([]model.Person, error)
+	SeriesPeople() ([]primitive.ObjectID, error)
+}
+
+type QualityRepository struct {
+	movies *mongo.Collection
+	people *mongo.Collection
+	series *mongo.Collection
+}
+
+func NewQualityRepository(db *mongo.Database) IQualityRepository {
+	return &QualityRepository{
+		movies: db.Collection("movie"),
+		people: db.Collection("people"),
+		series: db.Collection("series"),
+	}
+}
+
+func (r *QualityRepository) Movies() ([]model.Movie, error) {
+	cursor, err := r.movies.Find(context.Background(), bson.M{},
+		options.Find().SetSort(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	movies := []model.Movie{}
+	if err := cursor.All(context.Background(), &movies); err != nil {
+		return nil, err
+	}
+	return movies, nil
+}
+
+func (r *QualityRepository) People() ([]model.Person, error) {
+	cursor, err := r.people.Find(context.Background(), bson.M{},
+		options.Find().SetSort(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	people := []model.Person{}
+	if err := cursor.All(context.Background(), &people); err != nil {
+		return nil, err
+	}
+	return people, nil
+}
+
+// SeriesPeople lists everybody credited on a series or one of its episodes.
+func (r *QualityRepository) SeriesPeople() ([]primitive.ObjectID, error) {
+	var ids []primitive.ObjectID
+	for _, field := range []string{"crew.person_id", "seasons.episodes.crew.person_id"} {
+		values, err := r.series.Distinct(context.Background(), field, bson.M{})
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			if id, ok := v.(primitive.ObjectID); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IRatingRepository interface {
+	Rate(rating *model.Rating) error
+	Delete(user string, movieID primitive.ObjectID) error
+	GetByUser(user string) ([]model.Rating, error)
+	TopRated(exclude []primitive.ObjectID, limit int64) ([]model.MovieRating, error)
+}
+
+type RatingRepository struct {
+	collection *mongo.Collection
+}
+
+// NewRatingRepository also makes sure a user can rate a movie only once.
+func NewRatingRepository(db *mongo.Database) IRatingRepository {
+	collection := db.Collection("ratings")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}, {Key: "movie_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &RatingRepository{collection: collection}
+}
+
+// Rate stores the rating, replacing the user's earlier score for the movie.
+func (r *RatingRepository) Rate(rating *model.Rating) error {
+	res := r.collection.FindOneAndUpdate(context.Background(),
+		bson.M{"user": rating.User, "movie_id": rating.MovieID},
+		bson.M{"$set": bson.M{"score": rating.Score, "rated_at": rating.RatedAt}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	return res.Decode(rating)
+}
+
+func (r *RatingRepository) Delete(user string, movieID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(context.Background(), bson.M{"user": user, "movie_id": movieID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("rating not found for given movie")
+	}
+	return nil
+}
+
+func (r *RatingRepository) GetByUser(user string) ([]model.Rating, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "rated_at", Value: -1}})
+	cursor, err := r.collection.Find(context.Background(), bson.M{"user": user}, opts)
+	if err != nil {
+		return nil, err
+	}
+	ratings := []model.Rating{}
+	if err := cursor.All(context.Background(), &ratings); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+// TopRated ranks movies by their average rating, more ratings first on ties.
+func (r *RatingRepository) TopRated(exclude []primitive.ObjectID, limit int64) ([]model.MovieRating, error) {
+	if exclude == nil {
+		exclude = []primitive.ObjectID{}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"movie_id": bson.M{"$nin": exclude}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$movie_id",
+			"average": bson.M{"$avg": "$score"},
+			"count":   bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "average", Value: -1}, {Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := r.collection.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	rated := []model.MovieRating{}
+	if err := cursor.All(context.Background(), &rated); err != nil {
+		return nil, err
+	}
+	return rated, nil
+}
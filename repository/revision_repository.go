@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IRevisionRepository interface {
+	Create(revision *model.Revision) error
+	GetByID(id primitive.ObjectID) (*model.Revision, error)
+	GetByEntity(entity string, entityID primitive.ObjectID) ([]model.Revision, error)
+}
+
+type RevisionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRevisionRepository(db *mongo.Database) IRevisionRepository {
+	return &RevisionRepository{collection: db.Collection("revisions")}
+}
+
+func (r *RevisionRepository) Create(revision *model.Revision) error {
+	revision.ID = primitive.NewObjectID()
+	_, err := r.collection.InsertOne(context.Background(), revision)
+	return err
+}
+
+func (r *RevisionRepository) GetByID(id primitive.ObjectID) (*model.Revision, error) {
+	var revision model.Revision
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&revision)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, model.NotFound("revision")
+	}
+	return &revision, err
+}
+
+// GetByEntity returns the history of one document, newest first.
+func (r *RevisionRepository) GetByEntity(entity string, entityID primitive.ObjectID) ([]model.Revision, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "at", Value: -1}, {Key: "_id", Value: -1}})
+	cursor, err := r.collection.Find(context.Background(), bson.M{
+		"entity":    entity,
+		"entity_id": entityID,
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	revisions := []model.Revision{}
+	if err := cursor.All(context.Background(), &revisions); err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
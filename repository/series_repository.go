@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ISeriesRepository interface {
+	Create(series *model.Series) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Series, error)
+	GetAll() ([]model.Series, error)
+	GetByPerson(personID primitive.ObjectID, department string) ([]model.Series, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+	Delete(id primitive.ObjectID, version int64) error
+	Restore(id primitive.ObjectID, doc bson.M) error
+}
+
+type SeriesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewSeriesRepository(db *mongo.Database) ISeriesRepository {
+	collection := db.Collection("series")
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "crew.person_id", Value: 1}}},
+		{Keys: bson.D{{Key: "seasons.episodes.crew.person_id", Value: 1}}},
+	})
+	return &SeriesRepository{collection: collection}
+}
+
+func (r *SeriesRepository) notFound() error {
+	return model.NotFound("series")
+}
+
+func (r *SeriesRepository) Create(series *model.Series) (primitive.ObjectID, error) {
+	series.ID = primitive.NewObjectID()
+	series.Version = 1
+	_, err := r.collection.InsertOne(context.Background(), series)
+	return series.ID, err
+}
+
+func (r *SeriesRepository) GetByID(id primitive.ObjectID) (*model.Series, error) {
+	var series model.Series
+	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&series)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, r.notFound()
+	}
+	return &series, err
+}
+
+func (r *SeriesRepository) GetAll() ([]model.Series, error) {
+	return r.find(bson.M{})
+}
+
+// GetByPerson returns the series the person is credited on in the
+// department, on the whole show or on any of its episodes.
+func (r *SeriesRepository) GetByPerson(personID primitive.ObjectID, department string) ([]model.Series, error) {
+	credit := bson.M{"$elemMatch": bson.M{"person_id": personID, "department": department}}
+	return r.find(bson.M{"$or": bson.A{
+		bson.M{"crew": credit},
+		bson.M{"seasons.episodes.crew": credit},
+	}})
+}
+
+func (r *SeriesRepository) find(filter bson.M) ([]model.Series, error) {
+	cursor, err := r.collection.Find(context.Background(), filter, options.Find().SetSort(bson.M{"title": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	series := []model.Series{}
+	if err := cursor.All(context.Background(), &series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Update applies the changes only if the series is still at the given
+// version and moves it to the next one. Season and episode changes replace
+// the seasons field as a whole, so they are checked the same way.
+func (r *SeriesRepository) Update(id primitive.ObjectID, update bson.M, version int64) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		withVersion(bson.M{"_id": id}, version),
+		bumpVersion(bson.M{"$set": update}),
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return missedWrite(r.collection, bson.M{"_id": id}, version, r.notFound())
+	}
+	return nil
+}
+
+func (r *SeriesRepository) Delete(id primitive.ObjectID, version int64) error {
+	res, err := r.collection.DeleteOne(context.Background(), withVersion(bson.M{"_id": id}, version))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return missedWrite(r.collection, bson.M{"_id": id}, version, r.notFound())
+	}
+	return nil
+}
+
+// Restore writes a stored snapshot back as the whole document, recreating it
+// if it was deleted.
+func (r *SeriesRepository) Restore(id primitive.ObjectID, doc bson.M) error {
+	version, err := restoredVersion(r.collection, id, doc)
+	if err != nil {
+		return err
+	}
+	doc["version"] = version
+	_, err = r.collection.ReplaceOne(context.Background(), bson.M{"_id": id}, doc, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// slugs hands out the URL slugs of one collection. A slug is unique among
+// both the current slugs and the aliases left behind by renames, so an old
+// link never starts pointing at a different document.
+type slugs[T any] struct {
+	collection *mongo.Collection
+	entity     string
+	base       func(doc *T) string
+	sources    []string
+}
+
+// slugFields are the slug fields of any document with slugs.
+type slugFields struct {
+	Slug    string   `bson:"slug"`
+	Aliases []string `bson:"slug_aliases"`
+}
+
+// newSlugs manages the slugs of collection, each made by base from the
+// document's sources fields.
+func newSlugs[T any](
+	collection *mongo.Collection,
+	entity string,
+	base func(doc *T) string,
+	sources ...string,
+) *slugs[T] {
+	collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$exists": true}}),
+		},
+		{
+			Keys: bson.D{{Key: "slug_aliases", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug_aliases": bson.M{"$exists": true}}),
+		},
+	})
+	return &slugs[T]{collection: collection, entity: entity, base: base, sources: sources}
+}
+
+func taken(slug string) bson.M {
+	return bson.M{"$or": bson.A{bson.M{"slug": slug}, bson.M{"slug_aliases": slug}}}
+}
+
+// unique returns the first free slug for base. Slugs the document itself
+// holds as aliases count as free, so renaming it back reclaims its old slug.
+func (s *slugs[T]) unique(base string, self primitive.ObjectID) (string, error) {
+	for n := 1; ; n++ {
+		slug := model.NumberedSlug(base, n)
+		if model.LooksLikeID(slug) {
+			continue
+		}
+		filter := taken(slug)
+		filter["_id"] = bson.M{"$ne": self}
+		count, err := s.collection.CountDocuments(context.Background(), filter)
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return slug, nil
+		}
+	}
+}
+
+// assign picks the slug for a document about to be created.
+func (s *slugs[T]) assign(doc *T, id primitive.ObjectID) (string, error) {
+	return s.unique(s.base(doc), id)
+}
+
+// touches reports whether an update changes anything the slug is made of.
+func (s *slugs[T]) touches(update bson.M) bool {
+	for _, field := range s.sources {
+		if _, ok := update[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// refresh gives the document a new slug when the one it has was made from
+// something else, keeping the old slug as an alias.
+func (s *slugs[T]) refresh(id primitive.ObjectID) error {
+	raw, err := s.collection.FindOne(context.Background(), bson.M{"_id": id}).Raw()
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var doc T
+	var current slugFields
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	if err := bson.Unmarshal(raw, &current); err != nil {
+		return err
+	}
+
+	base := s.base(&doc)
+	if current.Slug != "" && model.SlugHasBase(current.Slug, base) {
+		return nil
+	}
+	slug, err := s.unique(base, id)
+	if err != nil {
+		return err
+	}
+	aliases := []string{}
+	for _, alias := range current.Aliases {
+		if alias != slug {
+			aliases = append(aliases, alias)
+		}
+	}
+	if current.Slug != "" {
+		aliases = append(aliases, current.Slug)
+	}
+	return s.write(id, slug, aliases)
+}
+
+func (s *slugs[T]) write(id primitive.ObjectID, slug string, aliases []string) error {
+	update := bson.M{"$set": bson.M{"slug": slug}}
+	if len(aliases) > 0 {
+		update["$set"].(bson.M)["slug_aliases"] = aliases
+	} else {
+		update["$unset"] = bson.M{"slug_aliases": ""}
+	}
+	_, err := s.collection.UpdateOne(context.Background(), bson.M{"_id": id}, update)
+	return err
+}
+
+// keep carries the stored slugs over into a snapshot about to replace the
+// document, so restoring an old revision does not bring back a slug that
+// has since moved on or been given to somebody else.
+func (s *slugs[T]) keep(id primitive.ObjectID, doc bson.M) error {
+	delete(doc, "slug")
+	delete(doc, "slug_aliases")
+	var current slugFields
+	err := s.collection.FindOne(context.Background(), bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"slug": 1, "slug_aliases": 1}),
+	).Decode(&current)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if current.Slug != "" {
+		doc["slug"] = current.Slug
+	}
+	if len(current.Aliases) > 0 {
+		doc["slug_aliases"] = current.Aliases
+	}
+	return nil
+}
+
+// absorb adds the slugs to the document's aliases.
+func (s *slugs[T]) absorb(id primitive.ObjectID, aliases []string) error {
+	if len(aliases) == 0 {
+		return nil
+	}
+	_, err := s.collection.UpdateOne(context.Background(), bson.M{"_id": id},
+		bson.M{"$addToSet": bson.M{"slug_aliases": bson.M{"$each": aliases}}},
+	)
+	return err
+}
+
+// resolve finds the document a slug belongs to, by its current slug or by
+// one of its aliases.
+func (s *slugs[T]) resolve(slug string) (*model.SlugMatch, error) {
+	var match model.SlugMatch
+	err := s.collection.FindOne(context.Background(), taken(slug),
+		options.FindOne().SetProjection(bson.M{"slug": 1}),
+	).Decode(&match)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%s not found with given slug", s.entity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	match.Alias = match.Slug != slug
+	return &match, nil
+}
+
+// backfill gives a slug to every document stored before slugs existed.
+func (s *slugs[T]) backfill() error {
+	cursor, err := s.collection.Find(context.Background(), bson.M{"slug": bson.M{"$exists": false}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var ids []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(context.Background(), &ids); err != nil {
+		return err
+	}
+	for _, doc := range ids {
+		if err := s.refresh(doc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
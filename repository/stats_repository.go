@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"gin-demo/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type IStatsRepository interface {
+	ReleaseYears(filter model.StatsFilter) (*model.ReleaseStats, error)
+	ProlificPeople(filter model.StatsFilter, department string, limit int64) ([]model.ProlificPerson, error)
+	CastSize(filter model.StatsFilter) (*model.CastSizeStats, error)
+	Partnerships(filter model.StatsFilter, limit int64) ([]model.Partnership, error)
+	Growth(filter model.StatsFilter, interval string) ([]model.GrowthPoint, int, error)
+}
+
+type StatsRepository struct {
+	movies *mongo.Collection
+}
+
+func NewStatsRepository(db *mongo.Database) IStatsRepository {
+	return &StatsRepository{movies: db.Collection("movie")}
+}
+
+// matchStats turns the filter into a query. Movies carry no creation date,
+// the time a movie was added is the one embedded in its ObjectID. Statistics
+// are shared by everybody, so they only ever count published movies.
+func matchStats(filter model.StatsFilter) bson.M {
+	match := published(live(bson.M{}))
+	added := bson.M{}
+	if !filter.AddedFrom.IsZero() {
+		added["$gte"] = primitive.NewObjectIDFromTimestamp(filter.AddedFrom)
+	}
+	if !filter.AddedTo.IsZero() {
+		added["$lt"] = primitive.NewObjectIDFromTimestamp(filter.AddedTo)
+	}
+	if len(added) > 0 {
+		match["_id"] = added
+	}
+	released := bson.M{}
+	if filter.FromYear > 0 {
+		released["$gte"] = filter.FromYear
+	}
+	if filter.ToYear > 0 {
+		released["$lte"] = filter.ToYear
+	}
+	if len(released) > 0 {
+		match["release_year"] = released
+	}
+	return match
+}
+
+// creditsIn is the expression listing the people credited in a department.
+func creditsIn(department string) bson.M {
+	return bson.M{"$map": bson.M{
+		"input": bson.M{"$filter": bson.M{
+			"input": bson.M{"$ifNull": bson.A{"$crew", bson.A{}}},
+			"cond":  bson.M{"$eq": bson.A{"$$this.department", department}},
+		}},
+		"in": "$$this.person_id",
+	}}
+}
+
+func (r *StatsRepository) ReleaseYears(filter model.StatsFilter) (*model.ReleaseStats, error) {
+	year := "$release_year"
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchStats(filter)}},
+		{{Key: "$match", Value: bson.M{"release_year": bson.M{"$gt": 0}}}},
+		{{Key: "$facet", Value: bson.M{
+			"years": bson.A{
+				bson.M{"$group": bson.M{"_id": year, "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+			"decades": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":   bson.M{"$subtract": bson.A{year, bson.M{"$mod": bson.A{year, 10}}}},
+					"count": bson.M{"$sum": 1},
+				}},
+				bson.M{"$sort": bson.M{"_id": 1}},
+			},
+		}}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var result []struct {
+		Years   []model.YearCount `bson:"years"`
+		Decades []model.YearCount `bson:"decades"`
+	}
+	if err := cursor.All(context.Background(), &result); err != nil {
+		return nil, err
+	}
+
+	stats := &model.ReleaseStats{Years: []model.YearCount{}, Decades: []model.YearCount{}}
+	if len(result) > 0 {
+		stats.Years = append(stats.Years, result[0].Years...)
+		stats.Decades = append(stats.Decades, result[0].Decades...)
+	}
+	return stats, nil
+}
+
+// ProlificPeople ranks people by the number of movies they are credited on
+// in the department. People in the trash are left out of the ranking.
+func (r *StatsRepository) ProlificPeople(
+	filter model.StatsFilter,
+	department string,
+	limit int64,
+) ([]model.ProlificPerson, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchStats(filter)}},
+		{{Key: "$project", Value: bson.M{"people": bson.M{"$setUnion": bson.A{creditsIn(department)}}}}},
+		{{Key: "$unwind", Value: "$people"}},
+		{{Key: "$group", Value: bson.M{"_id": "$people", "movies": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "movies", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$lookup", Value: liveLookup("people", "_id", "person")}},
+		{{Key: "$unwind", Value: "$person"}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	people := []model.ProlificPerson{}
+	if err := cursor.All(context.Background(), &people); err != nil {
+		return nil, err
+	}
+	return people, nil
+}
+
+func (r *StatsRepository) CastSize(filter model.StatsFilter) (*model.CastSizeStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchStats(filter)}},
+		{{Key: "$project", Value: bson.M{
+			"cast": bson.M{"$size": bson.M{"$setUnion": bson.A{creditsIn(model.DepartmentActing)}}},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"movies":  bson.M{"$sum": 1},
+			"average": bson.M{"$avg": "$cast"},
+			"min":     bson.M{"$min": "$cast"},
+			"max":     bson.M{"$max": "$cast"},
+		}}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var result []model.CastSizeStats
+	if err := cursor.All(context.Background(), &result); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return &model.CastSizeStats{}, nil
+	}
+	return &result[0], nil
+}
+
+// Partnerships ranks director and actor pairs by the movies they made
+// together. Directors acting in their own movies are not a partnership, and
+// neither is a pair one of whom is in the trash.
+func (r *StatsRepository) Partnerships(filter model.StatsFilter, limit int64) ([]model.Partnership, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchStats(filter)}},
+		{{Key: "$project", Value: bson.M{
+			"directors": bson.M{"$setUnion": bson.A{creditsIn(model.DepartmentDirecting)}},
+			"actors":    bson.M{"$setUnion": bson.A{creditsIn(model.DepartmentActing)}},
+		}}},
+		{{Key: "$unwind", Value: "$directors"}},
+		{{Key: "$unwind", Value: "$actors"}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$ne": bson.A{"$directors", "$actors"}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    bson.M{"director_id": "$directors", "actor_id": "$actors"},
+			"movies": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{
+			{Key: "movies", Value: -1},
+			{Key: "_id.director_id", Value: 1},
+			{Key: "_id.actor_id", Value: 1},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":         0,
+			"director_id": "$_id.director_id",
+			"actor_id":    "$_id.actor_id",
+			"movies":      1,
+		}}},
+		{{Key: "$lookup", Value: liveLookup("people", "director_id", "director")}},
+		{{Key: "$lookup", Value: liveLookup("people", "actor_id", "actor")}},
+		{{Key: "$unwind", Value: "$director"}},
+		{{Key: "$unwind", Value: "$actor"}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	pairs := []model.Partnership{}
+	if err := cursor.All(context.Background(), &pairs); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+// Growth counts the movies added per period. It also returns how many movies
+// matching the release year filter were added before the range starts, so
+// the running total can start from the real catalog size.
+func (r *StatsRepository) Growth(filter model.StatsFilter, interval string) ([]model.GrowthPoint, int, error) {
+	format := "%Y-%m"
+	if interval == model.GrowthByYear {
+		format = "%Y"
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: matchStats(filter)}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"$dateToString": bson.M{
+				"format": format,
+				"date":   bson.M{"$toDate": "$_id"},
+			}},
+			"added": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.movies.Aggregate(context.Background(), pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	points := []model.GrowthPoint{}
+	if err := cursor.All(context.Background(), &points); err != nil {
+		return nil, 0, err
+	}
+
+	if filter.AddedFrom.IsZero() {
+		return points, 0, nil
+	}
+	earlier := filter
+	earlier.AddedFrom, earlier.AddedTo = time.Time{}, filter.AddedFrom
+	before, err := r.movies.CountDocuments(context.Background(), matchStats(earlier))
+	if err != nil {
+		return nil, 0, err
+	}
+	return points, int(before), nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// live leaves soft-deleted documents out of a movie, person or user query.
+// Every read and count of those collections goes through it; only the trash
+// methods below look at deleted documents.
+func live(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": false}
+	return filter
+}
+
+// trashed matches the soft-deleted documents of a query.
+func trashed(filter bson.M) bson.M {
+	filter["deleted_at"] = bson.M{"$exists": true}
+	return filter
+}
+
+// liveLookup is a $lookup of the document localField points to that leaves
+// soft-deleted documents out, so a credit to someone in the trash finds no
+// one.
+func liveLookup(from, localField, as string) bson.M {
+	return bson.M{
+		"from": from,
+		"let":  bson.M{"id": "$" + localField},
+		"pipeline": bson.A{
+			bson.M{"$match": live(bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$id"}}})},
+		},
+		"as": as,
+	}
+}
+
+// softDelete moves the document matched by filter to the trash, recording
+// who did it, and moves it to the next version.
+func softDelete(collection *mongo.Collection, filter bson.M, by string) (*mongo.UpdateResult, error) {
+	return collection.UpdateOne(context.Background(), filter, bumpVersion(bson.M{"$set": bson.M{
+		"deleted_at": time.Now().UTC(),
+		"deleted_by": by,
+	}}))
+}
+
+// undelete takes the document out of the trash. It reports whether there was
+// a deleted document with the id.
+func undelete(collection *mongo.Collection, id primitive.ObjectID) (bool, error) {
+	res, err := collection.UpdateOne(context.Background(),
+		trashed(bson.M{"_id": id}),
+		bumpVersion(bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}}),
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// findTrashed decodes the documents deleted before the cutoff, oldest first,
+// or all of them when the cutoff is zero.
+func findTrashed(collection *mongo.Collection, cutoff time.Time, out interface{}) error {
+	filter := trashed(bson.M{})
+	if !cutoff.IsZero() {
+		filter["deleted_at"] = bson.M{"$lt": cutoff}
+	}
+	cursor, err := collection.Find(context.Background(), filter,
+		options.Find().SetSort(bson.M{"deleted_at": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	return cursor.All(context.Background(), out)
+}
+
+// purge removes the documents for good, as long as they are still in the
+// trash.
+func purge(collection *mongo.Collection, ids []primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := collection.DeleteMany(context.Background(), trashed(bson.M{"_id": bson.M{"$in": ids}}))
+	return err
+}
@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"gin-demo/model"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 /*
@@ -75,6 +77,15 @@ type IUserRepository interface {
 	FindByEmail(email string) (*model.User, error)
 	FindById(id primitive.ObjectID) (*model.User, error)
 	FindAll(email string) []model.User
+	SetParentalControl(email string, control *model.ParentalControl) error
+	SetMemberships(id primitive.ObjectID, memberships []model.Membership) error
+	FindByOrganization(organization string) ([]model.User, error)
+	BackfillMemberships() error
+	Delete(id primitive.ObjectID, by string) error
+	Trashed() ([]model.User, error)
+	GetTrashed(id primitive.ObjectID) (*model.User, error)
+	Undelete(id primitive.ObjectID) error
+	Purge(cutoff time.Time) ([]model.User, error)
 }
 
 type UserRepository struct {
@@ -87,6 +98,8 @@ func NewUserRepository(db *mongo.Database) IUserRepository {
 	}
 }
 
+// CreateUser keeps usernames and emails unique among deleted users too, so
+// that restoring one never clashes with somebody who signed up since.
 func (r *UserRepository) CreateUser(user *model.User) error {
 	var existing model.User
 
@@ -106,13 +119,19 @@ func (r *UserRepository) CreateUser(user *model.User) error {
 		return err
 	}
 
-	_, err = r.collection.InsertOne(context.Background(), user)
-	return err
+	res, err := r.collection.InsertOne(context.Background(), user)
+	if err != nil {
+		return err
+	}
+	if id, ok := res.InsertedID.(primitive.ObjectID); ok {
+		user.ObjectID = id
+	}
+	return nil
 }
 
 func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 	var user model.User
-	err := r.collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
+	err := r.collection.FindOne(context.Background(), live(bson.M{"email": email})).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +140,7 @@ func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 
 func (r *UserRepository) FindById(id primitive.ObjectID) (*model.User, error) {
 	var user model.User
-	err := r.collection.FindOne(context.Background(), bson.M{"_id": id}).Decode(&user)
+	err := r.collection.FindOne(context.Background(), live(bson.M{"_id": id})).Decode(&user)
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +149,7 @@ func (r *UserRepository) FindById(id primitive.ObjectID) (*model.User, error) {
 }
 
 func (r *UserRepository) FindAll(email string) []model.User {
-	filter := bson.M{"email": bson.M{"$ne": email}}
+	filter := live(bson.M{"email": bson.M{"$ne": email}})
 
 	cursor, err := r.collection.Find(context.Background(), filter)
 	if err != nil {
@@ -146,3 +165,137 @@ func (r *UserRepository) FindAll(email string) []model.User {
 
 	return users
 }
+
+// SetParentalControl stores the user's parental control, or removes it when
+// control is nil.
+func (r *UserRepository) SetParentalControl(email string, control *model.ParentalControl) error {
+	update := bson.M{"$set": bson.M{"parental_control": control}}
+	if control == nil {
+		update = bson.M{"$unset": bson.M{"parental_control": ""}}
+	}
+	res, err := r.collection.UpdateOne(context.Background(), live(bson.M{"email": email}), update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("user not found with given email")
+	}
+	return nil
+}
+
+// SetMemberships replaces the organizations the user belongs to and their
+// roles there.
+func (r *UserRepository) SetMemberships(id primitive.ObjectID, memberships []model.Membership) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		live(bson.M{"_id": id}),
+		bson.M{"$set": bson.M{"memberships": memberships}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return model.NotFound("user")
+	}
+	return nil
+}
+
+// FindByOrganization lists the members of the organization.
+func (r *UserRepository) FindByOrganization(organization string) ([]model.User, error) {
+	filter := bson.M{"memberships.organization": organization}
+	opts := options.Find().SetSort(bson.D{{Key: "email", Value: 1}})
+	cursor, err := r.collection.Find(context.Background(), live(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	users := []model.User{}
+	if err := cursor.All(context.Background(), &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// BackfillMemberships gives users stored before memberships existed one in
+// each organization they were listed in, or in the default organization when
+// there were none, with the role they had everywhere. Users in the trash get
+// theirs too, so a restore brings them back as they were.
+func (r *UserRepository) BackfillMemberships() error {
+	organizations := bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$organizations", bson.A{}}}}, 0}},
+		"$organizations",
+		bson.A{model.DefaultOrganization},
+	}}
+	_, err := r.collection.UpdateMany(context.Background(),
+		bson.M{"memberships": bson.M{"$exists": false}},
+		bson.A{
+			bson.M{"$set": bson.M{"memberships": bson.M{"$map": bson.M{
+				"input": organizations,
+				"in": bson.M{
+					"organization": "$$this",
+					"role":         bson.M{"$ifNull": bson.A{"$role", model.RoleViewer}},
+				},
+			}}}},
+			bson.M{"$unset": "organizations"},
+		},
+	)
+	return err
+}
+
+// Delete moves the user to the trash. They can no longer log in, and
+// tokens they still hold stop working.
+func (r *UserRepository) Delete(id primitive.ObjectID, by string) error {
+	res, err := r.collection.UpdateOne(context.Background(), live(bson.M{"_id": id}), bson.M{"$set": bson.M{
+		"deleted_at": time.Now().UTC(),
+		"deleted_by": by,
+	}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return model.NotFound("user")
+	}
+	return nil
+}
+
+// Trashed lists the users in the trash, oldest deletion first.
+func (r *UserRepository) Trashed() ([]model.User, error) {
+	users := []model.User{}
+	return users, findTrashed(r.collection, time.Time{}, &users)
+}
+
+func (r *UserRepository) GetTrashed(id primitive.ObjectID) (*model.User, error) {
+	var user model.User
+	err := r.collection.FindOne(context.Background(), trashed(bson.M{"_id": id})).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("user not found in trash with given id")
+	}
+	return &user, err
+}
+
+// Undelete takes the user out of the trash.
+func (r *UserRepository) Undelete(id primitive.ObjectID) error {
+	res, err := r.collection.UpdateOne(context.Background(),
+		trashed(bson.M{"_id": id}),
+		bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return fmt.Errorf("user not found in trash with given id")
+	}
+	return nil
+}
+
+// Purge removes the users deleted before the cutoff for good and returns
+// them.
+func (r *UserRepository) Purge(cutoff time.Time) ([]model.User, error) {
+	users := []model.User{}
+	if err := findTrashed(r.collection, cutoff, &users); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, len(users))
+	for i := range users {
+		ids[i] = users[i].ObjectID
+	}
+	return users, purge(r.collection, ids)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gin-demo/model"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// withVersion adds the expected version to a write filter so the check and
+// the write happen in one atomic operation. Documents written before versions
+// were introduced have no version field and count as version 0.
+func withVersion(filter bson.M, version int64) bson.M {
+	switch {
+	case version == model.AnyVersion:
+	case version == 0:
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	default:
+		filter["version"] = version
+	}
+	return filter
+}
+
+// bumpVersion adds the version increment to an update document.
+func bumpVersion(update bson.M) bson.M {
+	update["$inc"] = bson.M{"version": 1}
+	return update
+}
+
+// missedWrite tells apart why a versioned write matched nothing: the document
+// is either gone or was changed by somebody else in the meantime.
+func missedWrite(collection *mongo.Collection, filter bson.M, version int64, notFound error) error {
+	if version == model.AnyVersion {
+		return notFound
+	}
+	count, err := collection.CountDocuments(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return notFound
+	}
+	return model.ErrVersionMismatch
+}
+
+// restoredVersion is the version a restored snapshot is written with. It has
+// to move past both the stored document and the snapshot itself so that no
+// ETag handed out earlier matches the restored state. A document in the
+// trash is not restored over; it has to come back through the trash first.
+func restoredVersion(collection *mongo.Collection, id primitive.ObjectID, doc bson.M) (int64, error) {
+	var current struct {
+		Version   int64      `bson:"version"`
+		DeletedAt *time.Time `bson:"deleted_at"`
+	}
+	err := collection.FindOne(context.Background(), bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"version": 1, "deleted_at": 1}),
+	).Decode(&current)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, err
+	}
+	if current.DeletedAt != nil {
+		return 0, fmt.Errorf("%w: restore it from the trash before reverting", model.ErrInvalidDocument)
+	}
+	if v, ok := doc["version"].(int64); ok && v > current.Version {
+		current.Version = v
+	}
+	return current.Version + 1, nil
+}
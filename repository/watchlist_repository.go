@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type IWatchlistRepository interface {
+	Add(entry *model.WatchlistEntry) error
+	Remove(user string, movieID primitive.ObjectID) error
+	GetByUser(user string) ([]model.WatchlistEntry, error)
+}
+
+type WatchlistRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWatchlistRepository(db *mongo.Database) IWatchlistRepository {
+	collection := db.Collection("watchlist")
+	collection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.D{{Key: "user", Value: 1}, {Key: "movie_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return &WatchlistRepository{collection: collection}
+}
+
+// Add puts the movie on the user's watchlist. Adding it again keeps the
+// original entry.
+func (r *WatchlistRepository) Add(entry *model.WatchlistEntry) error {
+	res := r.collection.FindOneAndUpdate(context.Background(),
+		bson.M{"user": entry.User, "movie_id": entry.MovieID},
+		bson.M{"$setOnInsert": bson.M{"added_at": entry.AddedAt}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	)
+	return res.Decode(entry)
+}
+
+func (r *WatchlistRepository) Remove(user string, movieID primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(context.Background(), bson.M{"user": user, "movie_id": movieID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return fmt.Errorf("movie not found on the watchlist")
+	}
+	return nil
+}
+
+func (r *WatchlistRepository) GetByUser(user string) ([]model.WatchlistEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "added_at", Value: -1}})
+	cursor, err := r.collection.Find(context.Background(), bson.M{"user": user}, opts)
+	if err != nil {
+		return nil, err
+	}
+	entries := []model.WatchlistEntry{}
+	if err := cursor.All(context.Background(), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,245 @@
+package routes
+
+import (
+	"gin-demo/config"
+	"gin-demo/handler"
+	"gin-demo/middleware"
+	"gin-demo/model"
+	"gin-demo/redis_utils"
+	"gin-demo/repository"
+	"gin-demo/services"
+	"gin-demo/storage"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// catalogKey is where the request's catalog is kept once it is selected.
+const catalogKey = "catalog"
+
+// catalog is everything that serves one organization's catalog. It is built
+// on that organization's database only, so none of its handlers can read or
+// write another organization's documents.
+type catalog struct {
+	movies          *handler.MovieHandler
+	actors          *handler.ActorHandler
+	directors       *handler.DirectorHandler
+	people          *handler.PersonHandler
+	collections     *handler.CollectionHandler
+	series          *handler.SeriesHandler
+	awards          *handler.AwardHandler
+	images          *handler.ImageHandler
+	graph           *handler.GraphHandler
+	recommendations *handler.RecommendationHandler
+	stats           *handler.StatsHandler
+	trash           *handler.TrashHandler
+	imports         *handler.ImportHandler
+	exports         *handler.ExportHandler
+	quality         *handler.QualityHandler
+
+	movieSlug      gin.HandlerFunc
+	personSlug     gin.HandlerFunc
+	actorSlug      gin.HandlerFunc
+	directorSlug   gin.HandlerFunc
+	catalogChanged gin.HandlerFunc
+}
+
+// catalogs builds the catalog of every organization once and hands it out
+// to the requests working with it.
+type catalogs struct {
+	client *mongo.Client
+	name   string
+	files  storage.IStorage
+	users  repository.IUserRepository
+
+	mu    sync.Mutex
+	byOrg map[string]*catalog
+}
+
+func newCatalogs(db *mongo.Database, files storage.IStorage, users repository.IUserRepository) *catalogs {
+	return &catalogs{
+		client: db.Client(),
+		name:   db.Name(),
+		files:  files,
+		users:  users,
+		byOrg:  map[string]*catalog{},
+	}
+}
+
+func (cs *catalogs) get(organization string) *catalog {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if t, ok := cs.byOrg[organization]; ok {
+		return t
+	}
+	t := cs.build(organization)
+	cs.byOrg[organization] = t
+	return t
+}
+
+func (cs *catalogs) build(organization string) *catalog {
+	db := repository.TenantDatabase(cs.client, cs.name, organization)
+	files := cs.files
+	if organization != model.DefaultOrganization {
+		files = storage.NewPrefixedStorage(files, organization)
+	}
+	storageConfig := config.GetConfig().Storage
+
+	revisionService := services.NewRevisionService(repository.NewRevisionRepository(db))
+
+	movieRepo := repository.NewMovieRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	collectionRepo := repository.NewCollectionRepository(db)
+	awardRepo := repository.NewAwardRepository(db)
+	imageService := services.NewImageService(movieRepo, personRepo, collectionRepo, files, revisionService)
+
+	actorRepo := repository.NewActorRepository(db)
+	actorService := services.NewActorService(actorRepo, revisionService, imageService, awardRepo)
+
+	directorRepo := repository.NewDirectorRepository(db)
+	directorService := services.NewDirectorService(directorRepo, revisionService, imageService, awardRepo)
+
+	personService := services.NewPersonService(personRepo, revisionService, imageService, awardRepo)
+
+	movieHydrator := repository.NewMovieHydrator(personRepo, collectionRepo)
+	movieService := services.NewMovieService(movieRepo, movieHydrator, revisionService, imageService, awardRepo)
+
+	collectionService := services.NewCollectionService(
+		collectionRepo,
+		movieRepo,
+		movieHydrator,
+		revisionService,
+		imageService,
+	)
+
+	seriesService := services.NewSeriesService(
+		repository.NewSeriesRepository(db),
+		movieRepo,
+		personRepo,
+		revisionService,
+	)
+
+	awardService := services.NewAwardService(awardRepo, movieRepo, personRepo, revisionService)
+
+	graphRepo, err := repository.NewGraphRepository(db)
+	if err != nil {
+		log.Printf("could not index the credits of %s: %s", organization, err)
+	}
+	graphConfig := config.GetConfig().Graph
+	graphService := services.NewGraphService(graphRepo, actorRepo, graphConfig.Depth(), graphConfig.Frontier())
+
+	ratingRepo := repository.NewRatingRepository(db)
+	watchlistRepo := repository.NewWatchlistRepository(db)
+	recommendationService := services.NewRecommendationService(
+		movieRepo,
+		ratingRepo,
+		watchlistRepo,
+		redis_utils.GetRedisClient(),
+		config.GetConfig().Recommendations.CacheTTL(),
+		organization,
+	)
+
+	statsService := services.NewStatsService(
+		repository.NewStatsRepository(db),
+		redis_utils.GetRedisClient(),
+		config.GetConfig().Stats.CacheTTL(),
+		organization,
+	)
+
+	// Movies and people stored before slugs existed get theirs on startup.
+	if err := movieRepo.BackfillSlugs(); err != nil {
+		log.Printf("could not backfill movie slugs of %s: %s", organization, err)
+	}
+	if err := personRepo.BackfillSlugs(); err != nil {
+		log.Printf("could not backfill person slugs of %s: %s", organization, err)
+	}
+
+	trashService := services.NewTrashService(
+		movieRepo,
+		personRepo,
+		cs.users,
+		collectionRepo,
+		awardRepo,
+		imageService,
+		revisionService,
+		config.GetConfig().Trash.Retention(),
+		organization,
+	)
+	trashService.Schedule(config.GetConfig().Trash.PurgeInterval())
+
+	return &catalog{
+		movies:      handler.NewMovieHandler(movieService),
+		actors:      handler.NewActorHandler(actorService),
+		directors:   handler.NewDirectorHandler(directorService),
+		people:      handler.NewPersonHandler(personService),
+		collections: handler.NewCollectionHandler(collectionService),
+		series:      handler.NewSeriesHandler(seriesService),
+		awards:      handler.NewAwardHandler(awardService),
+		images:      handler.NewImageHandler(imageService, storageConfig.UploadLimit()),
+		graph:       handler.NewGraphHandler(graphService),
+		recommendations: handler.NewRecommendationHandler(
+			recommendationService,
+			services.NewRatingService(ratingRepo, movieRepo),
+			services.NewWatchlistService(watchlistRepo, movieRepo),
+		),
+		stats:   handler.NewStatsHandler(statsService),
+		trash:   handler.NewTrashHandler(trashService),
+		imports: handler.NewImportHandler(services.NewImportService(repository.NewImportRepository(db), revisionService)),
+		exports: handler.NewExportHandler(services.NewExportService(repository.NewExportRepository(db), personRepo, awardRepo)),
+		quality: handler.NewQualityHandler(services.NewQualityService(
+			repository.NewQualityRepository(db),
+			movieRepo,
+			personRepo,
+			revisionService,
+		)),
+
+		movieSlug:      middleware.SlugMiddleware("id", movieService.ResolveSlug),
+		personSlug:     middleware.SlugMiddleware("id", personService.ResolveSlug),
+		actorSlug:      middleware.SlugMiddleware("actorId", personService.ResolveSlug),
+		directorSlug:   middleware.SlugMiddleware("directorId", personService.ResolveSlug),
+		catalogChanged: middleware.CatalogChangeMiddleware(recommendationService.CatalogChanged),
+	}
+}
+
+// selectTenant hands the request the catalog of the organization
+// TenantMiddleware resolved. It has to run after TenantMiddleware.
+func (cs *catalogs) selectTenant(c *gin.Context) {
+	c.Set(catalogKey, cs.get(c.GetString(middleware.TenantKey)))
+	c.Next()
+}
+
+// selectParam hands the request the catalog of the organization named in
+// the path, for admins working on an organization they need not belong to.
+func (cs *catalogs) selectParam(param string, find func(slug string) (*model.Organization, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		org, err := find(c.Param(param))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.Set(catalogKey, cs.get(org.Slug))
+		c.Next()
+	}
+}
+
+func catalogOf(c *gin.Context) *catalog {
+	return c.MustGet(catalogKey).(*catalog)
+}
+
+// handle runs a handler of the request's catalog, picked by pick and called
+// as a method expression such as (*handler.MovieHandler).GetMovie.
+func handle[H any](pick func(*catalog) H, method func(H, *gin.Context)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method(pick(catalogOf(c)), c)
+	}
+}
+
+// use runs a middleware of the request's catalog.
+func use(pick func(*catalog) gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pick(catalogOf(c))(c)
+	}
+}
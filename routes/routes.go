@@ -1,72 +1,273 @@
 package routes
 
 import (
+	"gin-demo/config"
 	"gin-demo/handler"
 	"gin-demo/middleware"
 	"gin-demo/redis_utils"
 	"gin-demo/repository"
 	"gin-demo/services"
+	"gin-demo/storage"
+	"log"
 
 	"github.com/gin-gonic/gin"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// SetupRouter serves the shared routes, users and organizations, from db and
+// every catalog route from the catalog of the organization the request works
+// with.
 func SetupRouter(db *mongo.Database) *gin.Engine {
 	router := gin.Default()
 	router.SetTrustedProxies([]string{"0.0.0.0/0"})
 	jwtStrategy := middleware.NewJWTStrategy(redis_utils.GetRedisClient())
+	revisionRepo := repository.NewRevisionRepository(db)
+	revisionService := services.NewRevisionService(revisionRepo)
+
+	storageConfig := config.GetConfig().Storage
+	fileStorage, err := storage.NewStorage(storageConfig)
+	if err != nil {
+		log.Fatalf("could not set up file storage: %s", err)
+	}
+	if storageConfig.Driver == "" || storageConfig.Driver == "local" {
+		router.Static(storageConfig.Local.URLPrefix(), storageConfig.Local.Path())
+	}
+
 	userRepo := repository.NewUserRepository(db)
+	if err := userRepo.BackfillMemberships(); err != nil {
+		log.Printf("could not backfill user memberships: %s", err)
+	}
 	userService := services.NewUserService(userRepo, *jwtStrategy)
-	userServiceFacade := services.NewUserServiceFacade(userService)
+	userServiceFacade := services.NewUserServiceFacade(userService, revisionService)
 	userHandler := handler.NewHandler(*userServiceFacade)
+	// Users are shared, so they are purged here once rather than with each
+	// organization's trash.
+	userTrashService := services.NewUserTrashService(userRepo, config.GetConfig().Trash.Retention())
+	userTrashService.Schedule(config.GetConfig().Trash.PurgeInterval())
+	audienceService := services.NewAudienceService(userRepo, config.GetConfig().ContentRating.Country())
+	audienceHandler := handler.NewAudienceHandler(audienceService)
+	adminOnly := middleware.AdminMiddleware(userServiceFacade.GetUserByEmail)
+	editorOnly := middleware.EditorMiddleware()
+	tenantAdminOnly := middleware.TenantAdminMiddleware()
+
+	organizationRepo := repository.NewOrganizationRepository(db)
+	if err := organizationRepo.EnsureDefault(); err != nil {
+		log.Printf("could not store the default organization: %s", err)
+	}
+	organizationService := services.NewOrganizationService(organizationRepo, userRepo, revisionService)
+	organizationHandler := handler.NewOrganizationHandler(organizationService)
 
-	actorRepo := repository.NewActorRepository(db)
-	actorService := services.NewActorService(actorRepo)
-	actorHandler := handler.NewActorHandler(actorService)
+	// Catalogs are built up front so every organization's purge job runs
+	// from startup; organizations created later get theirs on first use.
+	tenants := newCatalogs(db, fileStorage, userRepo)
+	if orgs, err := organizationService.GetAll(); err != nil {
+		log.Printf("could not list organizations: %s", err)
+	} else {
+		for _, org := range orgs {
+			tenants.get(org.Slug)
+		}
+	}
 
-	directorRepo := repository.NewDirectorRepository(db)
-	directorService := services.NewDirectorService(directorRepo)
-	directorHandler := handler.NewDirectorHandler(directorService)
+	movies := func(t *catalog) *handler.MovieHandler { return t.movies }
+	actors := func(t *catalog) *handler.ActorHandler { return t.actors }
+	directors := func(t *catalog) *handler.DirectorHandler { return t.directors }
+	people := func(t *catalog) *handler.PersonHandler { return t.people }
+	collections := func(t *catalog) *handler.CollectionHandler { return t.collections }
+	series := func(t *catalog) *handler.SeriesHandler { return t.series }
+	awards := func(t *catalog) *handler.AwardHandler { return t.awards }
+	images := func(t *catalog) *handler.ImageHandler { return t.images }
+	graph := func(t *catalog) *handler.GraphHandler { return t.graph }
+	recommendations := func(t *catalog) *handler.RecommendationHandler { return t.recommendations }
+	stats := func(t *catalog) *handler.StatsHandler { return t.stats }
+	trash := func(t *catalog) *handler.TrashHandler { return t.trash }
+	imports := func(t *catalog) *handler.ImportHandler { return t.imports }
+	exports := func(t *catalog) *handler.ExportHandler { return t.exports }
+	quality := func(t *catalog) *handler.QualityHandler { return t.quality }
 
-	movieRepo := repository.NewMovieRepository(db)
-	movieHydrator := repository.NewMovieHydrator(directorRepo, actorRepo)
-	movieService := services.NewMovieService(movieRepo, movieHydrator)
-	movieHandler := handler.NewMovieHandler(movieService)
+	movieSlug := use(func(t *catalog) gin.HandlerFunc { return t.movieSlug })
+	personSlug := use(func(t *catalog) gin.HandlerFunc { return t.personSlug })
+	actorSlug := use(func(t *catalog) gin.HandlerFunc { return t.actorSlug })
+	directorSlug := use(func(t *catalog) gin.HandlerFunc { return t.directorSlug })
+
+	idempotency := middleware.IdempotencyMiddleware(
+		redis_utils.GetRedisClient(),
+		config.GetConfig().Idempotency,
+	)
 
 	protected := router.Group("/api")
 	protected.Use(middleware.AuthMiddleware(*jwtStrategy))
+	audience := middleware.AudienceMiddleware(audienceService.Resolve)
+
+	// Users and organizations are shared by every catalog.
+	shared := protected.Group("")
+	shared.Use(audience)
+	shared.Use(idempotency)
+
+	// Everything else works with one organization's catalog, so idempotency
+	// keys are scoped to it as well.
+	tenant := protected.Group("")
+	tenant.Use(middleware.TenantMiddleware(organizationService.Resolve))
+	tenant.Use(tenants.selectTenant)
+	tenant.Use(audience)
+	tenant.Use(idempotency)
+
+	// Ratings and watchlists belong to the user, writing them leaves the
+	// catalog and the recommendations cached from it alone.
+	personal := tenant.Group("")
+	tenant.Use(use(func(t *catalog) gin.HandlerFunc { return t.catalogChanged }))
+
+	// Only editors and admins write the catalog or look into a movie's
+	// history, which keeps the drafts it went through.
+	editorial := tenant.Group("")
+	editorial.Use(editorOnly)
 
 	router.POST("/api/login", userHandler.Login)
-	protected.POST("/users", userHandler.Register)
-	protected.GET("/users", userHandler.GetUsers)
-	protected.GET("/users/me", userHandler.GetAuthenticatedUser)
-	protected.GET("/users/:id", userHandler.GetUserById)
-	protected.GET("/logout", userHandler.Logout)
-
-	protected.POST("/actors", actorHandler.CreateActor)
-	protected.PUT("/actors/:id", actorHandler.UpdateActor)
-	protected.DELETE("/actor/:id", actorHandler.DeleteActor)
-	protected.GET("/all-actors", actorHandler.GetAllActors)
-	protected.GET("/actor/:id", actorHandler.GetActor)
-
-	protected.POST("/directors", directorHandler.CreateDirector)
-	protected.PUT("/directors/:id", directorHandler.UpdateDirector)
-	protected.GET("/all-directors", directorHandler.GetAllDirectors)
-	protected.GET("/director/:id", directorHandler.GetDirector)
-	protected.DELETE("/director/:id", directorHandler.DeleteDirector)
-
-	protected.POST("/movies", movieHandler.CreateMovie)
-	protected.GET("/movie/:id", movieHandler.GetMovie)
-	protected.GET("/all-movies", movieHandler.GetAllMovies)
-	protected.PUT("/movie/:id", movieHandler.UpdateMovies)
-	protected.DELETE("/movie/:id", movieHandler.DeleteMovies)
+	shared.POST("/users", userHandler.Register)
+	shared.GET("/users", userHandler.GetUsers)
+	shared.GET("/users/me", userHandler.GetAuthenticatedUser)
+	shared.GET("/users/me/organizations", organizationHandler.GetMyOrganizations)
+	shared.GET("/users/me/parental-control", audienceHandler.GetParentalControl)
+	shared.PUT("/users/me/parental-control", audienceHandler.PutParentalControl)
+	shared.DELETE("/users/me/parental-control", audienceHandler.DeleteParentalControl)
+	personal.GET("/users/me/recommendations", handle(recommendations, (*handler.RecommendationHandler).GetRecommendations))
+	personal.GET("/users/me/ratings", handle(recommendations, (*handler.RecommendationHandler).GetRatings))
+	personal.GET("/users/me/watchlist", handle(recommendations, (*handler.RecommendationHandler).GetWatchlist))
+	personal.PUT("/users/me/watchlist/:movieId", handle(recommendations, (*handler.RecommendationHandler).AddToWatchlist))
+	personal.DELETE("/users/me/watchlist/:movieId", handle(recommendations, (*handler.RecommendationHandler).RemoveFromWatchlist))
+	shared.GET("/users/:id", userHandler.GetUserById)
+	shared.DELETE("/users/:id", adminOnly, userHandler.DeleteUser)
+	shared.GET("/logout", userHandler.Logout)
+
+	shared.POST("/organizations", adminOnly, organizationHandler.CreateOrganization)
+	shared.GET("/organizations", adminOnly, organizationHandler.GetOrganizations)
+	shared.GET("/organizations/:org", adminOnly, organizationHandler.GetOrganization)
+	shared.GET("/organizations/:org/members", adminOnly, organizationHandler.GetMembers)
+	shared.PUT("/organizations/:org/members/:userId", adminOnly, organizationHandler.AddMember)
+	shared.DELETE("/organizations/:org/members/:userId", adminOnly, organizationHandler.RemoveMember)
+	shared.GET("/organizations/:org/export/:kind",
+		adminOnly,
+		tenants.selectParam("org", organizationService.Get),
+		handle(exports, (*handler.ExportHandler).Export),
+	)
+
+	editorial.POST("/actors", handle(actors, (*handler.ActorHandler).CreateActor))
+	editorial.PUT("/actors/:id", handle(actors, (*handler.ActorHandler).UpdateActor))
+	editorial.PATCH("/actors/:id", handle(actors, (*handler.ActorHandler).PatchActor))
+	editorial.POST("/actors/:id/merge", handle(actors, (*handler.ActorHandler).MergeActors))
+	editorial.DELETE("/actor/:id", handle(actors, (*handler.ActorHandler).DeleteActor))
+	tenant.GET("/all-actors", handle(actors, (*handler.ActorHandler).GetAllActors))
+	tenant.GET("/actor/:id", personSlug, handle(actors, (*handler.ActorHandler).GetActor))
+	editorial.POST("/actor/:id/headshot", handle(images, (*handler.ImageHandler).UploadActorHeadshot))
+	tenant.GET("/actor/:id/history", personSlug, handle(actors, (*handler.ActorHandler).GetActorHistory))
+	tenant.GET("/actor/:id/costars", personSlug, handle(graph, (*handler.GraphHandler).GetCoStars))
+	tenant.GET("/actor/:id/filmography", personSlug, handle(movies, (*handler.MovieHandler).GetActorFilmography))
+	tenant.GET("/actors/path", handle(graph, (*handler.GraphHandler).GetActorPath))
+	editorial.POST("/actor/:id/history/:revisionId/revert", handle(actors, (*handler.ActorHandler).RevertActor))
+
+	editorial.POST("/directors", handle(directors, (*handler.DirectorHandler).CreateDirector))
+	editorial.PUT("/directors/:id", handle(directors, (*handler.DirectorHandler).UpdateDirector))
+	editorial.PATCH("/directors/:id", handle(directors, (*handler.DirectorHandler).PatchDirector))
+	editorial.POST("/directors/:id/merge", handle(directors, (*handler.DirectorHandler).MergeDirectors))
+	tenant.GET("/all-directors", handle(directors, (*handler.DirectorHandler).GetAllDirectors))
+	tenant.GET("/director/:id", personSlug, handle(directors, (*handler.DirectorHandler).GetDirector))
+	editorial.POST("/director/:id/headshot", handle(images, (*handler.ImageHandler).UploadDirectorHeadshot))
+	tenant.GET("/director/:id/filmography", personSlug, handle(movies, (*handler.MovieHandler).GetDirectorFilmography))
+	tenant.GET("/director/:id/history", personSlug, handle(directors, (*handler.DirectorHandler).GetDirectorHistory))
+	editorial.POST("/director/:id/history/:revisionId/revert", handle(directors, (*handler.DirectorHandler).RevertDirector))
+	editorial.DELETE("/director/:id", handle(directors, (*handler.DirectorHandler).DeleteDirector))
+
+	editorial.POST("/people", handle(people, (*handler.PersonHandler).CreatePerson))
+	editorial.PUT("/people/:id", handle(people, (*handler.PersonHandler).UpdatePerson))
+	editorial.PATCH("/people/:id", handle(people, (*handler.PersonHandler).PatchPerson))
+	tenant.GET("/all-people", handle(people, (*handler.PersonHandler).GetAllPeople))
+	tenant.GET("/person/:id", personSlug, handle(people, (*handler.PersonHandler).GetPerson))
+	editorial.POST("/person/:id/headshot", handle(images, (*handler.ImageHandler).UploadPersonHeadshot))
+	tenant.GET("/person/:id/awards", personSlug, handle(awards, (*handler.AwardHandler).GetPersonAwards))
+	tenant.GET("/person/:id/history", personSlug, handle(people, (*handler.PersonHandler).GetPersonHistory))
+	editorial.POST("/person/:id/history/:revisionId/revert", handle(people, (*handler.PersonHandler).RevertPerson))
+	editorial.DELETE("/person/:id", handle(people, (*handler.PersonHandler).DeletePerson))
+
+	editorial.POST("/movies", handle(movies, (*handler.MovieHandler).CreateMovie))
+	tenant.GET("/movie/:id", movieSlug, handle(movies, (*handler.MovieHandler).GetMovie))
+	editorial.POST("/movie/:id/poster", handle(images, (*handler.ImageHandler).UploadMoviePoster))
+	tenant.GET("/movie/:id/translations", movieSlug, handle(movies, (*handler.MovieHandler).GetTranslations))
+	editorial.PUT("/movie/:id/translations/:locale", handle(movies, (*handler.MovieHandler).PutTranslation))
+	editorial.DELETE("/movie/:id/translations/:locale", handle(movies, (*handler.MovieHandler).DeleteTranslation))
+	tenant.GET("/movie/:id/awards", movieSlug, handle(awards, (*handler.AwardHandler).GetMovieAwards))
+	tenant.GET("/movie/:id/similar", movieSlug, handle(recommendations, (*handler.RecommendationHandler).GetSimilarMovies))
+	personal.PUT("/movie/:id/rating", handle(recommendations, (*handler.RecommendationHandler).RateMovie))
+	personal.DELETE("/movie/:id/rating", handle(recommendations, (*handler.RecommendationHandler).DeleteRating))
+	editorial.GET("/movie/:id/history", movieSlug, handle(movies, (*handler.MovieHandler).GetMovieHistory))
+	editorial.POST("/movie/:id/history/:revisionId/revert", handle(movies, (*handler.MovieHandler).RevertMovie))
+	editorial.POST("/movie/:id/workflow", handle(movies, (*handler.MovieHandler).TransitionMovie))
+	tenant.GET("/all-movies", handle(movies, (*handler.MovieHandler).GetAllMovies))
+	editorial.PUT("/movie/:id", handle(movies, (*handler.MovieHandler).UpdateMovies))
+	editorial.PATCH("/movie/:id", handle(movies, (*handler.MovieHandler).PatchMovie))
+	editorial.DELETE("/movie/:id", handle(movies, (*handler.MovieHandler).DeleteMovies))
+
+	editorial.POST("/collections", handle(collections, (*handler.CollectionHandler).CreateCollection))
+	editorial.PUT("/collections/:id", handle(collections, (*handler.CollectionHandler).UpdateCollection))
+	tenant.GET("/all-collections", handle(collections, (*handler.CollectionHandler).GetAllCollections))
+	tenant.GET("/collection/:id", handle(collections, (*handler.CollectionHandler).GetCollection))
+	editorial.POST("/collection/:id/artwork", handle(images, (*handler.ImageHandler).UploadCollectionArtwork))
+	tenant.GET("/collection/:id/history", handle(collections, (*handler.CollectionHandler).GetCollectionHistory))
+	editorial.POST("/collection/:id/history/:revisionId/revert", handle(collections, (*handler.CollectionHandler).RevertCollection))
+	editorial.DELETE("/collection/:id", handle(collections, (*handler.CollectionHandler).DeleteCollection))
+
+	editorial.POST("/series", handle(series, (*handler.SeriesHandler).CreateSeries))
+	tenant.GET("/all-series", handle(series, (*handler.SeriesHandler).GetAllSeries))
+	tenant.GET("/series/:id", handle(series, (*handler.SeriesHandler).GetSeries))
+	editorial.PUT("/series/:id", handle(series, (*handler.SeriesHandler).UpdateSeries))
+	editorial.DELETE("/series/:id", handle(series, (*handler.SeriesHandler).DeleteSeries))
+	tenant.GET("/series/:id/history", handle(series, (*handler.SeriesHandler).GetSeriesHistory))
+	editorial.POST("/series/:id/history/:revisionId/revert", handle(series, (*handler.SeriesHandler).RevertSeries))
+	tenant.GET("/series/:id/seasons", handle(series, (*handler.SeriesHandler).GetSeasons))
+	editorial.POST("/series/:id/seasons", handle(series, (*handler.SeriesHandler).CreateSeason))
+	tenant.GET("/series/:id/seasons/:season", handle(series, (*handler.SeriesHandler).GetSeason))
+	editorial.PUT("/series/:id/seasons/:season", handle(series, (*handler.SeriesHandler).PutSeason))
+	editorial.DELETE("/series/:id/seasons/:season", handle(series, (*handler.SeriesHandler).DeleteSeason))
+	tenant.GET("/series/:id/seasons/:season/episodes", handle(series, (*handler.SeriesHandler).GetEpisodes))
+	editorial.POST("/series/:id/seasons/:season/episodes", handle(series, (*handler.SeriesHandler).CreateEpisode))
+	tenant.GET("/series/:id/seasons/:season/episodes/:episode", handle(series, (*handler.SeriesHandler).GetEpisode))
+	editorial.PUT("/series/:id/seasons/:season/episodes/:episode", handle(series, (*handler.SeriesHandler).PutEpisode))
+	editorial.DELETE("/series/:id/seasons/:season/episodes/:episode", handle(series, (*handler.SeriesHandler).DeleteEpisode))
+
+	editorial.POST("/awards", handle(awards, (*handler.AwardHandler).CreateAward))
+	editorial.PUT("/awards/:id", handle(awards, (*handler.AwardHandler).UpdateAward))
+	tenant.GET("/all-awards", handle(awards, (*handler.AwardHandler).GetAllAwards))
+	tenant.GET("/award/:id", handle(awards, (*handler.AwardHandler).GetAward))
+	tenant.GET("/award/:id/history", handle(awards, (*handler.AwardHandler).GetAwardHistory))
+	editorial.POST("/award/:id/history/:revisionId/revert", handle(awards, (*handler.AwardHandler).RevertAward))
+	editorial.DELETE("/award/:id", handle(awards, (*handler.AwardHandler).DeleteAward))
+	tenant.GET("/ceremonies/:ceremony/:year", handle(awards, (*handler.AwardHandler).GetCeremony))
+
+	tenant.GET("/stats/release-years", handle(stats, (*handler.StatsHandler).GetReleaseYears))
+	tenant.GET("/stats/top-directors", handle(stats, (*handler.StatsHandler).GetTopDirectors))
+	tenant.GET("/stats/top-actors", handle(stats, (*handler.StatsHandler).GetTopActors))
+	tenant.GET("/stats/cast-size", handle(stats, (*handler.StatsHandler).GetCastSize))
+	tenant.GET("/stats/partnerships", handle(stats, (*handler.StatsHandler).GetPartnerships))
+	tenant.GET("/stats/growth", handle(stats, (*handler.StatsHandler).GetGrowth))
+
+	tenant.GET("/trash", tenantAdminOnly, handle(trash, (*handler.TrashHandler).GetTrash))
+	tenant.POST("/trash/:kind/:id/restore", tenantAdminOnly, handle(trash, (*handler.TrashHandler).RestoreFromTrash))
+
+	tenant.GET("/admin/data-quality", tenantAdminOnly, handle(quality, (*handler.QualityHandler).GetDataQuality))
+	tenant.POST("/admin/data-quality/fix", tenantAdminOnly, handle(quality, (*handler.QualityHandler).FixDataQuality))
+
+	editorial.POST("/import/:kind", handle(imports, (*handler.ImportHandler).Import))
+	tenant.GET("/export/:kind", handle(exports, (*handler.ExportHandler).Export))
 
 	//for including the field the url has to look a like this way -->
 	// api/actor-movies/692035ff46a473472ef22f5b?field=title,release_year
 	//for excluding the field
 	// api/actor-movies/692035ff46a473472ef22f5b?exclude=title,release_year
-	protected.GET("/director-movies/:directorId", movieHandler.GetMoviesByDirector)
-	protected.GET("/actor-movies/:actorId", movieHandler.GetMoviesByActor)
+	tenant.GET("/director-movies/:directorId", directorSlug, handle(movies, (*handler.MovieHandler).GetMoviesByDirector))
+	tenant.GET("/actor-movies/:actorId", actorSlug, handle(movies, (*handler.MovieHandler).GetMoviesByActor))
+
+	// Credits cover movies and series alike, each entry tells which by its
+	// type field.
+	tenant.GET("/director-credits/:directorId", directorSlug, handle(series, (*handler.SeriesHandler).GetDirectorCredits))
+	tenant.GET("/actor-credits/:actorId", actorSlug, handle(series, (*handler.SeriesHandler).GetActorCredits))
 
 	return router
 }
@@ -9,36 +9,81 @@ import (
 )
 
 type IActorService interface {
-	Create(actor *model.Actor) (primitive.ObjectID, error)
+	Create(actor *model.Actor, editor string) (primitive.ObjectID, error)
 	GetByID(id primitive.ObjectID) (*model.Actor, error)
-	GetAll() ([]model.Actor, error)
-	Update(id primitive.ObjectID, update bson.M) error
-	Delete(id primitive.ObjectID) error
+	GetAll(awards *model.AwardFilter) ([]model.Actor, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	Patch(
+		id primitive.ObjectID,
+		contentType string,
+		patch []byte,
+		version int64,
+		editor string,
+	) (*model.Actor, error)
+	Merge(id primitive.ObjectID, req *model.MergeRequest, editor string) (*model.Actor, error)
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, editor string) (*model.Actor, error)
 }
 type ActorService struct {
-	repo repository.IActorRepository
+	repo      repository.IActorRepository
+	revisions IRevisionService
+	images    IImageService
+	awards    repository.IAwardRepository
 }
 
-func NewActorService(repo repository.IActorRepository) IActorService {
-	return &ActorService{repo: repo}
+func NewActorService(
+	repo repository.IActorRepository,
+	revisions IRevisionService,
+	images IImageService,
+	awards repository.IAwardRepository,
+) IActorService {
+	return &ActorService{repo: repo, revisions: revisions, images: images, awards: awards}
 }
 
-func (s *ActorService) Create(actor *model.Actor) (primitive.ObjectID, error) {
-	return s.repo.Create(actor)
+func (s *ActorService) Create(actor *model.Actor, editor string) (primitive.ObjectID, error) {
+	return createPerson(s.repo, s.revisions, actor, editor)
 }
 
 func (s *ActorService) GetByID(id primitive.ObjectID) (*model.Actor, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *ActorService) GetAll() ([]model.Actor, error) {
-	return s.repo.GetAll()
+func (s *ActorService) GetAll(awards *model.AwardFilter) ([]model.Actor, error) {
+	people, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return awardedPeople(s.awards, people, awards)
 }
 
-func (s *ActorService) Update(id primitive.ObjectID, update bson.M) error {
-	return s.repo.Update(id, update)
+func (s *ActorService) Update(id primitive.ObjectID, update bson.M, version int64, editor string) error {
+	return updatePerson(s.repo, s.revisions, id, update, version, editor)
 }
 
-func (s *ActorService) Delete(id primitive.ObjectID) error {
-	return s.repo.Delete(id)
+func (s *ActorService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	return deletePerson(s.repo, s.revisions, id, version, editor)
+}
+
+func (s *ActorService) Merge(id primitive.ObjectID, req *model.MergeRequest, editor string) (*model.Actor, error) {
+	return mergePeople(s.repo, s.revisions, "actor", id, req, editor)
+}
+
+func (s *ActorService) Patch(
+	id primitive.ObjectID,
+	contentType string,
+	patch []byte,
+	version int64,
+	editor string,
+) (*model.Actor, error) {
+	return patchPerson(s.repo, s.revisions, model.DepartmentActing, id, contentType, patch, version, editor)
+}
+
+func (s *ActorService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return personHistory(s.revisions, id)
+}
+
+// Revert puts the person back the way they were after the chosen revision.
+func (s *ActorService) Revert(id, revisionID primitive.ObjectID, editor string) (*model.Actor, error) {
+	return revertPerson(s.repo, s.revisions, id, revisionID, editor)
 }
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"regexp"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidPIN = errors.New("PIN must be 4 to 8 digits")
+	ErrWrongPIN   = errors.New("PIN does not match")
+	ErrInvalidAge = errors.New("max_age must be between 0 and 21")
+)
+
+var pinFormat = regexp.MustCompile(`^\d{4,8}$`)
+
+type IAudienceService interface {
+	Resolve(email, organization string) (*model.Audience, error)
+	ParentalControl(email string) (*model.ParentalControl, error)
+	SetParentalControl(email string, maxAge int, pin, currentPIN string) (*model.ParentalControl, error)
+	RemoveParentalControl(email, pin string) error
+}
+
+type AudienceService struct {
+	users          repository.IUserRepository
+	defaultCountry string
+}
+
+func NewAudienceService(users repository.IUserRepository, defaultCountry string) IAudienceService {
+	return &AudienceService{users: users, defaultCountry: defaultCountry}
+}
+
+// Resolve works out what the user may see of the organization's catalog,
+// going by their role there; with no organization it goes by their role
+// outside any catalog. Editors and admins see everything, everybody else is
+// limited by their parental control or else their age. Users whose age is
+// unknown are held to the strictest limit, only movies that require no age
+// are shown to them.
+func (s *AudienceService) Resolve(email, organization string) (*model.Audience, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	role := user.Role
+	if organization != "" {
+		role = ""
+		if membership := user.Membership(organization); membership != nil {
+			role = membership.Role
+		}
+	}
+	audience := &model.Audience{Country: s.defaultCountry, MaxAge: user.Age, Role: role}
+	if country, ok := model.NormalizeCountry(user.Country); ok {
+		audience.Country = country
+	}
+	switch {
+	case audience.Editorial():
+		audience.Unrestricted = true
+	case user.ParentalControl != nil:
+		audience.MaxAge = user.ParentalControl.MaxAge
+	case user.Age <= 0:
+		audience.MaxAge = 0
+	}
+	return audience, nil
+}
+
+func (s *AudienceService) ParentalControl(email string) (*model.ParentalControl, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	return user.ParentalControl, nil
+}
+
+// SetParentalControl sets the age limit the user is held to. Setting it up
+// the first time chooses the PIN; changing it afterwards needs that PIN in
+// currentPIN, and pin may be left empty to keep it.
+func (s *AudienceService) SetParentalControl(
+	email string,
+	maxAge int,
+	pin, currentPIN string,
+) (*model.ParentalControl, error) {
+	if maxAge < 0 || maxAge > 21 {
+		return nil, ErrInvalidAge
+	}
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	control := &model.ParentalControl{MaxAge: maxAge, UpdatedAt: time.Now().UTC()}
+	if user.ParentalControl != nil {
+		if !checkPIN(user.ParentalControl, currentPIN) {
+			return nil, ErrWrongPIN
+		}
+		control.PINHash = user.ParentalControl.PINHash
+	}
+	if pin != "" || user.ParentalControl == nil {
+		if !pinFormat.MatchString(pin) {
+			return nil, ErrInvalidPIN
+		}
+		hashed, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		control.PINHash = string(hashed)
+	}
+
+	if err := s.users.SetParentalControl(email, control); err != nil {
+		return nil, err
+	}
+	return control, nil
+}
+
+func (s *AudienceService) RemoveParentalControl(email, pin string) error {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if user.ParentalControl == nil {
+		return nil
+	}
+	if !checkPIN(user.ParentalControl, pin) {
+		return ErrWrongPIN
+	}
+	return s.users.SetParentalControl(email, nil)
+}
+
+func checkPIN(control *model.ParentalControl, pin string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(control.PINHash), []byte(pin)) == nil
+}
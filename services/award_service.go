@@ -0,0 +1,309 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IAwardService interface {
+	Create(award *model.Award, editor string) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID, audience *model.Audience) (*model.Award, error)
+	GetAll(ceremony string, year int) ([]model.Award, error)
+	Ceremony(ceremony string, year int, audience *model.Audience) (*model.CeremonyResults, error)
+	ForMovie(movieID primitive.ObjectID, audience *model.Audience) ([]model.AwardEntry, error)
+	ForPerson(personID primitive.ObjectID, audience *model.Audience) ([]model.AwardEntry, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, editor string) (*model.Award, error)
+}
+
+type AwardService struct {
+	repo      repository.IAwardRepository
+	movies    repository.IMovieRepository
+	people    repository.IPersonRepository
+	revisions IRevisionService
+}
+
+func NewAwardService(
+	repo repository.IAwardRepository,
+	movies repository.IMovieRepository,
+	people repository.IPersonRepository,
+	revisions IRevisionService,
+) IAwardService {
+	return &AwardService{repo: repo, movies: movies, people: people, revisions: revisions}
+}
+
+func (s *AwardService) Create(award *model.Award, editor string) (primitive.ObjectID, error) {
+	if err := award.Normalize(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := s.checkNominees(award.Nominees); err != nil {
+		return primitive.NilObjectID, err
+	}
+	id, err := s.repo.Create(award)
+	if err != nil {
+		return id, err
+	}
+	s.revisions.Record(model.EntityAward, id, model.RevisionCreate, editor, nil, award)
+	return id, nil
+}
+
+// GetByID leaves out the nominations for movies the audience may not see.
+func (s *AwardService) GetByID(id primitive.ObjectID, audience *model.Audience) (*model.Award, error) {
+	award, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	hidden, err := s.describe(award.Nominees, audience)
+	if err != nil {
+		return nil, err
+	}
+	award.Nominees = visibleNominees(award.Nominees, hidden)
+	return award, nil
+}
+
+func (s *AwardService) GetAll(ceremony string, year int) ([]model.Award, error) {
+	return s.repo.GetAll(ceremony, year)
+}
+
+// Ceremony lists the nominees of one edition of a ceremony by category,
+// leaving out the movies the audience may not see.
+func (s *AwardService) Ceremony(ceremony string, year int, audience *model.Audience) (*model.CeremonyResults, error) {
+	awards, err := s.repo.GetAll(ceremony, year)
+	if err != nil {
+		return nil, err
+	}
+	if len(awards) == 0 {
+		return nil, fmt.Errorf("ceremony not found with given name and year")
+	}
+	results := model.NewCeremonyResults(ceremony, year, awards)
+	for i := range results.Categories {
+		category := &results.Categories[i]
+		hidden, err := s.describe(category.Nominees, audience)
+		if err != nil {
+			return nil, err
+		}
+		category.Nominees = visibleNominees(category.Nominees, hidden)
+	}
+	return results, nil
+}
+
+// ForMovie reports movies the audience may not see as not found.
+func (s *AwardService) ForMovie(movieID primitive.ObjectID, audience *model.Audience) ([]model.AwardEntry, error) {
+	movie, err := s.movies.GetByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+	if !audience.Allows(movie) {
+		return nil, model.NotFound("movie")
+	}
+	awards, err := s.repo.GetByMovie(movieID)
+	if err != nil {
+		return nil, err
+	}
+	return s.entries(awards, model.NominatedMovie(movieID), audience)
+}
+
+// ForPerson leaves out the nominations for movies the audience may not see.
+func (s *AwardService) ForPerson(personID primitive.ObjectID, audience *model.Audience) ([]model.AwardEntry, error) {
+	if _, err := s.people.GetByID(personID); err != nil {
+		return nil, err
+	}
+	awards, err := s.repo.GetByPerson(personID)
+	if err != nil {
+		return nil, err
+	}
+	return s.entries(awards, model.NominatedPerson(personID), audience)
+}
+
+func (s *AwardService) entries(
+	awards []model.Award,
+	keep func(model.Nominee) bool,
+	audience *model.Audience,
+) ([]model.AwardEntry, error) {
+	entries := model.AwardEntries(awards, keep)
+	nominees := make([]model.Nominee, len(entries))
+	for i := range entries {
+		nominees[i] = entries[i].Nominee
+	}
+	hidden, err := s.describe(nominees, audience)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]model.AwardEntry, 0, len(entries))
+	for i := range entries {
+		if hidden[nominees[i].MovieID] {
+			continue
+		}
+		entries[i].Nominee = nominees[i]
+		kept = append(kept, entries[i])
+	}
+	return kept, nil
+}
+
+// Update expects nominees, when present, as the complete new list.
+func (s *AwardService) Update(id primitive.ObjectID, update bson.M, version int64, editor string) error {
+	if nominees, ok := update["nominees"].([]model.Nominee); ok {
+		if err := s.checkNominees(nominees); err != nil {
+			return err
+		}
+	}
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Update(id, update, version); err != nil {
+		return err
+	}
+	after := orNil(s.repo.GetByID(id))
+	s.revisions.Record(model.EntityAward, id, model.RevisionUpdate, editor, before, after)
+	return nil
+}
+
+func (s *AwardService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, version); err != nil {
+		return err
+	}
+	s.revisions.Record(model.EntityAward, id, model.RevisionDelete, editor, before, nil)
+	return nil
+}
+
+func (s *AwardService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return s.revisions.History(model.EntityAward, id)
+}
+
+// Revert puts the award back the way it was after the chosen revision.
+func (s *AwardService) Revert(id, revisionID primitive.ObjectID, editor string) (*model.Award, error) {
+	revision, err := s.revisions.Get(model.EntityAward, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	before := orNil(s.repo.GetByID(id))
+	if err := s.repo.Restore(id, restorable(revision)); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntityAward, id, model.RevisionRevert, editor, before, after)
+	return after, nil
+}
+
+// checkNominees makes sure every nominated movie exists and every nominated
+// person is credited on the movie they are nominated for.
+func (s *AwardService) checkNominees(nominees []model.Nominee) error {
+	ids := make([]primitive.ObjectID, 0, len(nominees))
+	for _, n := range nominees {
+		ids = append(ids, n.MovieID)
+	}
+	movies, err := s.movies.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[primitive.ObjectID]*model.Movie, len(movies))
+	for i := range movies {
+		byID[movies[i].ID] = &movies[i]
+	}
+	for _, n := range nominees {
+		movie, ok := byID[n.MovieID]
+		if !ok {
+			return fmt.Errorf("%w: nominee lists a movie that does not exist", model.ErrInvalidDocument)
+		}
+		if n.PersonID != nil && !movie.Credits(*n.PersonID) {
+			return fmt.Errorf("%w: nominated person is not credited on the movie", model.ErrInvalidDocument)
+		}
+	}
+	return nil
+}
+
+// describe fills in the movie titles and people of the nominees and returns
+// the nominated movies the audience may not see.
+func (s *AwardService) describe(
+	nominees []model.Nominee,
+	audience *model.Audience,
+) (map[primitive.ObjectID]bool, error) {
+	movieIDs := make([]primitive.ObjectID, 0, len(nominees))
+	personIDs := make([]primitive.ObjectID, 0, len(nominees))
+	for _, n := range nominees {
+		movieIDs = append(movieIDs, n.MovieID)
+		if n.PersonID != nil {
+			personIDs = append(personIDs, *n.PersonID)
+		}
+	}
+	movies, err := s.movies.GetByIDs(movieIDs)
+	if err != nil {
+		return nil, err
+	}
+	people, err := s.people.GetByIDs(personIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[primitive.ObjectID]string, len(movies))
+	hidden := map[primitive.ObjectID]bool{}
+	for i := range movies {
+		titles[movies[i].ID] = movies[i].Title
+		if !audience.Allows(&movies[i]) {
+			hidden[movies[i].ID] = true
+		}
+	}
+	byID := make(map[primitive.ObjectID]*model.Person, len(people))
+	for i := range people {
+		byID[people[i].ID] = &people[i]
+	}
+	for i := range nominees {
+		nominees[i].MovieTitle = titles[nominees[i].MovieID]
+		if nominees[i].PersonID != nil {
+			nominees[i].Person = byID[*nominees[i].PersonID]
+		}
+	}
+	return hidden, nil
+}
+
+// visibleNominees drops the nominations for hidden movies.
+func visibleNominees(nominees []model.Nominee, hidden map[primitive.ObjectID]bool) []model.Nominee {
+	kept := make([]model.Nominee, 0, len(nominees))
+	for _, n := range nominees {
+		if !hidden[n.MovieID] {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// awardedPeople keeps the people the award filter matches. A nil filter
+// keeps everybody.
+func awardedPeople(
+	awards repository.IAwardRepository,
+	people []model.Person,
+	filter *model.AwardFilter,
+) ([]model.Person, error) {
+	if filter == nil {
+		return people, nil
+	}
+	ids, err := awards.Nominated(filter, "person_id")
+	if err != nil {
+		return nil, err
+	}
+	nominated := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		nominated[id] = true
+	}
+	kept := make([]model.Person, 0, len(people))
+	for _, p := range people {
+		if nominated[p.ID] {
+			kept = append(kept, p)
+		}
+	}
+	return kept, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ICollectionService interface {
+	Create(collection *model.Collection, editor string) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID, audience *model.Audience) (*model.CollectionResponse, error)
+	GetAll() ([]model.Collection, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, editor string) (*model.Collection, error)
+}
+
+type CollectionService struct {
+	repo      repository.ICollectionRepository
+	movies    repository.IMovieRepository
+	hydrator  *repository.MovieHydrator
+	revisions IRevisionService
+	images    IImageService
+}
+
+func NewCollectionService(
+	repo repository.ICollectionRepository,
+	movies repository.IMovieRepository,
+	hydrator *repository.MovieHydrator,
+	revisions IRevisionService,
+	images IImageService,
+) ICollectionService {
+	return &CollectionService{
+		repo:      repo,
+		movies:    movies,
+		hydrator:  hydrator,
+		revisions: revisions,
+		images:    images,
+	}
+}
+
+func (s *CollectionService) Create(collection *model.Collection, editor string) (primitive.ObjectID, error) {
+	if err := collection.Normalize(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	if err := s.checkMovies(collection.MovieIDs); err != nil {
+		return primitive.NilObjectID, err
+	}
+	id, err := s.repo.Create(collection)
+	if err != nil {
+		return id, err
+	}
+	s.revisions.Record(model.EntityCollection, id, model.RevisionCreate, editor, nil, collection)
+	return id, nil
+}
+
+// GetByID returns the collection with its movies hydrated in the
+// collection's order. Movies the audience may not see are left out. A movie
+// that cannot be hydrated is listed as stored and marked incomplete rather
+// than failing the whole collection.
+func (s *CollectionService) GetByID(id primitive.ObjectID, audience *model.Audience) (*model.CollectionResponse, error) {
+	collection, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	movies, err := s.movies.GetByIDs(collection.MovieIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	hydratorOption := repository.HydrationOptions{
+		ForceDirector: true,
+		ForceActors:   true,
+		ForceCrew:     true,
+	}
+	response := &model.CollectionResponse{
+		Collection: *collection,
+		Movies:     []model.MovieResponse{},
+	}
+	for _, movie := range model.OrderMovies(collection.MovieIDs, movies) {
+		if !audience.Allows(&movie) {
+			continue
+		}
+		if err := s.hydrator.Hydrate(&movie, nil, hydratorOption); err != nil {
+			log.Printf("could not hydrate movie %s of collection %s: %s", movie.ID.Hex(), id.Hex(), err)
+			response.Incomplete = append(response.Incomplete, movie.ID)
+		}
+		response.Movies = append(response.Movies, *newMovieResponse(&movie))
+	}
+	return response, nil
+}
+
+func (s *CollectionService) GetAll() ([]model.Collection, error) {
+	return s.repo.GetAll()
+}
+
+// Update expects movie_ids, when present, in their final order; it replaces
+// the members as a whole.
+func (s *CollectionService) Update(id primitive.ObjectID, update bson.M, version int64, editor string) error {
+	if ids, ok := update["movie_ids"].([]primitive.ObjectID); ok {
+		if err := s.checkMovies(ids); err != nil {
+			return err
+		}
+	}
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Update(id, update, version); err != nil {
+		return err
+	}
+	after := orNil(s.repo.GetByID(id))
+	s.revisions.Record(model.EntityCollection, id, model.RevisionUpdate, editor, before, after)
+	return nil
+}
+
+func (s *CollectionService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, version); err != nil {
+		return err
+	}
+	s.revisions.Record(model.EntityCollection, id, model.RevisionDelete, editor, before, nil)
+	return nil
+}
+
+func (s *CollectionService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return s.revisions.History(model.EntityCollection, id)
+}
+
+// Revert puts the collection back the way it was after the chosen revision.
+// Members deleted since then are dropped again when the collection is read.
+func (s *CollectionService) Revert(id, revisionID primitive.ObjectID, editor string) (*model.Collection, error) {
+	revision, err := s.revisions.Get(model.EntityCollection, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	before := orNil(s.repo.GetByID(id))
+	if err := s.repo.Restore(id, restorable(revision)); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntityCollection, id, model.RevisionRevert, editor, before, after)
+	return after, nil
+}
+
+// checkMovies makes sure every member of a collection is an existing movie.
+func (s *CollectionService) checkMovies(ids []primitive.ObjectID) error {
+	movies, err := s.movies.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	if len(movies) != len(ids) {
+		return fmt.Errorf("%w: collection lists a movie that does not exist", model.ErrInvalidDocument)
+	}
+	return nil
+}
@@ -9,37 +9,82 @@ import (
 )
 
 type IDirectorService interface {
-	Create(director *model.Director) (primitive.ObjectID, error)
+	Create(director *model.Director, editor string) (primitive.ObjectID, error)
 	GetByID(id primitive.ObjectID) (*model.Director, error)
-	GetAll() ([]model.Director, error)
-	Update(id primitive.ObjectID, update bson.M) error
-	Delete(id primitive.ObjectID) error
+	GetAll(awards *model.AwardFilter) ([]model.Director, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	Patch(
+		id primitive.ObjectID,
+		contentType string,
+		patch []byte,
+		version int64,
+		editor string,
+	) (*model.Director, error)
+	Merge(id primitive.ObjectID, req *model.MergeRequest, editor string) (*model.Director, error)
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, editor string) (*model.Director, error)
 }
 
 type DirectorService struct {
-	repo repository.IDirectorRepository
+	repo      repository.IDirectorRepository
+	revisions IRevisionService
+	images    IImageService
+	awards    repository.IAwardRepository
 }
 
-func NewDirectorService(repo repository.IDirectorRepository) IDirectorService {
-	return &DirectorService{repo: repo}
+func NewDirectorService(
+	repo repository.IDirectorRepository,
+	revisions IRevisionService,
+	images IImageService,
+	awards repository.IAwardRepository,
+) IDirectorService {
+	return &DirectorService{repo: repo, revisions: revisions, images: images, awards: awards}
 }
 
-func (d *DirectorService) Create(director *model.Director) (primitive.ObjectID, error) {
-	return d.repo.Create(director)
+func (d *DirectorService) Create(director *model.Director, editor string) (primitive.ObjectID, error) {
+	return createPerson(d.repo, d.revisions, director, editor)
 }
 
 func (d *DirectorService) GetByID(id primitive.ObjectID) (*model.Director, error) {
 	return d.repo.GetByID(id)
 }
 
-func (d *DirectorService) GetAll() ([]model.Director, error) {
-	return d.repo.GetAll()
+func (d *DirectorService) GetAll(awards *model.AwardFilter) ([]model.Director, error) {
+	people, err := d.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return awardedPeople(d.awards, people, awards)
 }
 
-func (d *DirectorService) Update(id primitive.ObjectID, update bson.M) error {
-	return d.repo.Update(id, update)
+func (d *DirectorService) Update(id primitive.ObjectID, update bson.M, version int64, editor string) error {
+	return updatePerson(d.repo, d.revisions, id, update, version, editor)
 }
 
-func (d *DirectorService) Delete(id primitive.ObjectID) error {
-	return d.repo.Delete(id)
+func (d *DirectorService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	return deletePerson(d.repo, d.revisions, id, version, editor)
+}
+
+func (d *DirectorService) Merge(id primitive.ObjectID, req *model.MergeRequest, editor string) (*model.Director, error) {
+	return mergePeople(d.repo, d.revisions, "director", id, req, editor)
+}
+
+func (d *DirectorService) Patch(
+	id primitive.ObjectID,
+	contentType string,
+	patch []byte,
+	version int64,
+	editor string,
+) (*model.Director, error) {
+	return patchPerson(d.repo, d.revisions, model.DepartmentDirecting, id, contentType, patch, version, editor)
+}
+
+func (d *DirectorService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return personHistory(d.revisions, id)
+}
+
+// Revert puts the person back the way they were after the chosen revision.
+func (d *DirectorService) Revert(id, revisionID primitive.ObjectID, editor string) (*model.Director, error) {
+	return revertPerson(d.repo, d.revisions, id, revisionID, editor)
 }
@@ -0,0 +1,277 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"io"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ExportKindMovies    = "movies"
+	ExportKindActors    = "actors"
+	ExportKindDirectors = "directors"
+	ExportKindPeople    = "people"
+)
+
+// hydrationBatch is how many movies are held back while their director and
+// actor names are looked up together.
+const hydrationBatch = 100
+
+// ExportFilter picks the movies to export. The embedded listing query works
+// as it does for GET /movie, except that sorting is on the stored titles the
+// records carry, a country keeps the movies released there and the records
+// themselves are not localized. Its Audience limits the movies to those it
+// may see, nil exports them all.
+type ExportFilter struct {
+	model.MovieListQuery
+	DirectorID primitive.ObjectID
+	ActorID    primitive.ObjectID
+	Hydrate    bool
+}
+
+type IExportService interface {
+	Validate(kind, format string) error
+	Export(kind, format string, filter ExportFilter, w io.Writer) error
+}
+
+type ExportService struct {
+	repo   repository.IExportRepository
+	people repository.IPersonRepository
+	awards repository.IAwardRepository
+}
+
+func NewExportService(
+	repo repository.IExportRepository,
+	people repository.IPersonRepository,
+	awards repository.IAwardRepository,
+) IExportService {
+	return &ExportService{repo: repo, people: people, awards: awards}
+}
+
+func (s *ExportService) Validate(kind, format string) error {
+	if format != model.FormatCSV && format != model.FormatJSONL {
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	switch kind {
+	case ExportKindMovies, ExportKindActors, ExportKindDirectors, ExportKindPeople:
+		return nil
+	}
+	return fmt.Errorf("unsupported export kind %q", kind)
+}
+
+// Export writes the records as they come off the cursor, in the same layout
+// the importer reads, so people exports load back with kind=people and movie
+// exports with kind=movies.
+func (s *ExportService) Export(kind, format string, filter ExportFilter, w io.Writer) error {
+	if err := s.Validate(kind, format); err != nil {
+		return err
+	}
+
+	if kind == ExportKindMovies {
+		return s.exportMovies(filter, format, w)
+	}
+	return s.exportPeople(kind, format, w)
+}
+
+func (s *ExportService) exportPeople(kind, format string, w io.Writer) error {
+	out := newRecordWriter(format, model.PersonCSVHeader, w)
+
+	department := ""
+	switch kind {
+	case ExportKindActors:
+		department = model.DepartmentActing
+	case ExportKindDirectors:
+		department = model.DepartmentDirecting
+	}
+	err := s.repo.StreamPeople(department, func(p *model.Person) error {
+		record := model.NewPersonRecord(p)
+		return out.write(record, record.CSVRow())
+	})
+	if err != nil {
+		return err
+	}
+	return out.finish()
+}
+
+func (s *ExportService) exportMovies(filter ExportFilter, format string, w io.Writer) error {
+	query := bson.M{}
+	if !filter.DirectorID.IsZero() {
+		query["director_id"] = filter.DirectorID
+	}
+	if !filter.ActorID.IsZero() {
+		query["actors"] = filter.ActorID
+	}
+	if filter.Country != "" {
+		query["releases.country"] = filter.Country
+	}
+	if filter.Awards != nil {
+		ids, err := s.awards.Nominated(filter.Awards, "movie_id")
+		if err != nil {
+			return err
+		}
+		query["_id"] = bson.M{"$in": ids}
+	}
+	sort, err := exportSort(filter.Sort)
+	if err != nil {
+		return err
+	}
+	stream := func(fn func(*model.Movie) error) error {
+		return s.repo.StreamMovies(query, sort, filter.Audience, func(m *model.Movie) error {
+			if !filter.matches(m) {
+				return nil
+			}
+			return fn(m)
+		})
+	}
+
+	if !filter.Hydrate {
+		out := newRecordWriter(format, model.MovieCSVHeader, w)
+		err := stream(func(m *model.Movie) error {
+			record := model.NewMovieRecord(m)
+			return out.write(record, record.CSVRow())
+		})
+		if err != nil {
+			return err
+		}
+		return out.finish()
+	}
+
+	out := newRecordWriter(format, model.HydratedMovieCSVHeader, w)
+	batch := make([]*model.Movie, 0, hydrationBatch)
+	err = stream(func(m *model.Movie) error {
+		batch = append(batch, m)
+		if len(batch) < hydrationBatch {
+			return nil
+		}
+		err := s.writeHydrated(batch, out)
+		batch = batch[:0]
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.writeHydrated(batch, out); err != nil {
+		return err
+	}
+	return out.finish()
+}
+
+// matches applies the search of the listing, on the localized and the
+// original title.
+func (f *ExportFilter) matches(m *model.Movie) bool {
+	if strings.TrimSpace(f.Search) == "" {
+		return true
+	}
+	response := newMovieResponse(m)
+	response.Localize(f.Languages, f.DefaultLanguage)
+	return matchesSearch(response, f.Search)
+}
+
+// exportSort takes the sort parameter of the listing to the stored fields.
+func exportSort(sortBy string) (bson.D, error) {
+	order := 1
+	if strings.HasPrefix(sortBy, "-") {
+		order = -1
+	}
+	switch field := strings.TrimPrefix(sortBy, "-"); field {
+	case "":
+		return nil, nil
+	case "title", "release_year":
+		return bson.D{{Key: field, Value: order}}, nil
+	}
+	return nil, model.ErrInvalidSort
+}
+
+func (s *ExportService) writeHydrated(batch []*model.Movie, out *recordWriter) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var ids []primitive.ObjectID
+	for _, m := range batch {
+		m.SyncCrew()
+		if !m.DirectorID.IsZero() {
+			ids = append(ids, m.DirectorID)
+		}
+		ids = append(ids, m.Actors...)
+	}
+	people, err := s.people.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	names := make(map[primitive.ObjectID]string, len(people))
+	for i := range people {
+		names[people[i].ID] = people[i].FullName()
+	}
+
+	for _, m := range batch {
+		record := model.NewMovieRecord(m)
+		record.DirectorName = names[m.DirectorID]
+		record.ActorNames = make([]string, 0, len(m.Actors))
+		for _, id := range m.Actors {
+			record.ActorNames = append(record.ActorNames, names[id])
+		}
+		if err := out.write(record, record.HydratedCSVRow()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recordWriter hides the difference between the CSV and JSON Lines output.
+// Nothing reaches w before the first record, so a failing query can still be
+// answered with an error status.
+type recordWriter struct {
+	csv     *csv.Writer
+	json    *json.Encoder
+	columns []string
+	count   int
+}
+
+func newRecordWriter(format string, columns []string, w io.Writer) *recordWriter {
+	if format == model.FormatCSV {
+		return &recordWriter{csv: csv.NewWriter(w), columns: columns}
+	}
+	return &recordWriter{json: json.NewEncoder(w)}
+}
+
+func (r *recordWriter) write(record interface{}, row []string) error {
+	if r.count == 0 && r.csv != nil {
+		if err := r.csv.Write(r.columns); err != nil {
+			return err
+		}
+	}
+	r.count++
+	if r.csv == nil {
+		return r.json.Encode(record)
+	}
+	if err := r.csv.Write(row); err != nil {
+		return err
+	}
+	if r.count%hydrationBatch == 0 {
+		r.csv.Flush()
+		return r.csv.Error()
+	}
+	return nil
+}
+
+// finish writes the CSV header for empty exports and flushes what is left.
+func (r *recordWriter) finish() error {
+	if r.csv == nil {
+		return nil
+	}
+	if r.count == 0 {
+		if err := r.csv.Write(r.columns); err != nil {
+			return err
+		}
+	}
+	r.csv.Flush()
+	return r.csv.Error()
+}
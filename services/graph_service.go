@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"gin-demo/model"
+	"gin-demo/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// graphBatch is how many actors one lookup of the path search asks for.
+const graphBatch = 100
+
+// ErrNoActorPath is returned when two actors are not connected within the
+// requested number of movies.
+var ErrNoActorPath = errors.New("no path between the actors within the given depth")
+
+type IGraphService interface {
+	CoStars(actorID primitive.ObjectID, limit int64, audience *model.Audience) ([]model.CoStar, error)
+	ShortestPath(from, to primitive.ObjectID, depth int, audience *model.Audience) (*model.ActorPath, error)
+}
+
+type GraphService struct {
+	repo        repository.IGraphRepository
+	actors      repository.IActorRepository
+	maxDepth    int
+	maxFrontier int
+}
+
+func NewGraphService(
+	repo repository.IGraphRepository,
+	actors repository.IActorRepository,
+	maxDepth, maxFrontier int,
+) IGraphService {
+	return &GraphService{repo: repo, actors: actors, maxDepth: maxDepth, maxFrontier: maxFrontier}
+}
+
+// CoStars and ShortestPath only go through the movies the audience may see.
+func (s *GraphService) CoStars(actorID primitive.ObjectID, limit int64, audience *model.Audience) ([]model.CoStar, error) {
+	actor, err := s.actors.GetByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	coStars, err := s.repo.CoStars(actor.ID, limit, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(coStars))
+	for _, c := range coStars {
+		ids = append(ids, c.ActorID)
+	}
+	actors, err := s.actors.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*model.Actor, len(actors))
+	for i := range actors {
+		byID[actors[i].ID] = &actors[i]
+	}
+	for i := range coStars {
+		coStars[i].Actor = byID[coStars[i].ActorID]
+	}
+	return coStars, nil
+}
+
+// ShortestPath finds the shortest actor–movie–actor chain. depth is capped by
+// the configured maximum, zero means the maximum, and a hop reaching more
+// actors than the configured frontier fails with model.ErrPathSearchTooWide.
+func (s *GraphService) ShortestPath(from, to primitive.ObjectID, depth int, audience *model.Audience) (*model.ActorPath, error) {
+	if depth <= 0 || depth > s.maxDepth {
+		depth = s.maxDepth
+	}
+	if _, err := s.actors.GetByID(from); err != nil {
+		return nil, err
+	}
+	if _, err := s.actors.GetByID(to); err != nil {
+		return nil, err
+	}
+
+	moviesWith := func(actorIDs []primitive.ObjectID) ([]model.Movie, error) {
+		return s.repo.MoviesWithActors(actorIDs, audience)
+	}
+	ids, movies, found, err := model.ShortestActorPath(from, to, depth, s.maxFrontier, graphBatch, moviesWith)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNoActorPath
+	}
+
+	people, err := s.actors.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]model.Actor, len(people))
+	for _, p := range people {
+		byID[p.ID] = p
+	}
+
+	path := &model.ActorPath{
+		Degrees: len(movies),
+		Actors:  make([]model.Actor, 0, len(ids)),
+		Movies:  make([]model.MovieLink, 0, len(movies)),
+	}
+	for _, id := range ids {
+		actor, ok := byID[id]
+		if !ok {
+			actor = model.Actor{ID: id}
+		}
+		path.Actors = append(path.Actors, actor)
+	}
+	for _, m := range movies {
+		path.Movies = append(path.Movies, model.MovieLink{ID: m.ID, Title: m.Title, ReleaseYear: m.ReleaseYear})
+	}
+	return path, nil
+}
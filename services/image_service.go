@@ -0,0 +1,212 @@
+package services
+
+import (
+	"gin-demo/imaging"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"gin-demo/storage"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IImageService interface {
+	UploadPoster(movieID primitive.ObjectID, data []byte, editor string) (*model.ImageSet, error)
+	UploadHeadshot(
+		department string,
+		personID primitive.ObjectID,
+		data []byte,
+		editor string,
+	) (*model.ImageSet, error)
+	UploadArtwork(collectionID primitive.ObjectID, data []byte, editor string) (*model.ImageSet, error)
+	Remove(set *model.ImageSet)
+	Purge(entity string, id primitive.ObjectID, field string, current *model.ImageSet)
+}
+
+type ImageService struct {
+	movies      repository.IMovieRepository
+	people      repository.IPersonRepository
+	collections repository.ICollectionRepository
+	storage     storage.IStorage
+	revisions   IRevisionService
+}
+
+func NewImageService(
+	movies repository.IMovieRepository,
+	people repository.IPersonRepository,
+	collections repository.ICollectionRepository,
+	files storage.IStorage,
+	revisions IRevisionService,
+) IImageService {
+	return &ImageService{
+		movies:      movies,
+		people:      people,
+		collections: collections,
+		storage:     files,
+		revisions:   revisions,
+	}
+}
+
+// UploadPoster stores a new poster for the movie. The old files are kept:
+// the revision recorded for the upload still points to them, so reverting it
+// brings the old poster back. Purge deletes them with the movie.
+func (s *ImageService) UploadPoster(movieID primitive.ObjectID, data []byte, editor string) (*model.ImageSet, error) {
+	before, err := s.movies.GetByID(movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := s.store("movies/"+movieID.Hex()+"/poster", data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.movies.Update(movieID, bson.M{"poster": set}, model.AnyVersion); err != nil {
+		s.Remove(set)
+		return nil, err
+	}
+
+	after := orNil(s.movies.GetByID(movieID))
+	s.revisions.Record(model.EntityMovie, movieID, model.RevisionUpdate, editor, before, after)
+	return set, nil
+}
+
+// UploadHeadshot stores a new headshot for the person, keeping the old files
+// for its revisions like UploadPoster does. A non-empty department limits the upload to people credited in it.
+func (s *ImageService) UploadHeadshot(
+	department string,
+	personID primitive.ObjectID,
+	data []byte,
+	editor string,
+) (*model.ImageSet, error) {
+	before, err := s.people.GetByID(personID)
+	if err != nil {
+		return nil, err
+	}
+	if department != "" && !before.HasDepartment(department) {
+		return nil, model.NotFound("person")
+	}
+
+	set, err := s.store("people/"+before.ID.Hex()+"/headshot", data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.people.Update(before.ID, bson.M{"headshot": set}, model.AnyVersion); err != nil {
+		s.Remove(set)
+		return nil, err
+	}
+
+	after := orNil(s.people.GetByID(before.ID))
+	s.revisions.Record(model.EntityPerson, before.ID, model.RevisionUpdate, editor, before, after)
+	return set, nil
+}
+
+// UploadArtwork stores new artwork for the collection, keeping the old files
+// for its revisions like UploadPoster does.
+func (s *ImageService) UploadArtwork(collectionID primitive.ObjectID, data []byte, editor string) (*model.ImageSet, error) {
+	before, err := s.collections.GetByID(collectionID)
+	if err != nil {
+		return nil, err
+	}
+
+	set, err := s.store("collections/"+collectionID.Hex()+"/artwork", data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.collections.Update(collectionID, bson.M{"artwork": set}, model.AnyVersion); err != nil {
+		s.Remove(set)
+		return nil, err
+	}
+
+	after := orNil(s.collections.GetByID(collectionID))
+	s.revisions.Record(model.EntityCollection, collectionID, model.RevisionUpdate, editor, before, after)
+	return set, nil
+}
+
+// Remove deletes every file of the set. Only call it for files no revision
+// refers to, such as an upload that was never saved. It is best effort: a
+// file that cannot be deleted is left behind rather than failing the
+// request.
+func (s *ImageService) Remove(set *model.ImageSet) {
+	for _, key := range set.Keys() {
+		s.storage.Delete(key)
+	}
+}
+
+// Purge deletes the current images of a document leaving for good and every
+// earlier one its revisions kept, which nothing can bring back once it is
+// gone. Like Remove it is best effort.
+func (s *ImageService) Purge(entity string, id primitive.ObjectID, field string, current *model.ImageSet) {
+	keys := map[string]bool{}
+	for _, key := range current.Keys() {
+		keys[key] = true
+	}
+	history, err := s.revisions.History(entity, id)
+	if err != nil {
+		log.Printf("could not list the images of %s %s: %s", entity, id.Hex(), err)
+	}
+	for i := range history {
+		for _, key := range history[i].ImageKeys(field) {
+			keys[key] = true
+		}
+	}
+	for key := range keys {
+		if key != "" {
+			s.storage.Delete(key)
+		}
+	}
+}
+
+// store checks the upload, keeps it as it is and adds one resized variant per
+// thumbnail width. Every file gets a fresh name so caches never serve an old
+// image under a new URL.
+func (s *ImageService) store(prefix string, data []byte) (*model.ImageSet, error) {
+	img, contentType, err := imaging.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	name := prefix + "-" + primitive.NewObjectID().Hex()
+	bounds := img.Bounds()
+	set := &model.ImageSet{
+		Original: model.Image{
+			Key:    name + imaging.Extension(contentType),
+			Width:  bounds.Dx(),
+			Height: bounds.Dy(),
+		},
+		Thumbnails: map[string]model.Image{},
+		UploadedAt: time.Now().UTC(),
+	}
+	if err := s.put(&set.Original, data, contentType); err != nil {
+		return nil, err
+	}
+
+	for variant, width := range imaging.ThumbnailWidths {
+		thumb := imaging.Resize(img, width)
+		encoded, thumbType, ext, err := imaging.Encode(thumb, contentType)
+		if err != nil {
+			s.Remove(set)
+			return nil, err
+		}
+		image := model.Image{
+			Key:    name + "-" + variant + ext,
+			Width:  thumb.Bounds().Dx(),
+			Height: thumb.Bounds().Dy(),
+		}
+		if err := s.put(&image, encoded, thumbType); err != nil {
+			s.Remove(set)
+			return nil, err
+		}
+		set.Thumbnails[variant] = image
+	}
+	return set, nil
+}
+
+func (s *ImageService) put(image *model.Image, data []byte, contentType string) error {
+	if err := s.storage.Put(image.Key, data, contentType); err != nil {
+		return err
+	}
+	image.URL = s.storage.URL(image.Key)
+	return nil
+}
@@ -0,0 +1,649 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"gin-demo/utils"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IImportService interface {
+	Import(kind, format, mode string, dryRun bool, input io.Reader, role, editor string) (*model.ImportReport, error)
+}
+
+type ImportService struct {
+	repo      repository.IImportRepository
+	revisions IRevisionService
+}
+
+func NewImportService(repo repository.IImportRepository, revisions IRevisionService) IImportService {
+	return &ImportService{repo: repo, revisions: revisions}
+}
+
+// Import reads a people or movies file and writes the rows that pass
+// validation. Rows with problems are listed in the report and skipped; with
+// dryRun nothing is written and the report says what would have happened.
+// Problems with the request or the file wrap model.ErrInvalidImport; when
+// the database fails instead, the report tells what was written before.
+// Every record written gets a revision by editor, and the role decides, as
+// for any other edit, whether updated movies go back to draft.
+func (s *ImportService) Import(
+	kind, format, mode string,
+	dryRun bool,
+	input io.Reader,
+	role, editor string,
+) (*model.ImportReport, error) {
+	if format != model.FormatCSV && format != model.FormatJSONL {
+		return nil, fmt.Errorf("%w: unsupported format %q", model.ErrInvalidImport, format)
+	}
+	if mode == "" {
+		mode = model.ImportModeInsert
+	}
+	if mode != model.ImportModeInsert && mode != model.ImportModeUpsert {
+		return nil, fmt.Errorf("%w: unsupported mode %q", model.ErrInvalidImport, mode)
+	}
+
+	report := &model.ImportReport{Kind: kind, Format: format, Mode: mode, DryRun: dryRun, Errors: []model.ImportRowError{}}
+
+	switch kind {
+	case model.ImportKindPeople:
+		records, err := readRecords(format, input, report, func(c model.CSVColumns, row []string) (*model.PersonRecord, error) {
+			return model.PersonRecordFromCSV(c, row), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return report, s.importPeople(records, report, editor)
+	case model.ImportKindMovies:
+		records, err := readRecords(format, input, report, model.MovieRecordFromCSV)
+		if err != nil {
+			return nil, err
+		}
+		return report, s.importMovies(records, report, role, editor)
+	default:
+		return nil, fmt.Errorf("%w: unsupported import kind %q", model.ErrInvalidImport, kind)
+	}
+}
+
+type numbered[T any] struct {
+	line   int
+	record *T
+}
+
+// readRecords decodes CSV (with a header row) or JSON Lines input. Rows that
+// cannot be decoded are reported against their line and left out.
+func readRecords[T any](
+	format string,
+	input io.Reader,
+	report *model.ImportReport,
+	fromCSV func(model.CSVColumns, []string) (*T, error),
+) ([]numbered[T], error) {
+	var records []numbered[T]
+
+	if format == model.FormatJSONL {
+		scanner := bufio.NewScanner(input)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			report.Rows++
+			var record T
+			if err := json.Unmarshal([]byte(text), &record); err != nil {
+				report.AddError(line, "invalid JSON: %v", err)
+				continue
+			}
+			records = append(records, numbered[T]{line: line, record: &record})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidImport, err)
+		}
+		return records, nil
+	}
+
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", model.ErrInvalidImport, err)
+	}
+	columns := model.NewCSVColumns(header)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				report.Rows++
+				report.AddError(parseErr.Line, "invalid CSV: %v", parseErr.Err)
+				continue
+			}
+			return nil, fmt.Errorf("%w: %v", model.ErrInvalidImport, err)
+		}
+		report.Rows++
+		line, _ := reader.FieldPos(0)
+		record, err := fromCSV(columns, row)
+		if err != nil {
+			report.AddError(line, "%v", err)
+			continue
+		}
+		records = append(records, numbered[T]{line: line, record: record})
+	}
+	return records, nil
+}
+
+func (s *ImportService) importPeople(
+	records []numbered[model.PersonRecord],
+	report *model.ImportReport,
+	editor string,
+) error {
+	existing, err := s.repo.PeopleIndex()
+	if err != nil {
+		return err
+	}
+	byID := map[primitive.ObjectID]*model.Person{}
+	byExternal := map[string]*model.Person{}
+	byKey := map[string]*model.Person{}
+	for i := range existing {
+		p := &existing[i]
+		byID[p.ID] = p
+		if p.ExternalID != "" {
+			byExternal[p.ExternalID] = p
+		}
+		byKey[p.IdentityKey()] = p
+	}
+
+	seen := map[string]int{}
+	var inserts, updates []model.Person
+
+	for _, n := range records {
+		person, err := personFromRecord(n.record)
+		if err != nil {
+			report.AddError(n.line, "%v", err)
+			continue
+		}
+
+		keys := []string{"key:" + person.IdentityKey()}
+		if !person.ID.IsZero() {
+			keys = append(keys, "id:"+person.ID.Hex())
+		}
+		if person.ExternalID != "" {
+			keys = append(keys, "ext:"+person.ExternalID)
+		}
+		if line, dup := firstSeen(seen, keys); dup {
+			report.AddError(n.line, "duplicate of line %d", line)
+			continue
+		}
+		markSeen(seen, keys, n.line)
+
+		match := byID[person.ID]
+		if match == nil && person.ExternalID != "" {
+			match = byExternal[person.ExternalID]
+		}
+		if match == nil {
+			match = byKey[person.IdentityKey()]
+		}
+
+		if match != nil {
+			if report.Mode == model.ImportModeInsert {
+				report.AddError(n.line, "person already exists with id %s", match.ID.Hex())
+				continue
+			}
+			report.Valid++
+			person.ID = match.ID
+			updates = append(updates, *person)
+			continue
+		}
+		report.Valid++
+		if person.ID.IsZero() {
+			person.ID = primitive.NewObjectID()
+		}
+		inserts = append(inserts, *person)
+	}
+
+	report.Inserted = len(inserts)
+	report.Updated = len(updates)
+	if report.DryRun {
+		return nil
+	}
+	var before []model.Person
+	if len(updates) > 0 {
+		if before, err = s.repo.People(personIDs(updates)); err != nil {
+			return err
+		}
+	}
+	if report.Mode == model.ImportModeInsert {
+		report.Updated = 0
+		report.Inserted, err = s.repo.InsertPeople(inserts)
+	} else {
+		report.Inserted, report.Updated, err = s.repo.UpsertPeople(inserts, updates)
+	}
+	if err != nil {
+		return err
+	}
+	after, err := s.repo.People(personIDs(append(inserts, updates...)))
+	if err != nil {
+		return err
+	}
+	recordImport(s.revisions, model.EntityPerson, editor, before, after, func(p *model.Person) primitive.ObjectID { return p.ID })
+	return nil
+}
+
+func personIDs(people []model.Person) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(people))
+	for _, p := range people {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+func personFromRecord(r *model.PersonRecord) (*model.Person, error) {
+	person := &model.Person{
+		ExternalID:  strings.TrimSpace(r.ExternalID),
+		FirstName:   strings.TrimSpace(r.FirstName),
+		LastName:    strings.TrimSpace(r.LastName),
+		Departments: []string{},
+	}
+	if person.FirstName == "" || person.LastName == "" {
+		return nil, fmt.Errorf("first_name and last_name are required")
+	}
+	if r.ID != "" {
+		id, err := primitive.ObjectIDFromHex(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("id %q is not a valid ObjectID", r.ID)
+		}
+		person.ID = id
+	}
+	if r.BirthDate != "" {
+		t, err := utils.ParseDate(r.BirthDate)
+		if err != nil {
+			return nil, fmt.Errorf("birth_date must be yyyy-mm-dd")
+		}
+		person.BirthDate = t
+	}
+	for _, d := range r.Departments {
+		if !model.ValidDepartment(d) {
+			return nil, fmt.Errorf("unknown department %q", d)
+		}
+		if !person.HasDepartment(d) {
+			person.Departments = append(person.Departments, d)
+		}
+	}
+	return person, nil
+}
+
+func (s *ImportService) importMovies(
+	records []numbered[model.MovieRecord],
+	report *model.ImportReport,
+	role, editor string,
+) error {
+	people, err := s.repo.PeopleIndex()
+	if err != nil {
+		return err
+	}
+	resolver := newPersonResolver(people)
+
+	existing, err := s.repo.MovieIndex()
+	if err != nil {
+		return err
+	}
+	byID := map[primitive.ObjectID]*model.Movie{}
+	byExternal := map[string]*model.Movie{}
+	byKey := map[string]*model.Movie{}
+	for i := range existing {
+		m := &existing[i]
+		byID[m.ID] = m
+		if m.ExternalID != "" {
+			byExternal[m.ExternalID] = m
+		}
+		byKey[movieKey(m.Title, m.ReleaseYear)] = m
+	}
+
+	seen := map[string]int{}
+	var inserts, updates []model.Movie
+
+	for _, n := range records {
+		movie, err := movieFromRecord(n.record, resolver)
+		if err != nil {
+			report.AddError(n.line, "%v", err)
+			continue
+		}
+
+		keys := []string{"key:" + movieKey(movie.Title, movie.ReleaseYear)}
+		if !movie.ID.IsZero() {
+			keys = append(keys, "id:"+movie.ID.Hex())
+		}
+		if movie.ExternalID != "" {
+			keys = append(keys, "ext:"+movie.ExternalID)
+		}
+		if line, dup := firstSeen(seen, keys); dup {
+			report.AddError(n.line, "duplicate of line %d", line)
+			continue
+		}
+		markSeen(seen, keys, n.line)
+
+		match := byID[movie.ID]
+		if match == nil && movie.ExternalID != "" {
+			match = byExternal[movie.ExternalID]
+		}
+		if match == nil {
+			match = byKey[movieKey(movie.Title, movie.ReleaseYear)]
+		}
+
+		if match != nil {
+			if report.Mode == model.ImportModeInsert {
+				report.AddError(n.line, "movie already exists with id %s", match.ID.Hex())
+				continue
+			}
+			report.Valid++
+			movie.ID = match.ID
+			keepCredits(movie, match, n.record)
+			if movie.ReleaseYear == 0 {
+				movie.ReleaseYear = match.ReleaseYear
+			}
+			// Like any other edit, this one may send the movie back to draft.
+			if stored := *match; stored.Redraft(role) {
+				movie.Status = stored.Status
+				movie.PublishAt = stored.PublishAt
+				movie.ReviewComment = stored.ReviewComment
+			}
+			updates = append(updates, *movie)
+			continue
+		}
+		report.Valid++
+		if movie.ID.IsZero() {
+			movie.ID = primitive.NewObjectID()
+		}
+		// New movies go through review like those created one at a time,
+		// updated ones keep their place in the workflow.
+		movie.Status = model.StatusDraft
+		inserts = append(inserts, *movie)
+	}
+
+	report.Inserted = len(inserts)
+	report.Updated = len(updates)
+	if report.DryRun {
+		return nil
+	}
+	var before []model.Movie
+	if len(updates) > 0 {
+		if before, err = s.repo.Movies(movieIDs(updates)); err != nil {
+			return err
+		}
+	}
+	if report.Mode == model.ImportModeInsert {
+		report.Updated = 0
+		report.Inserted, err = s.repo.InsertMovies(inserts)
+	} else {
+		report.Inserted, report.Updated, err = s.repo.UpsertMovies(inserts, updates)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.AddDepartments(creditDepartments(append(inserts, updates...))); err != nil {
+		return err
+	}
+	after, err := s.repo.Movies(movieIDs(append(inserts, updates...)))
+	if err != nil {
+		return err
+	}
+	recordImport(s.revisions, model.EntityMovie, editor, before, after, func(m *model.Movie) primitive.ObjectID { return m.ID })
+	return nil
+}
+
+func movieIDs(movies []model.Movie) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// recordImport records a revision for each record an import wrote: an
+// update for those it found stored before and a create for the others.
+func recordImport[T any](
+	revisions IRevisionService,
+	entity, editor string,
+	before, after []T,
+	idOf func(*T) primitive.ObjectID,
+) {
+	stored := make(map[primitive.ObjectID]*T, len(before))
+	for i := range before {
+		stored[idOf(&before[i])] = &before[i]
+	}
+	for i := range after {
+		id := idOf(&after[i])
+		if previous, ok := stored[id]; ok {
+			revisions.Record(entity, id, model.RevisionUpdate, editor, previous, &after[i])
+			continue
+		}
+		revisions.Record(entity, id, model.RevisionCreate, editor, nil, &after[i])
+	}
+}
+
+// keepCredits fills in the credits an upsert row left out from the stored
+// movie, so a file without a director, cast or crew column does not wipe
+// them.
+func keepCredits(movie, stored *model.Movie, r *model.MovieRecord) {
+	merged := *stored
+	merged.Crew = append([]model.CrewMember{}, stored.Crew...)
+	merged.SyncCrew()
+	if len(r.Crew) > 0 {
+		crew := []model.CrewMember{}
+		for _, c := range merged.Crew {
+			if mainCredit(c) {
+				crew = append(crew, c)
+			}
+		}
+		for _, c := range movie.Crew {
+			if !mainCredit(c) {
+				crew = append(crew, c)
+			}
+		}
+		merged.Crew = crew
+	}
+	if r.Director != "" {
+		merged.SetDirector(movie.DirectorID)
+	}
+	if len(r.Actors) > 0 {
+		merged.SetCast(movie.Actors)
+	}
+	merged.SyncCrew()
+	movie.DirectorID = merged.DirectorID
+	movie.Actors = merged.Actors
+	movie.Crew = merged.Crew
+}
+
+// mainCredit tells the credits that have their own columns, the director
+// and the cast, from the ones in the crew column.
+func mainCredit(c model.CrewMember) bool {
+	return c.Department == model.DepartmentActing ||
+		(c.Department == model.DepartmentDirecting && c.Job == model.JobDirector)
+}
+
+// creditDepartments lists the departments each person is credited in.
+func creditDepartments(movies []model.Movie) map[primitive.ObjectID][]string {
+	departments := map[primitive.ObjectID][]string{}
+	for _, m := range movies {
+		for _, c := range m.Crew {
+			if !slices.Contains(departments[c.PersonID], c.Department) {
+				departments[c.PersonID] = append(departments[c.PersonID], c.Department)
+			}
+		}
+	}
+	return departments
+}
+
+func movieFromRecord(r *model.MovieRecord, resolver *personResolver) (*model.Movie, error) {
+	movie := &model.Movie{
+		ExternalID:  strings.TrimSpace(r.ExternalID),
+		Title:       strings.TrimSpace(r.Title),
+		ReleaseYear: r.ReleaseYear,
+	}
+	if movie.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if movie.ReleaseYear < 0 {
+		return nil, fmt.Errorf("release_year cannot be negative")
+	}
+	if r.ID != "" {
+		id, err := primitive.ObjectIDFromHex(r.ID)
+		if err != nil {
+			return nil, fmt.Errorf("id %q is not a valid ObjectID", r.ID)
+		}
+		movie.ID = id
+	}
+	if err := movieDetailsFromRecord(movie, r); err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	resolve := func(ref string) primitive.ObjectID {
+		id, err := resolver.resolve(ref)
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+		return id
+	}
+
+	if r.Director != "" {
+		movie.SetDirector(resolve(r.Director))
+	}
+	cast := make([]primitive.ObjectID, 0, len(r.Actors))
+	for _, ref := range r.Actors {
+		cast = append(cast, resolve(ref))
+	}
+	movie.SetCast(cast)
+	for _, c := range r.Crew {
+		if !model.ValidDepartment(c.Department) {
+			problems = append(problems, fmt.Sprintf("unknown department %q", c.Department))
+			continue
+		}
+		movie.Crew = append(movie.Crew, model.CrewMember{
+			PersonID:   resolve(c.Person),
+			Department: c.Department,
+			Job:        c.Job,
+		})
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "; "))
+	}
+
+	movie.SyncCrew()
+	return movie, nil
+}
+
+// movieDetailsFromRecord checks and copies what a record says about the
+// movie besides its credits, the way a PATCH of the movie would.
+func movieDetailsFromRecord(movie *model.Movie, r *model.MovieRecord) error {
+	movie.Overview = strings.TrimSpace(r.Overview)
+	movie.Genres = model.NormalizeGenres(r.Genres)
+	if r.OriginalLanguage != "" {
+		locale, err := model.NormalizeLocale(r.OriginalLanguage)
+		if err != nil {
+			return fmt.Errorf("original_language %q is not a language tag", r.OriginalLanguage)
+		}
+		movie.OriginalLanguage = locale
+	}
+	releases, err := model.NormalizeReleases(r.Releases)
+	if err != nil {
+		return err
+	}
+	movie.SetReleases(releases)
+	for locale, t := range r.Translations {
+		normalized, err := model.NormalizeLocale(locale)
+		if err != nil {
+			return fmt.Errorf("translation locale %q is not a language tag", locale)
+		}
+		if strings.TrimSpace(t.Title) == "" {
+			return fmt.Errorf("translation %s has no title", normalized)
+		}
+		if movie.Translations == nil {
+			movie.Translations = map[string]model.Translation{}
+		}
+		movie.Translations[normalized] = t
+	}
+	return nil
+}
+
+func movieKey(title string, year int) string {
+	return strings.ToLower(strings.TrimSpace(title)) + "|" + strconv.Itoa(year)
+}
+
+func firstSeen(seen map[string]int, keys []string) (int, bool) {
+	for _, k := range keys {
+		if line, ok := seen[k]; ok {
+			return line, true
+		}
+	}
+	return 0, false
+}
+
+func markSeen(seen map[string]int, keys []string, line int) {
+	for _, k := range keys {
+		seen[k] = line
+	}
+}
+
+// personResolver turns the references used in import files into person IDs.
+// A reference is tried as an ObjectID, then as an external ID, then as a
+// full name; a name shared by several people is rejected as ambiguous.
+type personResolver struct {
+	ids      map[primitive.ObjectID]bool
+	external map[string]primitive.ObjectID
+	names    map[string][]primitive.ObjectID
+}
+
+func newPersonResolver(people []model.Person) *personResolver {
+	r := &personResolver{
+		ids:      map[primitive.ObjectID]bool{},
+		external: map[string]primitive.ObjectID{},
+		names:    map[string][]primitive.ObjectID{},
+	}
+	for _, p := range people {
+		r.ids[p.ID] = true
+		if p.ExternalID != "" {
+			r.external[p.ExternalID] = p.ID
+		}
+		name := strings.ToLower(p.FullName())
+		r.names[name] = append(r.names[name], p.ID)
+	}
+	return r
+}
+
+func (r *personResolver) resolve(ref string) (primitive.ObjectID, error) {
+	ref = strings.TrimSpace(ref)
+	if id, err := primitive.ObjectIDFromHex(ref); err == nil && r.ids[id] {
+		return id, nil
+	}
+	if id, ok := r.external[ref]; ok {
+		return id, nil
+	}
+	matches := r.names[strings.ToLower(strings.Join(strings.Fields(ref), " "))]
+	switch len(matches) {
+	case 0:
+		return primitive.NilObjectID, fmt.Errorf("unknown person %q", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return primitive.NilObjectID, fmt.Errorf("person %q is ambiguous, use an id or external_id", ref)
+	}
+}
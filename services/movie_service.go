@@ -1,80 +1,180 @@
 package services
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"gin-demo/model"
 	"gin-demo/repository"
 	"gin-demo/utils"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/text/language"
 )
 
 type IMovieService interface {
-	Create(movie *model.Movie) (primitive.ObjectID, error)
-	GetByID(id primitive.ObjectID) (*model.MovieResponse, error)
-	GetAll() ([]model.MovieResponse, error)
-	Update(id primitive.ObjectID, update bson.M) error
-	Delete(id primitive.ObjectID) error
+	Create(movie *model.Movie, editor string) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID, audience *model.Audience) (*model.MovieResponse, error)
+	GetAll(query model.MovieListQuery) ([]model.MovieResponse, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, role, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	Patch(
+		id primitive.ObjectID,
+		contentType string,
+		patch []byte,
+		version int64,
+		role string,
+		editor string,
+	) (*model.MovieResponse, error)
+	Translations(id primitive.ObjectID) (*model.Movie, error)
+	SetTranslation(
+		id primitive.ObjectID,
+		locale string,
+		translation *model.Translation,
+		version int64,
+		role string,
+		editor string,
+	) (*model.Movie, error)
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, role, editor string) (*model.MovieResponse, error)
+	Transition(
+		id primitive.ObjectID,
+		transition model.Transition,
+		role string,
+		version int64,
+		editor string,
+	) (*model.MovieResponse, error)
+	ResolveSlug(slug string, audience *model.Audience) (*model.SlugMatch, error)
 	GetByDirector(
 		directorID primitive.ObjectID,
 		pagination *utils.Pagination,
 		projection bson.M,
+		audience *model.Audience,
+		country string,
 	) ([]bson.M, int64, error)
 	GetByActor(
-		directorID primitive.ObjectID,
+		actorID primitive.ObjectID,
 		pagination *utils.Pagination,
 		projection bson.M,
+		audience *model.Audience,
+		country string,
 	) ([]bson.M, int64, error)
+	Filmography(personID primitive.ObjectID, department string, audience *model.Audience) (*model.Filmography, error)
 	hydrateRawMovies(
 		rawMovies []bson.M,
 		projection bson.M,
 		opts repository.HydrationOptions,
+		country string,
 	) ([]bson.M, error)
 }
 
 type MovieService struct {
-	repo     repository.IMovieRepository
-	hydrator *repository.MovieHydrator
+	repo      repository.IMovieRepository
+	hydrator  *repository.MovieHydrator
+	revisions IRevisionService
+	images    IImageService
+	awards    repository.IAwardRepository
 }
 
-func NewMovieService(repo repository.IMovieRepository, hydrator *repository.MovieHydrator) IMovieService {
-	return &MovieService{repo: repo, hydrator: hydrator}
+func NewMovieService(
+	repo repository.IMovieRepository,
+	hydrator *repository.MovieHydrator,
+	revisions IRevisionService,
+	images IImageService,
+	awards repository.IAwardRepository,
+) IMovieService {
+	return &MovieService{repo: repo, hydrator: hydrator, revisions: revisions, images: images, awards: awards}
 }
 
-func (s *MovieService) Create(movie *model.Movie) (primitive.ObjectID, error) {
-	return s.repo.Create(movie)
+// Create stores the movie as a draft, whatever status it came with. It only
+// reaches viewers once it went through review.
+func (s *MovieService) Create(movie *model.Movie, editor string) (primitive.ObjectID, error) {
+	movie.Status = model.StatusDraft
+	movie.PublishAt = nil
+	movie.ReviewComment = ""
+	movie.SyncCrew()
+	if err := s.checkCredits(movie, nil); err != nil {
+		return primitive.NilObjectID, err
+	}
+	movie.Genres = model.NormalizeGenres(movie.Genres)
+	movie.SetReleases(movie.Releases)
+	id, err := s.repo.Create(movie)
+	if err != nil {
+		return id, err
+	}
+	s.revisions.Record(model.EntityMovie, id, model.RevisionCreate, editor, nil, movie)
+	return id, nil
 }
 
-func (s *MovieService) GetByID(id primitive.ObjectID) (*model.MovieResponse, error) {
+// GetByID reports movies the audience may not see as not found, so their
+// existence does not leak either.
+func (s *MovieService) GetByID(id primitive.ObjectID, audience *model.Audience) (*model.MovieResponse, error) {
 	movie, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
+	if !audience.Allows(movie) {
+		return nil, model.NotFound("movie")
+	}
 	hydratorOption := repository.HydrationOptions{
-		ForceDirector: true,
-		ForceActors:   true,
+		ForceDirector:    true,
+		ForceActors:      true,
+		ForceCrew:        true,
+		ForceCollections: true,
 	}
 	err = s.hydrator.Hydrate(movie, nil, hydratorOption)
 	if err != nil {
 		return nil, err
 	}
-	movieResponse := &model.MovieResponse{
+	return newMovieResponse(movie), nil
+}
+
+func newMovieResponse(movie *model.Movie) *model.MovieResponse {
+	return &model.MovieResponse{
+		ID:            movie.ID,
+		Slug:          movie.Slug,
 		Title:         movie.Title,
+		Overview:      movie.Overview,
 		ReleaseYear:   movie.ReleaseYear,
+		Releases:      movie.Releases,
+		Genres:        movie.Genres,
+		DirectorID:    movie.DirectorID,
+		Actors:        movie.Actors,
 		Director:      movie.Director,
 		ActorsDetails: movie.ActorsDetails,
+		Crew:          movie.Crew,
+		Version:       movie.Version,
+		Poster:        movie.Poster,
+		Collections:   movie.Collections,
+		Status:        movie.WorkflowStatus(),
+		PublishAt:     movie.PublishAt,
+		ReviewComment: movie.ReviewComment,
+
+		OriginalLanguage: movie.OriginalLanguage,
+		Translations:     movie.Translations,
 	}
-	return movieResponse, nil
 }
 
-func (s *MovieService) GetAll() ([]model.MovieResponse, error) {
+// GetAll lists the movies the query's audience may see, localized for its
+// languages, keeping those whose localized or original title contains the
+// search text and that match the award filter.
+func (s *MovieService) GetAll(query model.MovieListQuery) ([]model.MovieResponse, error) {
 	movies, err := s.repo.GetAll()
 	if err != nil {
 		return nil, err
 	}
+	if query.Awards != nil {
+		if movies, err = s.awardedMovies(movies, query.Awards); err != nil {
+			return nil, err
+		}
+	}
 	hydratorOption := repository.HydrationOptions{
 		ForceDirector: true,
 		ForceActors:   true,
+		ForceCrew:     true,
 	}
 	for i := range movies {
 		if err := s.hydrator.Hydrate(&movies[i], nil, hydratorOption); err != nil {
@@ -83,38 +183,401 @@ func (s *MovieService) GetAll() ([]model.MovieResponse, error) {
 	}
 
 	movieResponses := make([]model.MovieResponse, 0, len(movies))
-
 	for _, movie := range movies {
-		movieResponses = append(movieResponses, model.MovieResponse{
-			Title:         movie.Title,
-			ReleaseYear:   movie.ReleaseYear,
-			Director:      movie.Director,
-			ActorsDetails: movie.ActorsDetails,
-		})
+		if !query.Audience.Allows(&movie) {
+			continue
+		}
+		response := newMovieResponse(&movie)
+		response.Localize(query.Languages, query.DefaultLanguage)
+		if query.Country != "" {
+			response.LocalRelease = model.LocalRelease(response.Releases, query.Country)
+		}
+		if !matchesSearch(response, query.Search) {
+			continue
+		}
+		movieResponses = append(movieResponses, *response)
 	}
 
+	collation := language.Make(query.DefaultLanguage)
+	if len(query.Languages) > 0 {
+		collation = query.Languages[0]
+	}
+	if err := model.SortMovies(movieResponses, query.Sort, collation); err != nil {
+		return nil, err
+	}
 	return movieResponses, nil
 }
 
-func (s *MovieService) Update(id primitive.ObjectID, update bson.M) error {
-	return s.repo.Update(id, update)
+// matchesSearch tells whether the localized or the original title of the
+// movie contains the search text, ignoring case. Any movie matches an empty
+// search.
+func matchesSearch(movie *model.MovieResponse, search string) bool {
+	search = strings.ToLower(strings.TrimSpace(search))
+	return search == "" ||
+		strings.Contains(strings.ToLower(movie.Title), search) ||
+		strings.Contains(strings.ToLower(movie.OriginalTitle), search)
+}
+
+func (s *MovieService) awardedMovies(movies []model.Movie, filter *model.AwardFilter) ([]model.Movie, error) {
+	ids, err := s.awards.Nominated(filter, "movie_id")
+	if err != nil {
+		return nil, err
+	}
+	nominated := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		nominated[id] = true
+	}
+	kept := make([]model.Movie, 0, len(movies))
+	for _, m := range movies {
+		if nominated[m.ID] {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
 }
 
-func (s *MovieService) Delete(id primitive.ObjectID) error {
-	return s.repo.Delete(id)
+// Update takes edits of published movies by editors back to draft, see
+// model.Movie.Redraft.
+func (s *MovieService) Update(id primitive.ObjectID, update bson.M, version int64, role, editor string) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	redraft(update, before, role)
+
+	if releases, ok := update["releases"].([]model.Release); ok {
+		movie := *before
+		movie.SetReleases(releases)
+		update["release_year"] = movie.ReleaseYear
+	}
+
+	crew, hasCrew := update["crew"]
+	directorID, hasDirector := update["director_id"]
+	actors, hasActors := update["actors"]
+
+	// Credits can be changed either through the crew list or through the
+	// legacy director_id/actors fields, so all three are rewritten together.
+	if hasCrew || hasDirector || hasActors {
+		movie := *before
+		if hasCrew {
+			movie.Crew = crew.([]model.CrewMember)
+		} else {
+			movie.SyncCrew()
+			if hasDirector {
+				movie.SetDirector(directorID.(primitive.ObjectID))
+			}
+			if hasActors {
+				movie.SetCast(actors.([]primitive.ObjectID))
+			}
+		}
+		movie.SyncCrew()
+		if err := s.checkCredits(&movie, before); err != nil {
+			return err
+		}
+		update["crew"] = movie.Crew
+		update["director_id"] = movie.DirectorID
+		update["actors"] = movie.Actors
+
+		// The credits were worked out from before, so they must not land on
+		// top of a movie that changed in the meantime.
+		if version == model.AnyVersion {
+			version = before.Version
+		}
+	}
+
+	if err := s.repo.Update(id, update, version); err != nil {
+		return err
+	}
+	after := orNil(s.repo.GetByID(id))
+	s.revisions.Record(model.EntityMovie, id, model.RevisionUpdate, editor, before, after)
+	return nil
 }
 
+// checkCredits refuses new credits of people who do not exist or are in the
+// trash, and gives everybody credited the department of their credit, so
+// the movie also shows under /actor and /director. Credits the movie had
+// before are kept even if their person is gone, so the movie stays editable.
+func (s *MovieService) checkCredits(movie, before *model.Movie) error {
+	credited := map[primitive.ObjectID]bool{}
+	if before != nil {
+		for _, id := range before.PeopleIDs() {
+			credited[id] = true
+		}
+	}
+	ids := movie.PeopleIDs()
+	people, err := s.hydrator.PeopleRepo.GetByIDs(ids)
+	if err != nil {
+		return err
+	}
+	byID := make(map[primitive.ObjectID]*model.Person, len(people))
+	for i := range people {
+		byID[people[i].ID] = &people[i]
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if byID[id] == nil && !credited[id] {
+			missing = append(missing, id.Hex())
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: credited people not found: %s", model.ErrInvalidDocument, strings.Join(missing, ", "))
+	}
+
+	for _, c := range movie.Crew {
+		person := byID[c.PersonID]
+		if person == nil || person.HasDepartment(c.Department) {
+			continue
+		}
+		if err := s.hydrator.PeopleRepo.AddDepartment(c.PersonID, c.Department); err != nil {
+			return err
+		}
+		person.Departments = append(person.Departments, c.Department)
+	}
+	return nil
+}
+
+// Patch applies a merge patch or JSON Patch to the stored movie and writes
+// the result back as a whole once it validates. Like Update, it takes edits
+// of published movies by editors back to draft.
+func (s *MovieService) Patch(
+	id primitive.ObjectID,
+	contentType string,
+	patch []byte,
+	version int64,
+	role string,
+	editor string,
+) (*model.MovieResponse, error) {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != model.AnyVersion && version != before.Version {
+		return nil, model.ErrVersionMismatch
+	}
+
+	doc, err := json.Marshal(model.NewMoviePatch(before))
+	if err != nil {
+		return nil, err
+	}
+	patched, err := utils.ApplyPatch(contentType, doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	var result model.MoviePatch
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidDocument, err.Error())
+	}
+
+	movie := *before
+	if err := result.ApplyTo(&movie); err != nil {
+		return nil, err
+	}
+	if err := s.checkCredits(&movie, before); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"external_id":       movie.ExternalID,
+		"title":             movie.Title,
+		"overview":          movie.Overview,
+		"original_language": movie.OriginalLanguage,
+		"release_year":      movie.ReleaseYear,
+		"releases":          movie.Releases,
+		"genres":            movie.Genres,
+		"director_id":       movie.DirectorID,
+		"actors":            movie.Actors,
+		"crew":              movie.Crew,
+	}
+	redraft(update, before, role)
+	if err := s.repo.Update(id, update, before.Version); err != nil {
+		return nil, err
+	}
+	after := orNil(s.repo.GetByID(id))
+	s.revisions.Record(model.EntityMovie, id, model.RevisionUpdate, editor, before, after)
+	return s.GetByID(id, nil)
+}
+
+// Delete moves the movie to the trash. Its poster and its places in
+// collections and awards are kept until it is purged, so a restore brings
+// all of it back.
+func (s *MovieService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, version, editor); err != nil {
+		return err
+	}
+	s.revisions.Record(model.EntityMovie, id, model.RevisionDelete, editor, before, nil)
+	return nil
+}
+
+// Translations returns the movie so callers see its original language and
+// version next to the translations.
+func (s *MovieService) Translations(id primitive.ObjectID) (*model.Movie, error) {
+	return s.repo.GetByID(id)
+}
+
+// SetTranslation adds or replaces the translation for one locale, or removes
+// it when translation is nil. Like Update, it takes edits of published
+// movies by editors back to draft.
+func (s *MovieService) SetTranslation(
+	id primitive.ObjectID,
+	locale string,
+	translation *model.Translation,
+	version int64,
+	role string,
+	editor string,
+) (*model.Movie, error) {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != model.AnyVersion && version != before.Version {
+		return nil, model.ErrVersionMismatch
+	}
+
+	translations := make(map[string]model.Translation, len(before.Translations)+1)
+	for l, t := range before.Translations {
+		translations[l] = t
+	}
+	if translation == nil {
+		if _, ok := translations[locale]; !ok {
+			return nil, fmt.Errorf("translation not found for given locale")
+		}
+		delete(translations, locale)
+	} else {
+		translations[locale] = *translation
+	}
+
+	update := bson.M{"translations": translations}
+	redraft(update, before, role)
+	if err := s.repo.Update(id, update, before.Version); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntityMovie, id, model.RevisionUpdate, editor, before, after)
+	return after, nil
+}
+
+func (s *MovieService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return s.revisions.History(model.EntityMovie, id)
+}
+
+// Revert puts the movie back the way it was after the chosen revision. Its
+// place in the workflow is left alone, only Transition moves it there, but
+// like any edit by an editor it takes a published movie back to draft. A
+// movie that no longer exists comes back as a draft.
+func (s *MovieService) Revert(id, revisionID primitive.ObjectID, role, editor string) (*model.MovieResponse, error) {
+	revision, err := s.revisions.Get(model.EntityMovie, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	doc := restorable(revision)
+	current, err := s.repo.GetByID(id)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return nil, err
+	}
+	movie := model.Movie{Status: model.StatusDraft}
+	if current != nil {
+		movie = *current
+		movie.Redraft(role)
+	}
+	keepWorkflow(doc, &movie)
+	before := orNil(current, err)
+	if err := s.repo.Restore(id, doc); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntityMovie, id, model.RevisionRevert, editor, before, after)
+	return s.GetByID(id, nil)
+}
+
+// Transition moves the movie on in the workflow on behalf of a user with
+// the given role.
+func (s *MovieService) Transition(
+	id primitive.ObjectID,
+	transition model.Transition,
+	role string,
+	version int64,
+	editor string,
+) (*model.MovieResponse, error) {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	movie := *before
+	if err := movie.Apply(transition, role, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"status":         movie.Status,
+		"publish_at":     movie.PublishAt,
+		"review_comment": movie.ReviewComment,
+	}
+	if err := s.repo.Update(id, update, version); err != nil {
+		return nil, err
+	}
+	after := orNil(s.repo.GetByID(id))
+	s.revisions.Record(model.EntityMovie, id, model.RevisionUpdate, editor, before, after)
+	return s.GetByID(id, nil)
+}
+
+// redraft adds the way back to draft to the update of a movie when the
+// editor's role calls for it.
+func redraft(update bson.M, before *model.Movie, role string) {
+	movie := *before
+	if movie.Redraft(role) {
+		update["status"] = movie.Status
+		update["publish_at"] = movie.PublishAt
+		update["review_comment"] = movie.ReviewComment
+	}
+}
+
+// keepWorkflow copies the movie's workflow fields into a snapshot that is
+// about to replace it, dropping those the movie does not have.
+func keepWorkflow(doc bson.M, movie *model.Movie) {
+	delete(doc, "status")
+	delete(doc, "publish_at")
+	delete(doc, "review_comment")
+	if movie.Status != "" {
+		doc["status"] = movie.Status
+	}
+	if movie.PublishAt != nil {
+		doc["publish_at"] = movie.PublishAt
+	}
+	if movie.ReviewComment != "" {
+		doc["review_comment"] = movie.ReviewComment
+	}
+}
+
+// GetByActor lists the movies a page at a time. A non-empty country adds
+// each movie's release there as local_release.
 func (s *MovieService) GetByActor(
 	actorID primitive.ObjectID,
 	pagination *utils.Pagination,
 	projection bson.M,
+	audience *model.Audience,
+	country string,
 ) ([]bson.M, int64, error) {
 
-	totalRows, err := s.repo.CountByActorID(actorID)
+	totalRows, err := s.repo.CountByActorID(actorID, audience)
 	if err != nil {
 		return nil, 0, err
 	}
-	rawMovies, err := s.repo.GetByActor(actorID, pagination, projection)
+	read, dropReleases := projection, false
+	if country != "" {
+		read, dropReleases = withReleases(projection)
+	}
+	rawMovies, err := s.repo.GetByActor(actorID, pagination, read, audience)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -123,21 +586,37 @@ func (s *MovieService) GetByActor(
 		ForceActors: true,
 	}
 
-	hydrated, err := s.hydrateRawMovies(rawMovies, projection, opts)
-	return hydrated, totalRows, err
+	hydrated, err := s.hydrateRawMovies(rawMovies, projection, opts, country)
+	if err != nil {
+		return nil, 0, err
+	}
+	if dropReleases {
+		for _, m := range hydrated {
+			delete(m, "releases")
+		}
+	}
+	return hydrated, totalRows, nil
 }
 
+// GetByDirector lists the movies a page at a time. A non-empty country adds
+// each movie's release there as local_release.
 func (s *MovieService) GetByDirector(
 	directorID primitive.ObjectID,
 	pagination *utils.Pagination,
 	projection bson.M,
+	audience *model.Audience,
+	country string,
 ) ([]bson.M, int64, error) {
 
-	totalRows, err := s.repo.CountByDirectorID(directorID)
+	totalRows, err := s.repo.CountByDirectorID(directorID, audience)
 	if err != nil {
 		return nil, 0, err
 	}
-	rawMovies, err := s.repo.GetByDirector(directorID, pagination, projection)
+	read, dropReleases := projection, false
+	if country != "" {
+		read, dropReleases = withReleases(projection)
+	}
+	rawMovies, err := s.repo.GetByDirector(directorID, pagination, read, audience)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -146,14 +625,52 @@ func (s *MovieService) GetByDirector(
 		ForceDirector: true,
 	}
 
-	hydrated, err := s.hydrateRawMovies(rawMovies, projection, opts)
-	return hydrated, totalRows, err
+	hydrated, err := s.hydrateRawMovies(rawMovies, projection, opts, country)
+	if err != nil {
+		return nil, 0, err
+	}
+	if dropReleases {
+		for _, m := range hydrated {
+			delete(m, "releases")
+		}
+	}
+	return hydrated, totalRows, nil
+}
+
+// Filmography returns the credits of a person in one department. People who
+// never worked in it are reported as not found, like the scoped repositories
+// do. Movies the audience may not see are left out.
+func (s *MovieService) Filmography(
+	personID primitive.ObjectID,
+	department string,
+	audience *model.Audience,
+) (*model.Filmography, error) {
+	person, err := s.hydrator.PeopleRepo.GetByID(personID)
+	if err != nil {
+		return nil, err
+	}
+	if !person.HasDepartment(department) {
+		return nil, model.NotFound("person")
+	}
+
+	filmography, err := s.repo.Filmography(person.ID, department, audience)
+	if err != nil {
+		return nil, err
+	}
+	awards, err := s.awards.GetByPerson(person.ID)
+	if err != nil {
+		return nil, err
+	}
+	filmography.AddAwards(model.AwardEntries(awards, model.NominatedPerson(person.ID)))
+	filmography.Person = person
+	return filmography, nil
 }
 
 func (s *MovieService) hydrateRawMovies(
 	rawMovies []bson.M,
 	projection bson.M,
 	opts repository.HydrationOptions,
+	country string,
 ) ([]bson.M, error) {
 
 	hydratedMovies := make([]bson.M, 0, len(rawMovies))
@@ -176,6 +693,16 @@ func (s *MovieService) hydrateRawMovies(
 			raw["actors_details"] = m.ActorsDetails
 		}
 
+		if _, ok := raw["crew"]; ok {
+			raw["crew"] = m.Crew
+		}
+
+		if country != "" {
+			if local := model.LocalRelease(m.Releases, country); local != nil {
+				raw["local_release"] = local
+			}
+		}
+
 		delete(raw, "director_id")
 		delete(raw, "actors")
 
@@ -184,3 +711,49 @@ func (s *MovieService) hydrateRawMovies(
 
 	return hydratedMovies, nil
 }
+
+// withReleases makes sure a projection reads the releases, which the local
+// release is worked out from. It reports whether they had to be added, in
+// which case they are not part of the response.
+func withReleases(projection bson.M) (bson.M, bool) {
+	if len(projection) == 0 {
+		return projection, false
+	}
+	read := make(bson.M, len(projection)+1)
+	including := false
+	for field, v := range projection {
+		read[field] = v
+		if v == 1 {
+			including = true
+		}
+	}
+	if including {
+		if _, ok := read["releases"]; ok {
+			return read, false
+		}
+		read["releases"] = 1
+		return read, true
+	}
+	if _, ok := read["releases"]; !ok {
+		return read, false
+	}
+	delete(read, "releases")
+	if len(read) == 0 {
+		return nil, true
+	}
+	return read, true
+}
+
+// ResolveSlug finds the movie a slug or one of its aliases belongs to. A movie
+// the audience may not see is not found, so neither its id nor its current
+// slug leaks through a redirect.
+func (s *MovieService) ResolveSlug(slug string, audience *model.Audience) (*model.SlugMatch, error) {
+	match, err := s.repo.ResolveSlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.GetByID(match.ID, audience); err != nil {
+		return nil, err
+	}
+	return match, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IOrganizationService interface {
+	Create(org *model.Organization, editor string) (primitive.ObjectID, error)
+	GetAll() ([]model.Organization, error)
+	Get(slug string) (*model.Organization, error)
+	ForUser(email string) ([]model.Organization, error)
+	Members(slug string) ([]model.User, error)
+	AddMember(slug string, userID primitive.ObjectID, role, editor string) (*model.User, error)
+	RemoveMember(slug string, userID primitive.ObjectID, editor string) (*model.User, error)
+	Resolve(email, requested string) (*model.Membership, error)
+}
+
+// OrganizationService manages the organizations and who belongs to them.
+// Both live in the shared database; the catalogs themselves do not.
+type OrganizationService struct {
+	repo      repository.IOrganizationRepository
+	users     repository.IUserRepository
+	revisions IRevisionService
+}
+
+func NewOrganizationService(
+	repo repository.IOrganizationRepository,
+	users repository.IUserRepository,
+	revisions IRevisionService,
+) IOrganizationService {
+	return &OrganizationService{repo: repo, users: users, revisions: revisions}
+}
+
+func (s *OrganizationService) Create(org *model.Organization, editor string) (primitive.ObjectID, error) {
+	if err := org.Validate(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	org.CreatedBy = editor
+	id, err := s.repo.Create(org)
+	if err != nil {
+		return id, err
+	}
+	s.revisions.Record(model.EntityOrganization, id, model.RevisionCreate, editor, nil, org)
+	return id, nil
+}
+
+func (s *OrganizationService) GetAll() ([]model.Organization, error) {
+	return s.repo.GetAll()
+}
+
+func (s *OrganizationService) Get(slug string) (*model.Organization, error) {
+	return s.repo.GetBySlug(slug)
+}
+
+// ForUser lists the organizations the user can pick from.
+func (s *OrganizationService) ForUser(email string) ([]model.Organization, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	orgs := []model.Organization{}
+	for _, slug := range user.OrganizationSlugs() {
+		org, err := s.repo.GetBySlug(slug)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+	return orgs, nil
+}
+
+func (s *OrganizationService) Members(slug string) ([]model.User, error) {
+	if _, err := s.repo.GetBySlug(slug); err != nil {
+		return nil, err
+	}
+	return s.users.FindByOrganization(slug)
+}
+
+// AddMember puts the user in the organization with the role, viewer when
+// none is given. A member keeps their place and gets the new role.
+func (s *OrganizationService) AddMember(
+	slug string,
+	userID primitive.ObjectID,
+	role, editor string,
+) (*model.User, error) {
+	if role == "" {
+		role = model.RoleViewer
+	}
+	if !model.ValidRole(role) {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidDocument, ErrInvalidRole)
+	}
+	if _, err := s.repo.GetBySlug(slug); err != nil {
+		return nil, err
+	}
+	user, err := s.users.FindById(userID)
+	if err != nil {
+		return nil, model.NotFound("user")
+	}
+	return s.setMemberships(user, user.Join(slug, role), editor)
+}
+
+// RemoveMember takes the user out of the organization. Everybody belongs
+// to some organization, so the last one of a user cannot be taken away.
+func (s *OrganizationService) RemoveMember(slug string, userID primitive.ObjectID, editor string) (*model.User, error) {
+	user, err := s.users.FindById(userID)
+	if err != nil {
+		return nil, model.NotFound("user")
+	}
+	if !user.MemberOf(slug) {
+		return nil, model.ErrNotMember
+	}
+	remaining := user.Leave(slug)
+	if len(remaining) == 0 {
+		return nil, fmt.Errorf("%w: user has to belong to at least one organization", model.ErrInvalidDocument)
+	}
+	return s.setMemberships(user, remaining, editor)
+}
+
+func (s *OrganizationService) setMemberships(
+	user *model.User,
+	memberships []model.Membership,
+	editor string,
+) (*model.User, error) {
+	before, err := userDocument(user)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.SetMemberships(user.ObjectID, memberships); err != nil {
+		return nil, err
+	}
+	updated, err := s.users.FindById(user.ObjectID)
+	if err != nil {
+		return nil, err
+	}
+	after, err := userDocument(updated)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntityUser, user.ObjectID, model.RevisionUpdate, editor, before, after)
+	return updated, nil
+}
+
+// Resolve picks the membership a request works with: the one in the
+// requested organization, or else the only one the user has. Users who
+// belong to no organization work with none.
+func (s *OrganizationService) Resolve(email, requested string) (*model.Membership, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if requested != "" {
+		membership := user.Membership(requested)
+		if membership == nil {
+			return nil, model.ErrNotMember
+		}
+		return membership, nil
+	}
+	switch len(user.Memberships) {
+	case 0:
+		return nil, model.ErrNotMember
+	case 1:
+		return &user.Memberships[0], nil
+	default:
+		return nil, model.ErrOrganizationRequired
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"gin-demo/model"
+	"gin-demo/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// personMerger is the part of the actor and director repositories needed to
+// merge duplicate records.
+type personMerger interface {
+	Merge(survivorID primitive.ObjectID, duplicates []primitive.ObjectID, merge repository.MergeFunc) (*model.Person, error)
+}
+
+// mergePeople merges the duplicates into the survivor. The records are read
+// inside the repository's transaction, so the merge works on what is stored
+// when it commits, and the revisions record the last reading, the one that
+// committed.
+func mergePeople(
+	repo personMerger,
+	revisions IRevisionService,
+	entity string,
+	survivorID primitive.ObjectID,
+	req *model.MergeRequest,
+	editor string,
+) (*model.Person, error) {
+	if err := req.Validate(survivorID); err != nil {
+		return nil, err
+	}
+
+	var before *model.Person
+	var removed []model.Person
+	merge := func(survivor *model.Person, duplicates []model.Person) (*model.Person, *model.AuditEntry) {
+		before, removed = survivor, duplicates
+		merged := req.Merge(survivor, duplicates)
+		audit := &model.AuditEntry{
+			Action:   "merge",
+			Entity:   entity,
+			EntityID: survivor.ID,
+			Editor:   editor,
+			At:       time.Now().UTC(),
+			Details: bson.M{
+				"merged_ids": req.Duplicates,
+				"rules":      req.Rules,
+				"survivor":   survivor,
+				"duplicates": duplicates,
+			},
+		}
+		return merged, audit
+	}
+	merged, err := repo.Merge(survivorID, req.Duplicates, merge)
+	if err != nil {
+		return nil, err
+	}
+	revisions.Record(model.EntityPerson, merged.ID, model.RevisionUpdate, editor, before, merged)
+	for i := range removed {
+		revisions.Record(model.EntityPerson, removed[i].ID, model.RevisionDelete, editor, &removed[i], nil)
+	}
+	return merged, nil
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// personPatcher is the part of the person, actor and director repositories
+// needed to patch a record.
+type personPatcher interface {
+	GetByID(id primitive.ObjectID) (*model.Person, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+}
+
+// patchPerson applies a merge patch or JSON Patch to the stored person and
+// writes the result back as a whole once it validates. department is the one
+// the person has to keep, empty when patching through /people.
+func patchPerson(
+	repo personPatcher,
+	revisions IRevisionService,
+	department string,
+	id primitive.ObjectID,
+	contentType string,
+	patch []byte,
+	version int64,
+	editor string,
+) (*model.Person, error) {
+	before, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != model.AnyVersion && version != before.Version {
+		return nil, model.ErrVersionMismatch
+	}
+
+	doc, err := json.Marshal(model.NewPersonPatch(before))
+	if err != nil {
+		return nil, err
+	}
+	patched, err := utils.ApplyPatch(contentType, doc, patch)
+	if err != nil {
+		return nil, err
+	}
+	var result model.PersonPatch
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return nil, fmt.Errorf("%w: %s", model.ErrInvalidDocument, err.Error())
+	}
+
+	person := *before
+	if err := result.ApplyTo(&person, department); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{
+		"external_id": person.ExternalID,
+		"first_name":  person.FirstName,
+		"last_name":   person.LastName,
+		"birth_date":  person.BirthDate,
+		"departments": person.Departments,
+	}
+	if err := repo.Update(before.ID, update, before.Version); err != nil {
+		return nil, err
+	}
+	after, err := repo.GetByID(before.ID)
+	if err != nil {
+		return nil, err
+	}
+	revisions.Record(model.EntityPerson, before.ID, model.RevisionUpdate, editor, before, after)
+	return after, nil
+}
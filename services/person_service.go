@@ -0,0 +1,95 @@
+package services
+
+import (
+	"gin-demo/model"
+	"gin-demo/repository"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IPersonService interface {
+	Create(person *model.Person, editor string) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Person, error)
+	GetAll(awards *model.AwardFilter) ([]model.Person, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	Patch(
+		id primitive.ObjectID,
+		contentType string,
+		patch []byte,
+		version int64,
+		editor string,
+	) (*model.Person, error)
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, editor string) (*model.Person, error)
+	ResolveSlug(slug string, audience *model.Audience) (*model.SlugMatch, error)
+}
+
+type PersonService struct {
+	repo      repository.IPersonRepository
+	revisions IRevisionService
+	images    IImageService
+	awards    repository.IAwardRepository
+}
+
+func NewPersonService(
+	repo repository.IPersonRepository,
+	revisions IRevisionService,
+	images IImageService,
+	awards repository.IAwardRepository,
+) IPersonService {
+	return &PersonService{repo: repo, revisions: revisions, images: images, awards: awards}
+}
+
+func (s *PersonService) Create(person *model.Person, editor string) (primitive.ObjectID, error) {
+	return createPerson(s.repo, s.revisions, person, editor)
+}
+
+func (s *PersonService) GetByID(id primitive.ObjectID) (*model.Person, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetAll lists everybody, or with a filter only those nominated for or
+// winning a matching award.
+func (s *PersonService) GetAll(awards *model.AwardFilter) ([]model.Person, error) {
+	people, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return awardedPeople(s.awards, people, awards)
+}
+
+func (s *PersonService) Update(id primitive.ObjectID, update bson.M, version int64, editor string) error {
+	return updatePerson(s.repo, s.revisions, id, update, version, editor)
+}
+
+func (s *PersonService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	return deletePerson(s.repo, s.revisions, id, version, editor)
+}
+
+func (s *PersonService) Patch(
+	id primitive.ObjectID,
+	contentType string,
+	patch []byte,
+	version int64,
+	editor string,
+) (*model.Person, error) {
+	return patchPerson(s.repo, s.revisions, "", id, contentType, patch, version, editor)
+}
+
+func (s *PersonService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return personHistory(s.revisions, id)
+}
+
+// Revert puts the person back the way they were after the chosen revision.
+func (s *PersonService) Revert(id, revisionID primitive.ObjectID, editor string) (*model.Person, error) {
+	return revertPerson(s.repo, s.revisions, id, revisionID, editor)
+}
+
+// ResolveSlug finds the person a slug belongs to. Actors and directors share
+// the people collection, so this resolves their slugs too. Nobody is hidden
+// from an audience, so it takes one only to fit SlugMiddleware.
+func (s *PersonService) ResolveSlug(slug string, _ *model.Audience) (*model.SlugMatch, error) {
+	return s.repo.ResolveSlug(slug)
+}
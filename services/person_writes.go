@@ -0,0 +1,101 @@
+package services
+
+import (
+	"gin-demo/model"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// personWriter is the part of the person, actor and director repositories
+// the services write through. The three only differ in the department their
+// repository is scoped to, so they share the writes and their revisions.
+type personWriter interface {
+	Create(person *model.Person) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Person, error)
+	Update(id primitive.ObjectID, update bson.M, version int64) error
+	Delete(id primitive.ObjectID, version int64, by string) error
+	Restore(id primitive.ObjectID, doc bson.M) error
+}
+
+func createPerson(
+	repo personWriter,
+	revisions IRevisionService,
+	person *model.Person,
+	editor string,
+) (primitive.ObjectID, error) {
+	id, err := repo.Create(person)
+	if err != nil {
+		return id, err
+	}
+	revisions.Record(model.EntityPerson, id, model.RevisionCreate, editor, nil, person)
+	return id, nil
+}
+
+func updatePerson(
+	repo personWriter,
+	revisions IRevisionService,
+	id primitive.ObjectID,
+	update bson.M,
+	version int64,
+	editor string,
+) error {
+	before, err := repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.Update(id, update, version); err != nil {
+		return err
+	}
+	after := orNil(repo.GetByID(id))
+	revisions.Record(model.EntityPerson, id, model.RevisionUpdate, editor, before, after)
+	return nil
+}
+
+// deletePerson records what is left of the person afterwards: nothing when
+// they went to the trash, the person without the department when an actor
+// or director repository only took that away.
+func deletePerson(
+	repo personWriter,
+	revisions IRevisionService,
+	id primitive.ObjectID,
+	version int64,
+	editor string,
+) error {
+	before, err := repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := repo.Delete(id, version, editor); err != nil {
+		return err
+	}
+	after := orNil(repo.GetByID(id))
+	revisions.Record(model.EntityPerson, id, model.RevisionDelete, editor, before, after)
+	return nil
+}
+
+func personHistory(revisions IRevisionService, id primitive.ObjectID) ([]model.Revision, error) {
+	return revisions.History(model.EntityPerson, id)
+}
+
+func revertPerson(
+	repo personWriter,
+	revisions IRevisionService,
+	id, revisionID primitive.ObjectID,
+	editor string,
+) (*model.Person, error) {
+	revision, err := revisions.Get(model.EntityPerson, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	before := orNil(repo.GetByID(id))
+	if err := repo.Restore(id, restorable(revision)); err != nil {
+		return nil, err
+	}
+	after, err := repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	revisions.Record(model.EntityPerson, id, model.RevisionRevert, editor, before, after)
+	return after, nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IQualityService interface {
+	Report() (*model.QualityReport, error)
+	Fix(req *model.QualityFixRequest, editor string) (*model.QualityFixResult, error)
+}
+
+// QualityService checks the catalog for broken references, duplicates and
+// missing data, and repairs what can be repaired without an editor deciding.
+type QualityService struct {
+	repo      repository.IQualityRepository
+	movies    repository.IMovieRepository
+	people    repository.IPersonRepository
+	revisions IRevisionService
+}
+
+func NewQualityService(
+	repo repository.IQualityRepository,
+	movies repository.IMovieRepository,
+	people repository.IPersonRepository,
+	revisions IRevisionService,
+) IQualityService {
+	return &QualityService{repo: repo, movies: movies, people: people, revisions: revisions}
+}
+
+func (s *QualityService) Report() (*model.QualityReport, error) {
+	movies, err := s.repo.Movies()
+	if err != nil {
+		return nil, err
+	}
+	people, err := s.repo.People()
+	if err != nil {
+		return nil, err
+	}
+	seriesPeople, err := s.repo.SeriesPeople()
+	if err != nil {
+		return nil, err
+	}
+	return model.CheckQuality(movies, people, seriesPeople, time.Now().UTC()), nil
+}
+
+// Fix repairs the fixable findings the request selects from a fresh check.
+// Duplicates are merged first, since merging moves credits between people,
+// and the catalog is checked again before the other fixes are applied.
+// Selected findings that went away with the merges are skipped.
+func (s *QualityService) Fix(req *model.QualityFixRequest, editor string) (*model.QualityFixResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	report, err := s.Report()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.QualityFixResult{Fixed: []string{}, Skipped: []string{}, Failed: map[string]string{}}
+	var selected []string
+	merged := false
+	for i := range report.Findings {
+		f := &report.Findings[i]
+		if !req.Selects(f) {
+			continue
+		}
+		if !f.Fixable {
+			result.Skipped = append(result.Skipped, f.ID)
+			continue
+		}
+		if f.Code != model.IssueDuplicatePerson {
+			selected = append(selected, f.ID)
+			continue
+		}
+		s.apply(f, editor, result)
+		merged = true
+	}
+	if len(selected) == 0 {
+		return result, nil
+	}
+
+	if merged {
+		if report, err = s.Report(); err != nil {
+			return nil, err
+		}
+	}
+	byID := make(map[string]*model.QualityFinding, len(report.Findings))
+	for i := range report.Findings {
+		byID[report.Findings[i].ID] = &report.Findings[i]
+	}
+	for _, id := range selected {
+		f, ok := byID[id]
+		if !ok {
+			result.Skipped = append(result.Skipped, id)
+			continue
+		}
+		s.apply(f, editor, result)
+	}
+	return result, nil
+}
+
+func (s *QualityService) apply(f *model.QualityFinding, editor string, result *model.QualityFixResult) {
+	var err error
+	switch f.Code {
+	case model.IssueMissingDirector, model.IssueMissingActor:
+		err = s.dropCredits(f.EntityID, f.Related, editor)
+	case model.IssueNoReleaseYear:
+		err = s.setReleaseYear(f.EntityID, editor)
+	case model.IssueIdlePerson:
+		err = s.trashPerson(f.EntityID, editor)
+	case model.IssueDuplicatePerson:
+		req := &model.MergeRequest{Duplicates: f.Related}
+		_, err = mergePeople(s.people, s.revisions, model.EntityPerson, f.EntityID, req, editor)
+	default:
+		err = fmt.Errorf("%s cannot be fixed automatically", f.Code)
+	}
+	if err != nil {
+		result.Failed[f.ID] = err.Error()
+		return
+	}
+	result.Fixed = append(result.Fixed, f.ID)
+}
+
+func (s *QualityService) dropCredits(id primitive.ObjectID, people []primitive.ObjectID, editor string) error {
+	before, err := s.movies.GetByID(id)
+	if err != nil {
+		return err
+	}
+	gone := make(map[primitive.ObjectID]bool, len(people))
+	for _, personID := range people {
+		gone[personID] = true
+	}
+
+	movie := *before
+	movie.DropPeople(gone)
+	err = s.movies.Update(id, bson.M{
+		"director_id": movie.DirectorID,
+		"actors":      movie.Actors,
+		"crew":        movie.Crew,
+	}, model.AnyVersion)
+	if err != nil {
+		return err
+	}
+	after := orNil(s.movies.GetByID(id))
+	s.revisions.Record(model.EntityMovie, id, model.RevisionUpdate, editor, before, after)
+	return nil
+}
+
+func (s *QualityService) setReleaseYear(id primitive.ObjectID, editor string) error {
+	before, err := s.movies.GetByID(id)
+	if err != nil {
+		return err
+	}
+	year := before.FirstReleaseYear()
+	if year == 0 {
+		return fmt.Errorf("%w: the movie has no dated release", model.ErrInvalidDocument)
+	}
+
+	if err := s.movies.Update(id, bson.M{"release_year": year}, model.AnyVersion); err != nil {
+		return err
+	}
+	after := orNil(s.movies.GetByID(id))
+	s.revisions.Record(model.EntityMovie, id, model.RevisionUpdate, editor, before, after)
+	return nil
+}
+
+func (s *QualityService) trashPerson(id primitive.ObjectID, editor string) error {
+	return deletePerson(s.people, s.revisions, id, model.AnyVersion, editor)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"errors"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidScore is returned for ratings outside the accepted range.
+var ErrInvalidScore = errors.New("score must be between 1 and 5")
+
+type IRatingService interface {
+	Rate(user string, movieID primitive.ObjectID, score int) (*model.Rating, error)
+	Remove(user string, movieID primitive.ObjectID) error
+	GetByUser(user string) ([]model.Rating, error)
+}
+
+type RatingService struct {
+	repo   repository.IRatingRepository
+	movies repository.IMovieRepository
+}
+
+func NewRatingService(repo repository.IRatingRepository, movies repository.IMovieRepository) IRatingService {
+	return &RatingService{repo: repo, movies: movies}
+}
+
+func (s *RatingService) Rate(user string, movieID primitive.ObjectID, score int) (*model.Rating, error) {
+	if score < model.MinRatingScore || score > model.MaxRatingScore {
+		return nil, ErrInvalidScore
+	}
+	if _, err := s.movies.GetByID(movieID); err != nil {
+		return nil, err
+	}
+	rating := &model.Rating{
+		User:    user,
+		MovieID: movieID,
+		Score:   score,
+		RatedAt: time.Now().UTC(),
+	}
+	if err := s.repo.Rate(rating); err != nil {
+		return nil, err
+	}
+	return rating, nil
+}
+
+func (s *RatingService) Remove(user string, movieID primitive.ObjectID) error {
+	return s.repo.Delete(user, movieID)
+}
+
+func (s *RatingService) GetByUser(user string) ([]model.Rating, error) {
+	return s.repo.GetByUser(user)
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// catalogGenerationKey is bumped on every catalog change. It is part of every
+// cache key, so a change makes all cached recommendations unreachable at once
+// and they simply expire.
+const catalogGenerationKey = "catalog:generation"
+
+// userGenerationKey works the same way for the recommendations of one user,
+// it is bumped when they rate a movie or change their watchlist.
+func userGenerationKey(user string) string {
+	return "recommendations:user:" + user + ":generation"
+}
+
+// candidatePool bounds how many movies are scored for one request.
+const candidatePool = 500
+
+type IRecommendationService interface {
+	Similar(movieID primitive.ObjectID, limit int, audience *model.Audience) ([]model.Recommendation, error)
+	ForUser(user string, limit int, audience *model.Audience) ([]model.Recommendation, error)
+	CatalogChanged()
+	UserChanged(user string)
+}
+
+type RecommendationService struct {
+	movies    repository.IMovieRepository
+	ratings   repository.IRatingRepository
+	watchlist repository.IWatchlistRepository
+	cache     *redis.Client
+	ttl       time.Duration
+	// organization keeps the cache keys of different catalogs apart.
+	organization string
+}
+
+func NewRecommendationService(
+	movies repository.IMovieRepository,
+	ratings repository.IRatingRepository,
+	watchlist repository.IWatchlistRepository,
+	cache *redis.Client,
+	ttl time.Duration,
+	organization string,
+) IRecommendationService {
+	return &RecommendationService{
+		movies:       movies,
+		ratings:      ratings,
+		watchlist:    watchlist,
+		cache:        cache,
+		ttl:          ttl,
+		organization: organization,
+	}
+}
+
+// Similar ranks the movies most like the given one among those the audience
+// may see.
+func (s *RecommendationService) Similar(
+	movieID primitive.ObjectID,
+	limit int,
+	audience *model.Audience,
+) ([]model.Recommendation, error) {
+	key := fmt.Sprintf("similar:%s:%s:%d", movieID.Hex(), audienceKey(audience), limit)
+	return s.cached(key, func() ([]model.Recommendation, error) {
+		target, err := s.movies.GetByID(movieID)
+		if err != nil {
+			return nil, err
+		}
+		if !target.Published(time.Now()) || !audience.Allows(target) {
+			return nil, model.NotFound("movie")
+		}
+		candidates, err := s.movies.SimilarCandidates(
+			[]model.Movie{*target},
+			[]primitive.ObjectID{target.ID},
+			candidatePool,
+			audience,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		recs := make([]model.Recommendation, 0, len(candidates))
+		for i := range candidates {
+			score, reasons := model.Similarity(target, &candidates[i])
+			if score <= 0 {
+				continue
+			}
+			recs = append(recs, model.Recommendation{
+				Movie:   movieLink(&candidates[i]),
+				Score:   score,
+				Reasons: reasons,
+			})
+		}
+		return model.RankRecommendations(recs, limit), nil
+	})
+}
+
+// ForUser recommends movies like the ones the user rated highly or put on
+// their watchlist. Movies the user already rated or listed are left out. When
+// that does not give enough results, or the user has no history yet, the
+// list is topped up with the best rated movies. Only movies the audience may
+// see are recommended.
+func (s *RecommendationService) ForUser(
+	user string,
+	limit int,
+	audience *model.Audience,
+) ([]model.Recommendation, error) {
+	key := fmt.Sprintf("user:%s:%d:%s:%d", user, s.generation(userGenerationKey(user)), audienceKey(audience), limit)
+	return s.cached(key, func() ([]model.Recommendation, error) {
+		ratings, err := s.ratings.GetByUser(user)
+		if err != nil {
+			return nil, err
+		}
+		watchlist, err := s.watchlist.GetByUser(user)
+		if err != nil {
+			return nil, err
+		}
+
+		// A 5 counts twice as much as a 4; lower scores say nothing about
+		// what the user wants to see.
+		weights := map[primitive.ObjectID]float64{}
+		var exclude, seedIDs []primitive.ObjectID
+		for _, r := range ratings {
+			exclude = append(exclude, r.MovieID)
+			if r.Score >= 4 {
+				weights[r.MovieID] += float64(r.Score - 3)
+			}
+		}
+		for _, w := range watchlist {
+			exclude = append(exclude, w.MovieID)
+			weights[w.MovieID] += 1
+		}
+		for id := range weights {
+			seedIDs = append(seedIDs, id)
+		}
+
+		seeds, err := s.movies.GetByIDs(seedIDs)
+		if err != nil {
+			return nil, err
+		}
+		candidates, err := s.movies.SimilarCandidates(seeds, exclude, candidatePool, audience)
+		if err != nil {
+			return nil, err
+		}
+
+		recs := make([]model.Recommendation, 0, len(candidates))
+		for i := range candidates {
+			total, best := 0.0, 0.0
+			var reasons []string
+			for j := range seeds {
+				score, why := model.Similarity(&seeds[j], &candidates[i])
+				score *= weights[seeds[j].ID]
+				total += score
+				if score > best {
+					best = score
+					reasons = append([]string{"similar to " + seeds[j].Title}, why...)
+				}
+			}
+			if total <= 0 {
+				continue
+			}
+			recs = append(recs, model.Recommendation{
+				Movie:   movieLink(&candidates[i]),
+				Score:   total,
+				Reasons: reasons,
+			})
+		}
+		recs = model.RankRecommendations(recs, limit)
+
+		if len(recs) < limit {
+			for _, r := range recs {
+				exclude = append(exclude, r.Movie.ID)
+			}
+			popular, err := s.topRated(exclude, limit-len(recs), audience)
+			if err != nil {
+				return nil, err
+			}
+			recs = append(recs, popular...)
+		}
+		return recs, nil
+	})
+}
+
+func (s *RecommendationService) topRated(
+	exclude []primitive.ObjectID,
+	limit int,
+	audience *model.Audience,
+) ([]model.Recommendation, error) {
+	rated, err := s.ratings.TopRated(exclude, int64(limit))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(rated))
+	for _, r := range rated {
+		ids = append(ids, r.MovieID)
+	}
+	movies, err := s.movies.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*model.Movie, len(movies))
+	for i := range movies {
+		byID[movies[i].ID] = &movies[i]
+	}
+
+	recs := make([]model.Recommendation, 0, len(rated))
+	for _, r := range rated {
+		movie, ok := byID[r.MovieID]
+		if !ok || !movie.Published(time.Now()) || !audience.Allows(movie) {
+			continue
+		}
+		recs = append(recs, model.Recommendation{
+			Movie:   movieLink(movie),
+			Score:   r.Average,
+			Reasons: []string{fmt.Sprintf("rated %.1f on average by %d users", r.Average, r.Count)},
+		})
+	}
+	return recs, nil
+}
+
+// CatalogChanged drops every cached recommendation.
+func (s *RecommendationService) CatalogChanged() {
+	if s.cache == nil {
+		return
+	}
+	s.cache.Incr(context.Background(), tenantKey(s.organization, catalogGenerationKey))
+}
+
+// UserChanged drops the cached recommendations of one user.
+func (s *RecommendationService) UserChanged(user string) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.Incr(context.Background(), tenantKey(s.organization, userGenerationKey(user)))
+}
+
+// generation reads a generation counter, zero when there is none yet or the
+// cache cannot be reached.
+func (s *RecommendationService) generation(key string) int64 {
+	if s.cache == nil {
+		return 0
+	}
+	generation, _ := s.cache.Get(context.Background(), tenantKey(s.organization, key)).Int64()
+	return generation
+}
+
+// cached keys the result by the current catalog generation.
+func (s *RecommendationService) cached(
+	key string,
+	compute func() ([]model.Recommendation, error),
+) ([]model.Recommendation, error) {
+	if s.cache == nil {
+		return compute()
+	}
+	generation, err := s.cache.Get(context.Background(), tenantKey(s.organization, catalogGenerationKey)).Int64()
+	if err != nil && err != redis.Nil {
+		return compute()
+	}
+	key = tenantKey(s.organization, fmt.Sprintf("recommendations:%d:%s", generation, key))
+	return cachedJSON(s.cache, key, s.ttl, compute)
+}
+
+// audienceKey tells apart the audiences that get different recommendations:
+// everybody without an age limit shares one, the others go by country and
+// age.
+func audienceKey(audience *model.Audience) string {
+	if audience == nil || audience.Unrestricted {
+		return "all"
+	}
+	return fmt.Sprintf("%s-%d", audience.Country, audience.MaxAge)
+}
+
+func movieLink(m *model.Movie) model.MovieLink {
+	return model.MovieLink{ID: m.ID, Title: m.Title, ReleaseYear: m.ReleaseYear}
+}
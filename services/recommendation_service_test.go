@@ -0,0 +1,184 @@
+package services_test
+
+import (
+	"gin-demo/model"
+	"gin-demo/repository"
+	services "gin-demo/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// catalogMovies answers the movie lookups of the recommendation service from
+// memory. SimilarCandidates returns every movie not excluded that the audience
+// may see.
+type catalogMovies struct {
+	repository.IMovieRepository
+	movies []model.Movie
+}
+
+func (r *catalogMovies) GetByID(id primitive.ObjectID) (*model.Movie, error) {
+	for i := range r.movies {
+		if r.movies[i].ID == id {
+			movie := r.movies[i]
+			return &movie, nil
+		}
+	}
+	return nil, model.NotFound("movie")
+}
+
+func (r *catalogMovies) GetByIDs(ids []primitive.ObjectID) ([]model.Movie, error) {
+	var out []model.Movie
+	for _, id := range ids {
+		if movie, err := r.GetByID(id); err == nil {
+			out = append(out, *movie)
+		}
+	}
+	return out, nil
+}
+
+func (r *catalogMovies) SimilarCandidates(
+	seeds []model.Movie,
+	exclude []primitive.ObjectID,
+	limit int64,
+	audience *model.Audience,
+) ([]model.Movie, error) {
+	skip := map[primitive.ObjectID]bool{}
+	for _, id := range exclude {
+		skip[id] = true
+	}
+	var out []model.Movie
+	for _, m := range r.movies {
+		if !skip[m.ID] && m.Published(time.Now()) && audience.Allows(&m) {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+type userRatings struct {
+	repository.IRatingRepository
+	ratings []model.Rating
+	top     []model.MovieRating
+}
+
+func (r *userRatings) GetByUser(user string) ([]model.Rating, error) {
+	return r.ratings, nil
+}
+
+func (r *userRatings) TopRated(exclude []primitive.ObjectID, limit int64) ([]model.MovieRating, error) {
+	skip := map[primitive.ObjectID]bool{}
+	for _, id := range exclude {
+		skip[id] = true
+	}
+	var out []model.MovieRating
+	for _, r := range r.top {
+		if !skip[r.MovieID] && int64(len(out)) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+type userWatchlist struct {
+	repository.IWatchlistRepository
+	entries []model.WatchlistEntry
+}
+
+func (r *userWatchlist) GetByUser(user string) ([]model.WatchlistEntry, error) {
+	return r.entries, nil
+}
+
+func credited(title string, director primitive.ObjectID, actors ...primitive.ObjectID) model.Movie {
+	m := model.Movie{ID: primitive.NewObjectID(), Title: title, DirectorID: director, Actors: actors}
+	m.SyncCrew()
+	return m
+}
+
+func TestRecommendationSimilar(t *testing.T) {
+	director, actor := primitive.NewObjectID(), primitive.NewObjectID()
+	target := credited("Dawn", director, actor)
+	sameDirector := credited("Noon", director)
+	sameActor := credited("Dusk", primitive.NewObjectID(), actor)
+	unrelated := credited("Night", primitive.NewObjectID())
+	draft := credited("Draft", director, actor)
+	draft.Status = model.StatusDraft
+
+	movies := &catalogMovies{movies: []model.Movie{target, sameDirector, sameActor, unrelated, draft}}
+	svc := services.NewRecommendationService(movies, &userRatings{}, &userWatchlist{}, nil, 0, "")
+
+	recs, err := svc.Similar(target.ID, 10, nil)
+
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, sameDirector.ID, recs[0].Movie.ID)
+	assert.Equal(t, []string{"same director"}, recs[0].Reasons)
+	assert.Equal(t, sameActor.ID, recs[1].Movie.ID)
+}
+
+func TestRecommendationSimilar_Audience(t *testing.T) {
+	director := primitive.NewObjectID()
+	target := credited("Dawn", director)
+	family := credited("Noon", director)
+	adult := credited("Night", director)
+	adult.Releases = []model.Release{{Country: "US", Certification: "R", MinAge: 17}}
+
+	movies := &catalogMovies{movies: []model.Movie{target, family, adult}}
+	svc := services.NewRecommendationService(movies, &userRatings{}, &userWatchlist{}, nil, 0, "")
+	child := &model.Audience{Country: "US", MaxAge: 10, Role: model.RoleViewer}
+
+	recs, err := svc.Similar(target.ID, 10, child)
+	require.NoError(t, err)
+	require.Len(t, recs, 1)
+	assert.Equal(t, family.ID, recs[0].Movie.ID)
+
+	_, err = svc.Similar(adult.ID, 10, child)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestRecommendationSimilar_UnpublishedMovie(t *testing.T) {
+	draft := credited("Draft", primitive.NewObjectID())
+	draft.Status = model.StatusDraft
+	svc := services.NewRecommendationService(
+		&catalogMovies{movies: []model.Movie{draft}}, &userRatings{}, &userWatchlist{}, nil, 0, "",
+	)
+
+	_, err := svc.Similar(draft.ID, 10, nil)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+
+	_, err = svc.Similar(primitive.NewObjectID(), 10, nil)
+	assert.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func TestRecommendationForUser(t *testing.T) {
+	director := primitive.NewObjectID()
+	loved := credited("Loved", director)
+	listed := credited("Listed", primitive.NewObjectID())
+	liked := credited("Same director", director)
+	popular := credited("Popular", primitive.NewObjectID())
+
+	movies := &catalogMovies{movies: []model.Movie{loved, listed, liked, popular}}
+	ratings := &userRatings{
+		ratings: []model.Rating{{User: "john", MovieID: loved.ID, Score: 5}},
+		top: []model.MovieRating{
+			{MovieID: loved.ID, Average: 4.8, Count: 3},
+			{MovieID: popular.ID, Average: 4.5, Count: 10},
+		},
+	}
+	watchlist := &userWatchlist{entries: []model.WatchlistEntry{{User: "john", MovieID: listed.ID}}}
+	svc := services.NewRecommendationService(movies, ratings, watchlist, nil, 0, "")
+
+	recs, err := svc.ForUser("john", 2, nil)
+
+	require.NoError(t, err)
+	require.Len(t, recs, 2)
+	assert.Equal(t, liked.ID, recs[0].Movie.ID)
+	assert.Equal(t, "similar to Loved", recs[0].Reasons[0])
+	// Nothing else is like what the user rated, the rest is topped up with
+	// the best rated movie they have not rated or listed yet.
+	assert.Equal(t, popular.ID, recs[1].Movie.ID)
+	assert.Equal(t, 4.5, recs[1].Score)
+}
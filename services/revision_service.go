@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IRevisionService interface {
+	Record(entity string, id primitive.ObjectID, action, editor string, before, after interface{})
+	History(entity string, id primitive.ObjectID) ([]model.Revision, error)
+	Get(entity string, id, revisionID primitive.ObjectID) (*model.Revision, error)
+}
+
+type RevisionService struct {
+	repo repository.IRevisionRepository
+}
+
+func NewRevisionService(repo repository.IRevisionRepository) IRevisionService {
+	return &RevisionService{repo: repo}
+}
+
+// Record stores a revision with the field-level diff between before and
+// after. Either side is nil when the document did not exist. It is called
+// once the write went through, so a revision that cannot be stored is
+// logged instead of failing a request whose change was already applied.
+func (s *RevisionService) Record(
+	entity string,
+	id primitive.ObjectID,
+	action, editor string,
+	before, after interface{},
+) {
+	if err := s.record(entity, id, action, editor, before, after); err != nil {
+		log.Printf("could not record %s revision of %s %s: %s", action, entity, id.Hex(), err)
+	}
+}
+
+func (s *RevisionService) record(
+	entity string,
+	id primitive.ObjectID,
+	action, editor string,
+	before, after interface{},
+) error {
+	beforeDoc, err := model.ToDocument(before)
+	if err != nil {
+		return err
+	}
+	afterDoc, err := model.ToDocument(after)
+	if err != nil {
+		return err
+	}
+
+	snapshot := afterDoc
+	if action == model.RevisionDelete {
+		snapshot = beforeDoc
+	}
+
+	return s.repo.Create(&model.Revision{
+		Entity:   entity,
+		EntityID: id,
+		Action:   action,
+		Editor:   editor,
+		At:       time.Now().UTC(),
+		Changes:  model.DiffDocuments(beforeDoc, afterDoc),
+		Snapshot: snapshot,
+	})
+}
+
+func (s *RevisionService) History(entity string, id primitive.ObjectID) ([]model.Revision, error) {
+	return s.repo.GetByEntity(entity, id)
+}
+
+// Get loads a revision and makes sure it belongs to the given document.
+func (s *RevisionService) Get(entity string, id, revisionID primitive.ObjectID) (*model.Revision, error) {
+	revision, err := s.repo.GetByID(revisionID)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Entity != entity || revision.EntityID != id {
+		return nil, model.NotFound("revision")
+	}
+	if revision.Snapshot == nil {
+		return nil, fmt.Errorf("%w: revision has no snapshot to restore", model.ErrInvalidDocument)
+	}
+	return revision, nil
+}
+
+// restorable copies a revision snapshot and pins it to the document ID.
+func restorable(revision *model.Revision) bson.M {
+	doc := bson.M{}
+	for k, v := range revision.Snapshot {
+		doc[k] = v
+	}
+	doc["_id"] = revision.EntityID
+	return doc
+}
+
+// orNil keeps a missing document from reaching Record as a typed nil pointer.
+func orNil[T any](v *T, err error) interface{} {
+	if err != nil || v == nil {
+		return nil
+	}
+	return v
+}
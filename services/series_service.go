@@ -0,0 +1,271 @@
+package services
+
+import (
+	"gin-demo/model"
+	"gin-demo/repository"
+	"gin-demo/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ISeriesService interface {
+	Create(series *model.Series, editor string) (primitive.ObjectID, error)
+	GetByID(id primitive.ObjectID) (*model.Series, error)
+	GetAll() ([]model.Series, error)
+	Update(id primitive.ObjectID, update bson.M, version int64, editor string) error
+	Delete(id primitive.ObjectID, version int64, editor string) error
+	PutSeason(id primitive.ObjectID, season model.Season, version int64, editor string) (*model.Series, error)
+	DeleteSeason(id primitive.ObjectID, number int, version int64, editor string) (*model.Series, error)
+	PutEpisode(
+		id primitive.ObjectID,
+		season int,
+		episode model.Episode,
+		version int64,
+		editor string,
+	) (*model.Series, error)
+	DeleteEpisode(id primitive.ObjectID, season, number int, version int64, editor string) (*model.Series, error)
+	History(id primitive.ObjectID) ([]model.Revision, error)
+	Revert(id, revisionID primitive.ObjectID, editor string) (*model.Series, error)
+	Credits(
+		personID primitive.ObjectID,
+		department string,
+		pagination *utils.Pagination,
+		audience *model.Audience,
+	) ([]model.Credit, int64, error)
+}
+
+type SeriesService struct {
+	repo      repository.ISeriesRepository
+	movies    repository.IMovieRepository
+	people    repository.IPersonRepository
+	revisions IRevisionService
+}
+
+func NewSeriesService(
+	repo repository.ISeriesRepository,
+	movies repository.IMovieRepository,
+	people repository.IPersonRepository,
+	revisions IRevisionService,
+) ISeriesService {
+	return &SeriesService{repo: repo, movies: movies, people: people, revisions: revisions}
+}
+
+func (s *SeriesService) Create(series *model.Series, editor string) (primitive.ObjectID, error) {
+	if err := series.Normalize(); err != nil {
+		return primitive.NilObjectID, err
+	}
+	id, err := s.repo.Create(series)
+	if err != nil {
+		return id, err
+	}
+	s.revisions.Record(model.EntitySeries, id, model.RevisionCreate, editor, nil, series)
+	return id, nil
+}
+
+// GetByID returns the series with everybody credited on it or its episodes
+// filled in.
+func (s *SeriesService) GetByID(id primitive.ObjectID) (*model.Series, error) {
+	series, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	people, err := s.people.GetByIDs(series.People())
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]*model.Person, len(people))
+	for i := range people {
+		byID[people[i].ID] = &people[i]
+	}
+	hydrateCrew(series.Crew, byID)
+	for i := range series.Seasons {
+		for j := range series.Seasons[i].Episodes {
+			hydrateCrew(series.Seasons[i].Episodes[j].Crew, byID)
+		}
+	}
+	return series, nil
+}
+
+func hydrateCrew(crew []model.CrewMember, people map[primitive.ObjectID]*model.Person) {
+	for i := range crew {
+		crew[i].Person = people[crew[i].PersonID]
+	}
+}
+
+func (s *SeriesService) GetAll() ([]model.Series, error) {
+	return s.repo.GetAll()
+}
+
+func (s *SeriesService) Update(id primitive.ObjectID, update bson.M, version int64, editor string) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Update(id, update, version); err != nil {
+		return err
+	}
+	after := orNil(s.repo.GetByID(id))
+	s.revisions.Record(model.EntitySeries, id, model.RevisionUpdate, editor, before, after)
+	return nil
+}
+
+func (s *SeriesService) Delete(id primitive.ObjectID, version int64, editor string) error {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id, version); err != nil {
+		return err
+	}
+	s.revisions.Record(model.EntitySeries, id, model.RevisionDelete, editor, before, nil)
+	return nil
+}
+
+// PutSeason adds the season or replaces the one with the same number.
+func (s *SeriesService) PutSeason(
+	id primitive.ObjectID,
+	season model.Season,
+	version int64,
+	editor string,
+) (*model.Series, error) {
+	return s.changeSeasons(id, version, editor, func(series *model.Series) error {
+		return series.PutSeason(season)
+	})
+}
+
+func (s *SeriesService) DeleteSeason(id primitive.ObjectID, number int, version int64, editor string) (*model.Series, error) {
+	return s.changeSeasons(id, version, editor, func(series *model.Series) error {
+		return series.RemoveSeason(number)
+	})
+}
+
+// PutEpisode adds the episode to the season or replaces the one with the
+// same number.
+func (s *SeriesService) PutEpisode(
+	id primitive.ObjectID,
+	season int,
+	episode model.Episode,
+	version int64,
+	editor string,
+) (*model.Series, error) {
+	return s.changeSeasons(id, version, editor, func(series *model.Series) error {
+		target, err := series.Season(season)
+		if err != nil {
+			return err
+		}
+		return target.PutEpisode(episode)
+	})
+}
+
+func (s *SeriesService) DeleteEpisode(
+	id primitive.ObjectID,
+	season, number int,
+	version int64,
+	editor string,
+) (*model.Series, error) {
+	return s.changeSeasons(id, version, editor, func(series *model.Series) error {
+		target, err := series.Season(season)
+		if err != nil {
+			return err
+		}
+		return target.RemoveEpisode(number)
+	})
+}
+
+// changeSeasons applies change to the stored series and writes its seasons
+// back. The write is checked against the version the client expects or,
+// without one, against the version that was read, so concurrent changes to
+// other seasons are not lost.
+func (s *SeriesService) changeSeasons(
+	id primitive.ObjectID,
+	version int64,
+	editor string,
+	change func(*model.Series) error,
+) (*model.Series, error) {
+	before, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if version != model.AnyVersion && version != before.Version {
+		return nil, model.ErrVersionMismatch
+	}
+	series := *before
+	series.Seasons = cloneSeasons(before.Seasons)
+	if err := change(&series); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(id, bson.M{"seasons": series.Seasons}, before.Version); err != nil {
+		return nil, err
+	}
+	after, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntitySeries, id, model.RevisionUpdate, editor, before, after)
+	return after, nil
+}
+
+func cloneSeasons(seasons []model.Season) []model.Season {
+	out := make([]model.Season, len(seasons))
+	for i, season := range seasons {
+		out[i] = season
+		out[i].Episodes = append([]model.Episode{}, season.Episodes...)
+	}
+	return out
+}
+
+func (s *SeriesService) History(id primitive.ObjectID) ([]model.Revision, error) {
+	return s.revisions.History(model.EntitySeries, id)
+}
+
+// Revert puts the series back the way it was after the chosen revision.
+func (s *SeriesService) Revert(id, revisionID primitive.ObjectID, editor string) (*model.Series, error) {
+	revision, err := s.revisions.Get(model.EntitySeries, id, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	before := orNil(s.repo.GetByID(id))
+	if err := s.repo.Restore(id, restorable(revision)); err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.revisions.Record(model.EntitySeries, id, model.RevisionRevert, editor, before, after)
+	return after, nil
+}
+
+// Credits lists the movies and series the person worked on in the
+// department, newest first, one page at a time. Movies the audience may not
+// see are left out.
+func (s *SeriesService) Credits(
+	personID primitive.ObjectID,
+	department string,
+	pagination *utils.Pagination,
+	audience *model.Audience,
+) ([]model.Credit, int64, error) {
+	movies, err := s.movies.GetCredited(personID, department, audience)
+	if err != nil {
+		return nil, 0, err
+	}
+	series, err := s.repo.GetByPerson(personID, department)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	credits := make([]model.Credit, 0, len(movies)+len(series))
+	for i := range movies {
+		credits = append(credits, model.MovieCredit(&movies[i], personID, department))
+	}
+	for i := range series {
+		credits = append(credits, model.SeriesCredit(&series[i], personID, department))
+	}
+	model.SortCredits(credits)
+
+	total := int64(len(credits))
+	start := min(pagination.GetOffset(), total)
+	end := min(start+pagination.Limit, total)
+	return credits[start:end], total, nil
+}
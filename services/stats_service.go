@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type IStatsService interface {
+	ReleaseYears(filter model.StatsFilter) (*model.ReleaseStats, error)
+	TopPeople(filter model.StatsFilter, department string, limit int64) ([]model.ProlificPerson, error)
+	CastSize(filter model.StatsFilter) (*model.CastSizeStats, error)
+	Partnerships(filter model.StatsFilter, limit int64) ([]model.Partnership, error)
+	Growth(filter model.StatsFilter, interval string) ([]model.GrowthPoint, error)
+}
+
+// StatsService answers the catalog statistics. The aggregations scan the
+// whole catalog, so every result is cached for ttl; the numbers may lag
+// behind the catalog by that much.
+type StatsService struct {
+	repo         repository.IStatsRepository
+	cache        *redis.Client
+	ttl          time.Duration
+	organization string
+}
+
+func NewStatsService(
+	repo repository.IStatsRepository,
+	cache *redis.Client,
+	ttl time.Duration,
+	organization string,
+) IStatsService {
+	return &StatsService{repo: repo, cache: cache, ttl: ttl, organization: organization}
+}
+
+func (s *StatsService) ReleaseYears(filter model.StatsFilter) (*model.ReleaseStats, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return cachedJSON(s.cache, s.statsKey("release-years", filter), s.ttl, func() (*model.ReleaseStats, error) {
+		return s.repo.ReleaseYears(filter)
+	})
+}
+
+func (s *StatsService) TopPeople(
+	filter model.StatsFilter,
+	department string,
+	limit int64,
+) ([]model.ProlificPerson, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	key := s.statsKey(fmt.Sprintf("top:%s:%d", department, limit), filter)
+	return cachedJSON(s.cache, key, s.ttl, func() ([]model.ProlificPerson, error) {
+		return s.repo.ProlificPeople(filter, department, limit)
+	})
+}
+
+func (s *StatsService) CastSize(filter model.StatsFilter) (*model.CastSizeStats, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	return cachedJSON(s.cache, s.statsKey("cast-size", filter), s.ttl, func() (*model.CastSizeStats, error) {
+		return s.repo.CastSize(filter)
+	})
+}
+
+func (s *StatsService) Partnerships(filter model.StatsFilter, limit int64) ([]model.Partnership, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	key := s.statsKey(fmt.Sprintf("partnerships:%d", limit), filter)
+	return cachedJSON(s.cache, key, s.ttl, func() ([]model.Partnership, error) {
+		return s.repo.Partnerships(filter, limit)
+	})
+}
+
+func (s *StatsService) Growth(filter model.StatsFilter, interval string) ([]model.GrowthPoint, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	if interval != model.GrowthByMonth && interval != model.GrowthByYear {
+		return nil, model.ErrInvalidStatsFilter
+	}
+	return cachedJSON(s.cache, s.statsKey("growth:"+interval, filter), s.ttl, func() ([]model.GrowthPoint, error) {
+		points, before, err := s.repo.Growth(filter, interval)
+		if err != nil {
+			return nil, err
+		}
+		return model.AccumulateGrowth(points, before), nil
+	})
+}
+
+// statsKey names the cached result, per organization.
+func (s *StatsService) statsKey(name string, filter model.StatsFilter) string {
+	day := func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02")
+	}
+	return tenantKey(s.organization, fmt.Sprintf("stats:%s:%s:%s:%d:%d",
+		name, day(filter.AddedFrom), day(filter.AddedTo), filter.FromYear, filter.ToYear))
+}
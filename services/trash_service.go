@@ -0,0 +1,294 @@
+package services
+
+import (
+	"fmt"
+	"gin-demo/model"
+	"gin-demo/repository"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PurgeReport counts what one purge removed for good.
+type PurgeReport struct {
+	Cutoff time.Time `json:"cutoff"`
+	Movies int       `json:"movies"`
+	People int       `json:"people"`
+	Users  int       `json:"users"`
+}
+
+type ITrashService interface {
+	List(kind string) ([]model.TrashItem, error)
+	Restore(kind string, id primitive.ObjectID, dropMissing bool, editor string) error
+	Purge() (*PurgeReport, error)
+	Schedule(interval time.Duration)
+}
+
+// TrashService looks after deleted movies, people and users: it lists
+// them, brings them back and, once the retention period is over, removes
+// the movies and people for good together with whatever still pointed at
+// them. Users are shared by all organizations, so each trash only shows its
+// own members and UserTrashService purges them once for all.
+type TrashService struct {
+	movies      repository.IMovieRepository
+	people      repository.IPersonRepository
+	users       repository.IUserRepository
+	collections repository.ICollectionRepository
+	awards      repository.IAwardRepository
+	images      IImageService
+	revisions   IRevisionService
+	retention   time.Duration
+
+	organization string
+}
+
+func NewTrashService(
+	movies repository.IMovieRepository,
+	people repository.IPersonRepository,
+	users repository.IUserRepository,
+	collections repository.ICollectionRepository,
+	awards repository.IAwardRepository,
+	images IImageService,
+	revisions IRevisionService,
+	retention time.Duration,
+	organization string,
+) ITrashService {
+	return &TrashService{
+		movies:       movies,
+		people:       people,
+		users:        users,
+		collections:  collections,
+		awards:       awards,
+		images:       images,
+		revisions:    revisions,
+		retention:    retention,
+		organization: organization,
+	}
+}
+
+// List returns what is in the trash, everything or only one kind, with the
+// items that are purged first at the top.
+func (s *TrashService) List(kind string) ([]model.TrashItem, error) {
+	items := []model.TrashItem{}
+	if kind == "" || kind == model.TrashMovie {
+		movies, err := s.movies.Trashed()
+		if err != nil {
+			return nil, err
+		}
+		for i := range movies {
+			items = append(items, movies[i].TrashItem(s.retention))
+		}
+	}
+	if kind == "" || kind == model.TrashPerson {
+		people, err := s.people.Trashed()
+		if err != nil {
+			return nil, err
+		}
+		for i := range people {
+			items = append(items, people[i].TrashItem(s.retention))
+		}
+	}
+	if kind == "" || kind == model.TrashUser {
+		users, err := s.users.Trashed()
+		if err != nil {
+			return nil, err
+		}
+		for i := range users {
+			if users[i].MemberOf(s.organization) {
+				items = append(items, users[i].TrashItem(s.retention))
+			}
+		}
+	}
+	model.SortTrash(items)
+	return items, nil
+}
+
+// Restore takes the document out of the trash. A movie is only restored
+// when everybody it credits still exists, unless dropMissing says to restore
+// it without them.
+func (s *TrashService) Restore(kind string, id primitive.ObjectID, dropMissing bool, editor string) error {
+	switch kind {
+	case model.TrashMovie:
+		return s.restoreMovie(id, dropMissing, editor)
+	case model.TrashPerson:
+		before, err := s.people.GetTrashed(id)
+		if err != nil {
+			return err
+		}
+		if err := s.people.Undelete(id); err != nil {
+			return err
+		}
+		after := orNil(s.people.GetByID(id))
+		s.revisions.Record(model.EntityPerson, id, model.RevisionRestore, editor, before, after)
+		return nil
+	case model.TrashUser:
+		user, err := s.users.GetTrashed(id)
+		if err != nil {
+			return err
+		}
+		if !user.MemberOf(s.organization) {
+			return fmt.Errorf("user not found in trash with given id")
+		}
+		if err := s.users.Undelete(id); err != nil {
+			return err
+		}
+		before, err := userDocument(user)
+		if err != nil {
+			return err
+		}
+		restored, err := s.users.FindById(id)
+		if err != nil {
+			return err
+		}
+		after, err := userDocument(restored)
+		if err != nil {
+			return err
+		}
+		s.revisions.Record(model.EntityUser, id, model.RevisionRestore, editor, before, after)
+		return nil
+	default:
+		return fmt.Errorf("unknown trash kind %q", kind)
+	}
+}
+
+func (s *TrashService) restoreMovie(id primitive.ObjectID, dropMissing bool, editor string) error {
+	before, err := s.movies.GetTrashed(id)
+	if err != nil {
+		return err
+	}
+
+	credited := before.PeopleIDs()
+	found, err := s.people.GetByIDs(credited)
+	if err != nil {
+		return err
+	}
+	exists := make(map[primitive.ObjectID]bool, len(found))
+	for i := range found {
+		exists[found[i].ID] = true
+	}
+	gone := map[primitive.ObjectID]bool{}
+	var missing []string
+	for _, personID := range credited {
+		if !exists[personID] {
+			gone[personID] = true
+			missing = append(missing, personID.Hex())
+		}
+	}
+	if len(missing) > 0 && !dropMissing {
+		return fmt.Errorf("%w: %s", model.ErrMissingReferences, strings.Join(missing, ", "))
+	}
+
+	if err := s.movies.Undelete(id); err != nil {
+		return err
+	}
+	if len(gone) > 0 {
+		movie := *before
+		movie.DropPeople(gone)
+		err := s.movies.Update(id, bson.M{
+			"director_id": movie.DirectorID,
+			"actors":      movie.Actors,
+			"crew":        movie.Crew,
+		}, model.AnyVersion)
+		if err != nil {
+			return err
+		}
+	}
+	after := orNil(s.movies.GetByID(id))
+	s.revisions.Record(model.EntityMovie, id, model.RevisionRestore, editor, before, after)
+	return nil
+}
+
+// Purge removes the movies and people that have been in the trash longer
+// than the retention period. Purged movies also leave their collections and
+// awards, and the images of purged movies and people are deleted, the ones
+// their revisions kept included.
+func (s *TrashService) Purge() (*PurgeReport, error) {
+	report := &PurgeReport{Cutoff: time.Now().UTC().Add(-s.retention)}
+
+	movies, err := s.movies.Purge(report.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for i := range movies {
+		if err := s.collections.RemoveMovie(movies[i].ID); err != nil {
+			return nil, err
+		}
+		if err := s.awards.RemoveMovie(movies[i].ID); err != nil {
+			return nil, err
+		}
+		s.images.Purge(model.EntityMovie, movies[i].ID, "poster", movies[i].Poster)
+	}
+	report.Movies = len(movies)
+
+	people, err := s.people.Purge(report.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	for i := range people {
+		s.images.Purge(model.EntityPerson, people[i].ID, "headshot", people[i].Headshot)
+	}
+	report.People = len(people)
+	return report, nil
+}
+
+// Schedule runs Purge in the background every interval for as long as the
+// process lives.
+func (s *TrashService) Schedule(interval time.Duration) {
+	schedulePurge(interval, s.Purge)
+}
+
+type IUserTrashService interface {
+	Purge() (*PurgeReport, error)
+	Schedule(interval time.Duration)
+}
+
+// UserTrashService removes the users that have been in the trash longer
+// than the retention period. Users live in the shared database, so they are
+// purged once rather than by the trash of every organization.
+type UserTrashService struct {
+	users     repository.IUserRepository
+	retention time.Duration
+}
+
+func NewUserTrashService(users repository.IUserRepository, retention time.Duration) IUserTrashService {
+	return &UserTrashService{users: users, retention: retention}
+}
+
+func (s *UserTrashService) Purge() (*PurgeReport, error) {
+	report := &PurgeReport{Cutoff: time.Now().UTC().Add(-s.retention)}
+	users, err := s.users.Purge(report.Cutoff)
+	if err != nil {
+		return nil, err
+	}
+	report.Users = len(users)
+	return report, nil
+}
+
+// Schedule runs Purge in the background every interval for as long as the
+// process lives.
+func (s *UserTrashService) Schedule(interval time.Duration) {
+	schedulePurge(interval, s.Purge)
+}
+
+// schedulePurge runs purge every interval. Failures are logged and retried
+// on the next run.
+func schedulePurge(interval time.Duration, purge func() (*PurgeReport, error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			report, err := purge()
+			if err != nil {
+				log.Printf("trash purge failed: %s", err)
+				continue
+			}
+			if report.Movies+report.People+report.Users > 0 {
+				log.Printf("trash purge removed %d movies, %d people and %d users deleted before %s",
+					report.Movies, report.People, report.Users, report.Cutoff.Format(time.RFC3339))
+			}
+		}
+	}()
+}
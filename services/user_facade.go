@@ -1,27 +1,88 @@
 package services
 
 import (
+	"errors"
 	"gin-demo/model"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrInvalidRole    = errors.New("role must be viewer, editor or admin")
+	ErrRoleNotAllowed = errors.New("only admins can register editors and admins")
+)
+
 // Implemented facade structural design pattern
 // if the user service code logic changes,
 // or our user service starts to need using more service
 // this way the code will be more cleaner and flexible
 type UserServiceFacade struct {
 	userService IUserService
+	revisions   IRevisionService
 }
 
-func NewUserServiceFacade(userService IUserService) *UserServiceFacade {
+func NewUserServiceFacade(userService IUserService, revisions IRevisionService) *UserServiceFacade {
 	return &UserServiceFacade{
 		userService: userService,
+		revisions:   revisions,
+	}
+}
+
+// Register creates the user. Users are viewers unless an admin registers
+// them with another role, and they start out in the default organization
+// with that role; only the organization endpoints add them to others.
+func (f *UserServiceFacade) Register(user *model.User, editor string) error {
+	if user.Role == "" {
+		user.Role = model.RoleViewer
+	}
+	if !model.ValidRole(user.Role) {
+		return ErrInvalidRole
+	}
+	user.Memberships = []model.Membership{{Organization: model.DefaultOrganization, Role: user.Role}}
+	if user.Role != model.RoleViewer {
+		caller, err := f.userService.GetUserByEmail(editor)
+		if err != nil || caller.Role != model.RoleAdmin {
+			return ErrRoleNotAllowed
+		}
+	}
+
+	if err := f.userService.Register(user); err != nil {
+		return err
+	}
+
+	after, err := userDocument(user)
+	if err != nil {
+		return err
+	}
+	f.revisions.Record(model.EntityUser, user.ObjectID, model.RevisionCreate, editor, nil, after)
+	return nil
+}
+
+// userDocument is the user as the history stores them. The password hash
+// never goes into the history.
+func userDocument(user *model.User) (bson.M, error) {
+	doc, err := model.ToDocument(user)
+	if err != nil {
+		return nil, err
 	}
+	delete(doc, "password")
+	return doc, nil
 }
 
-func (f *UserServiceFacade) Register(user *model.User) error {
-	return f.userService.Register(user)
+// Delete moves the user to the trash. The password hash never goes into the
+// history.
+func (f *UserServiceFacade) Delete(id primitive.ObjectID, editor string) error {
+	user, err := f.userService.Delete(id, editor)
+	if err != nil {
+		return err
+	}
+	before, err := userDocument(user)
+	if err != nil {
+		return err
+	}
+	f.revisions.Record(model.EntityUser, id, model.RevisionDelete, editor, before, nil)
+	return nil
 }
 
 func (f *UserServiceFacade) Login(req *model.UserLoginRequest) (*model.UserLoginResponse, error) {
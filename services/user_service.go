@@ -25,6 +25,7 @@ type IUserService interface {
 	GetUsers(email string) []model.User
 	GetUserById(id primitive.ObjectID) (*model.User, error)
 	GetUserByEmail(email string) (*model.User, error)
+	Delete(id primitive.ObjectID, by string) (*model.User, error)
 }
 
 type UserService struct {
@@ -59,7 +60,11 @@ func (s *UserService) Login(loginRequest *model.UserLoginRequest) (*model.UserLo
 		return nil, errors.New("invalid credentials")
 	}
 
-	accessToken, err := s.tokenStrategy.GenerateAccessToken(loginRequest.Email)
+	if loginRequest.Organization != "" && !userAuth.MemberOf(loginRequest.Organization) {
+		return nil, model.ErrNotMember
+	}
+
+	accessToken, err := s.tokenStrategy.GenerateAccessToken(loginRequest.Email, loginRequest.Organization)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
@@ -98,6 +103,22 @@ func (s *UserService) GetUserById(id primitive.ObjectID) (*model.User, error) {
 	return s.repo.FindById(id)
 }
 
+// Delete moves the user to the trash and logs them out, returning the user
+// as they were.
+func (s *UserService) Delete(id primitive.ObjectID, by string) (*model.User, error) {
+	user, err := s.repo.FindById(id)
+	if err != nil {
+		return nil, model.NotFound("user")
+	}
+	if err := s.repo.Delete(id, by); err != nil {
+		return nil, err
+	}
+	if err := s.tokenStrategy.InvalidateToken(user.Email); err != nil {
+		return nil, fmt.Errorf("failed to log out deleted user: %w", err)
+	}
+	return user, nil
+}
+
 func (s *UserService) GetUserByEmail(email string) (*model.User, error) {
 	user, err := s.repo.FindByEmail(email)
 	if err != nil {
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"gin-demo/model"
+	"gin-demo/repository"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type IWatchlistService interface {
+	Add(user string, movieID primitive.ObjectID) (*model.WatchlistEntry, error)
+	Remove(user string, movieID primitive.ObjectID) error
+	GetByUser(user string) ([]model.WatchlistEntry, error)
+}
+
+type WatchlistService struct {
+	repo   repository.IWatchlistRepository
+	movies repository.IMovieRepository
+}
+
+func NewWatchlistService(repo repository.IWatchlistRepository, movies repository.IMovieRepository) IWatchlistService {
+	return &WatchlistService{repo: repo, movies: movies}
+}
+
+func (s *WatchlistService) Add(user string, movieID primitive.ObjectID) (*model.WatchlistEntry, error) {
+	if _, err := s.movies.GetByID(movieID); err != nil {
+		return nil, err
+	}
+	entry := &model.WatchlistEntry{User: user, MovieID: movieID, AddedAt: time.Now().UTC()}
+	if err := s.repo.Add(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *WatchlistService) Remove(user string, movieID primitive.ObjectID) error {
+	return s.repo.Remove(user, movieID)
+}
+
+func (s *WatchlistService) GetByUser(user string) ([]model.WatchlistEntry, error) {
+	return s.repo.GetByUser(user)
+}
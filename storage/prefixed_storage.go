@@ -0,0 +1,27 @@
+package storage
+
+import "strings"
+
+// PrefixedStorage keeps every key of the wrapped storage under a prefix, so
+// several catalogs can share one directory or bucket without overwriting
+// each other's files. Callers keep using the keys without the prefix.
+type PrefixedStorage struct {
+	files  IStorage
+	prefix string
+}
+
+func NewPrefixedStorage(files IStorage, prefix string) IStorage {
+	return &PrefixedStorage{files: files, prefix: strings.Trim(prefix, "/") + "/"}
+}
+
+func (s *PrefixedStorage) Put(key string, data []byte, contentType string) error {
+	return s.files.Put(s.prefix+key, data, contentType)
+}
+
+func (s *PrefixedStorage) Delete(key string) error {
+	return s.files.Delete(s.prefix + key)
+}
+
+func (s *PrefixedStorage) URL(key string) string {
+	return s.files.URL(s.prefix + key)
+}
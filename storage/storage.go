@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"fmt"
+	"gin-demo/config"
+)
+
+// IStorage keeps uploaded files. Keys are slash separated paths such as
+// "movies/<id>/poster.jpg" and URL turns a key into the address clients
+// download it from.
+type IStorage interface {
+	Put(key string, data []byte, contentType string) error
+	Delete(key string) error
+	URL(key string) string
+}
+
+// NewStorage builds the storage selected by the config driver.
+func NewStorage(cfg config.StorageConfig) (IStorage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		return NewLocalStorage(cfg.Local.Path(), cfg.Local.URLPrefix()), nil
+	case "s3":
+		return NewS3Storage(cfg.S3), nil
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
@@ -0,0 +1,47 @@
+package utils_test
+
+import (
+	"gin-demo/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const movieDoc = `{"title":"Heat","release_year":1995,"actors":["a","b"],"external_id":"tt1"}`
+
+func TestApplyPatch_MergePatchClearsAndSetsZeroValues(t *testing.T) {
+	out, err := utils.ApplyPatch(utils.MergePatchContentType, []byte(movieDoc),
+		[]byte(`{"release_year":0,"actors":[],"external_id":null}`))
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Heat","release_year":0,"actors":[]}`, string(out))
+}
+
+func TestApplyPatch_JSONPatchArrayOperations(t *testing.T) {
+	out, err := utils.ApplyPatch(utils.JSONPatchContentType, []byte(movieDoc), []byte(`[
+		{"op":"test","path":"/actors/0","value":"a"},
+		{"op":"add","path":"/actors/-","value":"c"},
+		{"op":"remove","path":"/actors/0"},
+		{"op":"add","path":"/actors/1","value":"d"},
+		{"op":"move","from":"/external_id","path":"/legacy~1id"}
+	]`))
+
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"title":"Heat","release_year":1995,"actors":["b","d","c"],"legacy/id":"tt1"}`,
+		string(out))
+}
+
+func TestApplyPatch_JSONPatchErrors(t *testing.T) {
+	for name, patch := range map[string]string{
+		"failed test":        `[{"op":"test","path":"/title","value":"Ronin"}]`,
+		"missing path":       `[{"op":"replace","path":"/director","value":"x"}]`,
+		"index out of range": `[{"op":"remove","path":"/actors/2"}]`,
+		"missing value":      `[{"op":"add","path":"/title"}]`,
+		"unknown op":         `[{"op":"rename","path":"/title"}]`,
+	} {
+		_, err := utils.ApplyPatch(utils.JSONPatchContentType, []byte(movieDoc), []byte(patch))
+		assert.ErrorIs(t, err, utils.ErrInvalidPatch, name)
+	}
+}